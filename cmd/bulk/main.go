@@ -0,0 +1,162 @@
+// Command bulk imports classes into the configured store and exports classes
+// and bookings from it, for backups and migrations:
+//
+//	bulk [-config file] [-studio id] import [-mode all-or-nothing|best-effort] [-format json|csv] file
+//	bulk [-config file] [-studio id] export [-format json|csv] [-o file] classes|bookings
+//
+// The import reads standard input when file is "-", and the format defaults to
+// the file extension. Both work on the default studio unless -studio names
+// another. Stop the server before using a file store: both would write to the
+// same snapshot and log.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"glofox/config"
+	"glofox/constants"
+	newError "glofox/errors"
+	"glofox/internal/bulk"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	configPath := flag.String("config", constants.FilePath, "path of the configuration file")
+	studio := flag.String("studio", "", "id of the studio to import into or export from; the default studio when empty")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf(constants.Failepath, err)
+	}
+	repo, err := repository.Open(cfg.Storage)
+	if err != nil {
+		log.Fatalf(constants.FailStore, err)
+	}
+	studios, err := service.InitializeStudios(repo, *cfg)
+	if err != nil {
+		log.Fatalf(constants.FailStudios, err)
+	}
+	services, err := studios.Studio(*studio)
+	if err != nil {
+		log.Fatalf("studio %q: %v", *studio, err)
+	}
+
+	switch flag.Arg(0) {
+	case "import":
+		err = importClasses(services, flag.Args()[1:])
+	case "export":
+		err = export(services, flag.Args()[1:])
+	default:
+		usage()
+		err = fmt.Errorf("unknown command %q", flag.Arg(0))
+	}
+
+	// Flush durable stores before exiting
+	if closer, ok := repo.(io.Closer); ok {
+		if closeErr := closer.Close(); closeErr != nil {
+			log.Println("Error: failed to close store:", closeErr)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "usage: bulk [-config file] [-studio id] import [-mode all-or-nothing|best-effort] [-format json|csv] file")
+	fmt.Fprintln(out, "       bulk [-config file] [-studio id] export [-format json|csv] [-o file] classes|bookings")
+	flag.PrintDefaults()
+}
+
+// importClasses imports the classes of a file and prints the outcome of every row as JSON.
+func importClasses(services service.BusinessService, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	mode := flags.String("mode", "", "all-or-nothing (the default) or best-effort")
+	format := flags.String("format", "", "json or csv; defaults to the file extension")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("import takes the file to import")
+	}
+
+	path := flags.Arg(0)
+	in := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	rows, err := bulk.DecodeClasses(in, bulk.Format(*format))
+	if err != nil {
+		return err
+	}
+	result, importErr := services.ImportClasses(rows, *mode)
+	if importErr != nil && !errors.Is(importErr, newError.ErrImportRejected) {
+		return importErr
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(result); err != nil {
+		return err
+	}
+	return importErr
+}
+
+// export writes every class or booking record to a file, or to standard output.
+func export(services service.BusinessService, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", string(bulk.JSON), "json or csv")
+	output := flags.String("o", "", "file to write instead of standard output")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("export takes classes or bookings")
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch flags.Arg(0) {
+	case "classes":
+		classes, err := services.ExportClasses()
+		if err != nil {
+			return err
+		}
+		return bulk.EncodeClasses(out, bulk.Format(*format), classes)
+	case "bookings":
+		bookings, err := services.ExportBookings()
+		if err != nil {
+			return err
+		}
+		return bulk.EncodeBookings(out, bulk.Format(*format), bookings)
+	default:
+		return fmt.Errorf("unknown export %q", flags.Arg(0))
+	}
+}
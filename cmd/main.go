@@ -4,10 +4,10 @@ import (
 	"glofox/cmd/server"
 	"glofox/config"
 	"glofox/constants"
-	mapstore "glofox/core"
+	"glofox/internal/repository"
 	"glofox/internal/service"
+	"io"
 	"log"
-	"sync"
 )
 
 // main is the entry point of the application.
@@ -19,18 +19,28 @@ func main() {
 	if err != nil {
 		log.Fatalf(constants.Failepath, err)
 	}
-	// Initialize a shared mutex for synchronizing access to the map store
-	lock := &sync.Mutex{}
-
-	// Create a thread-safe map store instance
-	reqMap := mapstore.NewMuMapStore(lock)
+	// Create the repository backend selected in the configuration
+	repo, err := repository.Open(cfg.Storage)
+	if err != nil {
+		log.Fatalf(constants.FailStore, err)
+	}
 
-	// Initialize the application's business logic layer with shared state
-	services := service.InitializeService(reqMap, lock, *cfg)
+	// Initialize the business logic layer of every studio on the shared store
+	studios, err := service.InitializeStudios(repo, *cfg)
+	if err != nil {
+		log.Fatalf(constants.FailStudios, err)
+	}
 
 	// Create a new HTTP server using the configured port
 	newServer := server.NewServer(*cfg)
 
 	// Start the server and listen for incoming requests
-	newServer.RunServer(reqMap, lock, services)
+	newServer.RunServer(studios)
+
+	// Flush durable stores once the server has shut down
+	if closer, ok := repo.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Println("Error: failed to close store:", err)
+		}
+	}
 }
@@ -7,12 +7,10 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
 	"glofox/config"
-	mapstore "glofox/core"
 	route "glofox/internal/gin"
 	"glofox/internal/service"
 
@@ -21,7 +19,7 @@ import (
 
 // Server interface defines the method required to start the application server.
 type Server interface {
-	RunServer(syMap mapstore.MapStore, lock *sync.Mutex, services service.BusinessService)
+	RunServer(studios service.Studios)
 }
 
 // server is a concrete implementation of the Server interface.
@@ -32,28 +30,38 @@ type server struct {
 	http   *http.Server // Underlying HTTP server
 	Status bool         // Indicates if the server is up
 	config config.Config
+	stop   chan struct{} // Closed on shutdown to stop background jobs
 }
 
+// noShowSweepInterval is how often ended class occurrences are checked for no-shows.
+const noShowSweepInterval = time.Minute
+
 // NewServer returns a new instance of the server with the given port.
 func NewServer(cfg config.Config) Server {
 	return &server{
 		config: cfg,
+		stop:   make(chan struct{}),
 	}
 }
 
 // RunServer initializes and starts the server, and listens for termination signals
 // to perform graceful shutdown when necessary.
-func (serverInfo *server) RunServer(syMap mapstore.MapStore, lock *sync.Mutex, services service.BusinessService) {
-	serverInfo.start(syMap, lock, services)
+func (serverInfo *server) RunServer(studios service.Studios) {
+	serverInfo.start(studios)
+	go serverInfo.sweepNoShows(studios)
 	serverInfo.gracefulShutdown()
 }
 
 // start initializes the HTTP server with routing and starts it asynchronously.
-func (serverInfo *server) start(syMap mapstore.MapStore, lock *sync.Mutex, services service.BusinessService) {
+func (serverInfo *server) start(studios service.Studios) {
+	router, err := route.NewRouter(serverInfo.config, studios)
+	if err != nil {
+		log.Fatalf("Error: invalid authentication settings: %v", err)
+	}
 	serverInfo.http = &http.Server{
-		Addr:              ":" + serverInfo.config.Port,                                          // Bind server to specified port
-		Handler:           route.NewRouter(syMap, lock, serverInfo.config, services).SetRoutes(), // Set up routing
-		ReadHeaderTimeout: 20 * time.Second,                                                      // Prevent slowloris attacks by setting header timeout
+		Addr:              ":" + serverInfo.config.Port, // Bind server to specified port
+		Handler:           router.SetRoutes(),           // Set up routing
+		ReadHeaderTimeout: 20 * time.Second,             // Prevent slowloris attacks by setting header timeout
 	}
 
 	// Start server in a separate goroutine to allow graceful shutdown
@@ -72,10 +80,37 @@ func (serverInfo *server) start(syMap mapstore.MapStore, lock *sync.Mutex, servi
 	}(*serverInfo)
 }
 
+// sweepNoShows periodically marks the unchecked bookings of ended class
+// occurrences of every studio as no-shows until the server shuts down.
+func (serverInfo *server) sweepNoShows(studios service.Studios) {
+	ticker := time.NewTicker(noShowSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-serverInfo.stop:
+			return
+		case <-ticker.C:
+			for _, id := range studios.IDs() {
+				services, err := studios.Studio(id)
+				if err != nil {
+					continue
+				}
+				marked, err := services.MarkNoShows()
+				if err != nil {
+					log.Printf("Error: failed to mark no-shows of studio %q: %v\n", id, err)
+				}
+				if marked > 0 {
+					log.Printf("Marked %d bookings of studio %q as no-show\n", marked, id)
+				}
+			}
+		}
+	}
+}
+
 // listenToSignalNotification blocks until an OS shutdown signal is received.
 // It listens for SIGINT and SIGTERM.
 func listenToSignalNotification() {
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit // Block until signal is received
 }
@@ -84,6 +119,7 @@ func listenToSignalNotification() {
 // It gracefully shuts down the server, closing connections properly.
 func (serverInfo *server) gracefulShutdown() {
 	listenToSignalNotification()
+	close(serverInfo.stop)
 
 	err := serverInfo.http.Shutdown(context.Background())
 	if err != nil {
@@ -7,9 +7,112 @@ import (
 )
 
 type Config struct {
-	DateFormat string `json:"DateFormat"`
-	BaseRoute  string `json:"BaseRoute"`
-	Port       string `json:"Port"`
+	DateFormat            string         `json:"DateFormat"`
+	BaseRoute             string         `json:"BaseRoute"`
+	Port                  string         `json:"Port"`
+	Timezone              string         `json:"Timezone"`          // IANA time zone of the studio, used for classes that do not set their own
+	RefundWindowHours     int            `json:"RefundWindowHours"` // Cancellations at least this long before the class start get their credit back
+	BookingPolicy         BookingPolicy  `json:"BookingPolicy"`
+	NoShowPolicy          NoShowPolicy   `json:"NoShowPolicy"`
+	IdempotencyTTLMinutes int            `json:"IdempotencyTTLMinutes"` // Minutes a response is kept for replay under its Idempotency-Key; 0 means a day
+	Storage               StorageConfig  `json:"Storage"`
+	Auth                  AuthConfig     `json:"Auth"`
+	Studios               []StudioConfig `json:"Studios"` // Studios served under /studios/:studioId, next to the default studio
+}
+
+// StudioConfig is a studio of the franchise served by this deployment. Its
+// Timezone, DateFormat and BookingPolicy override the deployment-wide settings
+// when they are set.
+type StudioConfig struct {
+	ID            string         `json:"ID"`
+	Timezone      string         `json:"Timezone"`
+	DateFormat    string         `json:"DateFormat"`
+	BookingPolicy *BookingPolicy `json:"BookingPolicy"`
+}
+
+// ForStudio returns the configuration studio runs with: cfg with the settings
+// the studio overrides replaced.
+func (cfg Config) ForStudio(studio StudioConfig) Config {
+	if studio.Timezone != "" {
+		cfg.Timezone = studio.Timezone
+	}
+	if studio.DateFormat != "" {
+		cfg.DateFormat = studio.DateFormat
+	}
+	if studio.BookingPolicy != nil {
+		cfg.BookingPolicy = *studio.BookingPolicy
+	}
+	return cfg
+}
+
+// BookingPolicy holds the studio defaults for when classes can be booked and
+// cancelled, relative to the start of a class. Classes can override each of them.
+// OpensHoursBefore of 0 lets bookings open as soon as the class exists; zero
+// CutoffMinutes and CancelHoursBefore allow bookings and cancellations until the start.
+type BookingPolicy struct {
+	OpensHoursBefore  int `json:"OpensHoursBefore"`
+	CutoffMinutes     int `json:"CutoffMinutes"`
+	CancelHoursBefore int `json:"CancelHoursBefore"`
+}
+
+// NoShowPolicy blocks members from booking after repeated no-shows: once a
+// member has Limit no-shows within the last WindowDays days (all time when 0),
+// booking is blocked for BlockDays days after the latest of them.
+// A Limit of 0 turns the policy off.
+type NoShowPolicy struct {
+	Limit      int `json:"Limit"`
+	WindowDays int `json:"WindowDays"`
+	BlockDays  int `json:"BlockDays"`
+}
+
+// StorageConfig selects the storage backend.
+// Type is "memory" (the default), "file" or "sqlite"; Shards only applies to
+// "memory", Dir and SnapshotEvery only apply to "file" and DSN only applies to "sqlite".
+type StorageConfig struct {
+	Type          string `json:"Type"`
+	Shards        int    `json:"Shards"`
+	Dir           string `json:"Dir"`
+	SnapshotEvery int    `json:"SnapshotEvery"`
+	DSN           string `json:"DSN"`
+}
+
+// AuthConfig holds the credentials requests are authenticated with: API keys
+// for server-to-server clients and JWT bearer tokens for member apps.
+// Disabled turns authentication off, e.g. for local development.
+type AuthConfig struct {
+	Disabled bool      `json:"Disabled"`
+	APIKeys  []APIKey  `json:"APIKeys"`
+	JWT      JWTConfig `json:"JWT"`
+}
+
+// APIKey is a named API key, stored as the hex SHA-256 hash of the key.
+// Role is admin (the default) or instructor. A key naming a Studio is only
+// valid for that studio.
+type APIKey struct {
+	Name   string `json:"Name"`
+	Hash   string `json:"Hash"`
+	Role   string `json:"Role"`
+	Studio string `json:"Studio"`
+}
+
+// JWTConfig holds the keys bearer tokens are verified with. Tokens must be
+// issued by Issuer and for Audience when those are set, and LeewaySeconds
+// allows for clock skew when checking their expiry.
+type JWTConfig struct {
+	Issuer        string   `json:"Issuer"`
+	Audience      string   `json:"Audience"`
+	LeewaySeconds int      `json:"LeewaySeconds"`
+	Keys          []JWTKey `json:"Keys"`
+}
+
+// JWTKey is a key tokens can be signed with, matched on the kid of a token
+// when it has one. Algorithm is HS256, with a shared Secret of at least 32
+// bytes, or RS256, with a PEM encoded RSA PublicKey.
+type JWTKey struct {
+	ID        string `json:"ID"`
+	Algorithm string `json:"Algorithm"`
+	Secret    string `json:"Secret"`
+	PublicKey string `json:"PublicKey"`
 }
 
 var (
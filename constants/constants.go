@@ -3,6 +3,33 @@ package constants
 const (
 	FilePath      = "../config.json"
 	BookingSucces = "Booking created successfully"
+	BookingCancel = "Booking cancelled successfully"
+	BookingFetch  = "Booking fetched successfully"
+	BookingMoved  = "Booking rescheduled successfully"
+	CheckedIn     = "Booking checked in successfully"
+	RosterChecked = "Class roster checked in"
+	RosterFetched = "Class roster fetched successfully"
+	WaitlistJoin  = "Class is full, user added to the waitlist"
+	WaitlistFetch = "Waitlist position fetched successfully"
 	ClassSuccess  = "Class data saved successfully"
+	ClassFetched  = "Class data fetched successfully"
+	ClassUpdated  = "Class updated successfully"
+	ClassDeleted  = "Class deleted successfully"
+	Imported      = "Classes imported successfully"
+	MemberSaved   = "Member saved successfully"
+	MemberFetched = "Member fetched successfully"
+	MemberDeact   = "Member deactivated successfully"
+	PlanSaved     = "Plan saved successfully"
+	PlanFetched   = "Plans fetched successfully"
+	PlanPurchase  = "Plan added to the member successfully"
+	CreditFetched = "Credit balance fetched successfully"
+	LedgerFetched = "Credit ledger fetched successfully"
+	HistoryFetch  = "Member bookings fetched successfully"
+	AttendFetched = "Member attendance fetched successfully"
 	Failepath     = "Failed to load config: %v"
+	FailStore     = "Failed to open store: %v"
+	FailStudios   = "Failed to set up studios: %v"
+	StorageMemory = "memory"
+	StorageFile   = "file"
+	StorageSQLite = "sqlite"
 )
@@ -0,0 +1,324 @@
+package mapstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"glofox/models/dto"
+)
+
+const (
+	snapshotFile = "snapshot.gob" // Compacted copy of the whole map
+	logFile      = "wal.log"      // Append-only log of writes made since the snapshot
+
+	opStore  byte = 1
+	opDelete byte = 2
+
+	recordHeaderSize = 8 // 4 bytes payload length + 4 bytes CRC32 of the payload
+)
+
+// init registers the concrete types kept behind interface{} values,
+// so that gob can encode and decode them in the snapshot and the log.
+func init() {
+	gob.Register(dto.ClassInfo{})
+	gob.Register(dto.Member{})
+	gob.Register(dto.Plan{})
+	gob.Register(dto.CreditAccount{})
+	gob.Register(dto.Booking{})
+}
+
+// record is a single Store or Delete operation written to the log.
+type record struct {
+	Op    byte
+	Key   string
+	Value interface{}
+}
+
+// fileMapStore is a durable implementation of MapStore.
+// Every write is appended to a log before it is acknowledged, and the log is
+// periodically compacted into a snapshot. On start-up the snapshot is loaded
+// and the log replayed on top of it.
+type fileMapStore struct {
+	mu            sync.Mutex
+	dir           string
+	mapStore      map[string]interface{}
+	log           *os.File
+	pending       int // Number of records written to the log since the last snapshot
+	snapshotEvery int
+}
+
+// NewFileMapStore opens (or creates) a file-backed MapStore in dir.
+// A snapshot is taken after every snapshotEvery writes; zero or a negative
+// value disables compaction until the store is closed.
+func NewFileMapStore(dir string, snapshotEvery int) (MapStore, error) {
+	fmt.Println("Application is using File Backed Map Mechanism")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	store := &fileMapStore{
+		dir:           dir,
+		mapStore:      make(map[string]interface{}),
+		snapshotEvery: snapshotEvery,
+	}
+	if err := store.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := store.replayLog(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Store appends the operation to the log and then saves the value under key.
+// When the write can not be made durable the map is left untouched.
+func (r *fileMapStore) Store(key string, value interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.write(record{Op: opStore, Key: key, Value: value}); err != nil {
+		return err
+	}
+	r.mapStore[key] = value
+	return nil
+}
+
+// Load retrieves the value for a given key. Returns the value and a boolean indicating if the key exists.
+func (r *fileMapStore) Load(key string) (interface{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	val, ok := r.mapStore[key]
+	return val, ok
+}
+
+// Delete appends the operation to the log and then removes the key.
+// When the write can not be made durable the key is kept.
+func (r *fileMapStore) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.write(record{Op: opDelete, Key: key}); err != nil {
+		return err
+	}
+	delete(r.mapStore, key)
+	return nil
+}
+
+// Update atomically replaces the value of key with the result of fn and logs the write.
+// When the write can not be made durable the old value is kept and the error returned.
+func (r *fileMapStore) Update(key string, fn UpdateFunc) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.mapStore[key]
+	value, err := fn(old, exists)
+	if errors.Is(err, ErrDeleteKey) {
+		if err = r.write(record{Op: opDelete, Key: key}); err != nil {
+			return nil, err
+		}
+		delete(r.mapStore, key)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = r.write(record{Op: opStore, Key: key, Value: value}); err != nil {
+		return nil, err
+	}
+	r.mapStore[key] = value
+	return value, nil
+}
+
+// Keys returns all keys present in the map in ascending order.
+func (r *fileMapStore) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.mapStore))
+	for key := range r.mapStore {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Close takes a final snapshot and releases the log file.
+func (r *fileMapStore) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.snapshot()
+	if closeErr := r.log.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// write appends a record to the log and syncs it to disk, compacting the log
+// into a snapshot once enough records have accumulated. An error means the
+// record may not survive a restart, so the caller must not apply it.
+// A failed snapshot is only logged: the record is already durable in the log.
+// It must be called while holding r.mu.
+func (r *fileMapStore) write(rec record) error {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(&rec); err != nil {
+		return fmt.Errorf("encode store record: %w", err)
+	}
+
+	frame := make([]byte, recordHeaderSize, recordHeaderSize+payload.Len())
+	binary.BigEndian.PutUint32(frame[0:4], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload.Bytes()))
+	frame = append(frame, payload.Bytes()...)
+
+	offset, err := r.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("append store record: %w", err)
+	}
+	if _, err = r.log.Write(frame); err == nil {
+		err = r.log.Sync()
+	}
+	if err != nil {
+		// Drop whatever part of the record made it to the log, so that a
+		// restart does not replay a write that was reported as failed
+		if r.log.Truncate(offset) == nil {
+			r.log.Seek(offset, io.SeekStart)
+		}
+		return fmt.Errorf("append store record: %w", err)
+	}
+
+	r.pending++
+	if r.snapshotEvery > 0 && r.pending >= r.snapshotEvery {
+		if err := r.snapshot(); err != nil {
+			log.Println("Error: failed to snapshot store:", err)
+		}
+	}
+	return nil
+}
+
+// snapshot writes the whole map to a temporary file, atomically renames it over
+// the previous snapshot and then truncates the log. A crash before the rename
+// keeps the old snapshot plus the full log; a crash after it merely replays
+// records that the new snapshot already contains, which is harmless.
+// It must be called while holding r.mu.
+func (r *fileMapStore) snapshot() error {
+	tmp, err := os.CreateTemp(r.dir, snapshotFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = gob.NewEncoder(tmp).Encode(r.mapStore); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(r.dir, snapshotFile)); err != nil {
+		return err
+	}
+	if err = syncDir(r.dir); err != nil {
+		return err
+	}
+
+	if err = r.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = r.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r.pending = 0
+	return r.log.Sync()
+}
+
+// loadSnapshot reads the last compacted snapshot, if there is one.
+func (r *fileMapStore) loadSnapshot() error {
+	file, err := os.Open(filepath.Join(r.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gob.NewDecoder(bufio.NewReader(file)).Decode(&r.mapStore)
+}
+
+// replayLog applies every complete record of the log on top of the snapshot.
+// A torn or corrupt record at the tail, left by a crash in the middle of a
+// write, is discarded and the log is truncated back to the last good record.
+func (r *fileMapStore) replayLog() error {
+	file, err := os.OpenFile(filepath.Join(r.dir, logFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err = io.ReadFull(reader, header); err != nil {
+			break
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+		if _, err = io.ReadFull(reader, payload); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			err = errors.New("checksum mismatch")
+			break
+		}
+
+		var rec record
+		if err = gob.NewDecoder(bytes.NewReader(payload)).Decode(&rec); err != nil {
+			break
+		}
+		switch rec.Op {
+		case opStore:
+			r.mapStore[rec.Key] = rec.Value
+		case opDelete:
+			delete(r.mapStore, rec.Key)
+		}
+		offset += int64(recordHeaderSize + len(payload))
+		r.pending++
+	}
+	if !errors.Is(err, io.EOF) {
+		log.Printf("Discarding incomplete store log tail at offset %d: %v\n", offset, err)
+	}
+
+	if err = file.Truncate(offset); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	r.log = file
+	return nil
+}
+
+// syncDir flushes directory metadata so that a rename survives a crash.
+func syncDir(dir string) error {
+	handle, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+	return handle.Sync()
+}
@@ -0,0 +1,125 @@
+package mapstore
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"glofox/models/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func classInfo(capacity int, users ...string) dto.ClassInfo {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	return dto.ClassInfo{
+		AllowedCapacity: capacity,
+		StartDate:       date,
+		EndDate:         date,
+		Bookings:        map[time.Time][]string{date: users},
+		Waitlist:        make(map[time.Time][]string),
+	}
+}
+
+func TestFileMapStore_ReplaysLogOnRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	// Write a few operations without ever taking a snapshot
+	store, err := NewFileMapStore(dir, 0)
+	require.NoError(t, err)
+	store.Store("Yoga", classInfo(10, "john_doe"))
+	store.Store("Pilates", classInfo(5))
+	store.Delete("Pilates")
+	require.NoError(t, store.(*fileMapStore).log.Close())
+
+	// Reopen the store and check that the state was rebuilt from the log
+	reopened, err := NewFileMapStore(dir, 0)
+	require.NoError(t, err)
+
+	value, ok := reopened.Load("Yoga")
+	assert.True(t, ok)
+	assert.Equal(t, classInfo(10, "john_doe"), value)
+	assert.Equal(t, []string{"Yoga"}, reopened.Keys())
+}
+
+func TestFileMapStore_CompactsIntoSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	// A snapshot is taken after every two writes
+	store, err := NewFileMapStore(dir, 2)
+	require.NoError(t, err)
+	store.Store("Yoga", classInfo(10))
+	store.Store("Pilates", classInfo(5))
+	store.Store("Spin", classInfo(20))
+
+	// The log only holds the write made after the snapshot
+	assert.FileExists(t, filepath.Join(dir, snapshotFile))
+	assert.Equal(t, 1, store.(*fileMapStore).pending)
+
+	require.NoError(t, store.(io.Closer).Close())
+
+	// Closing compacts the remaining record and the store reopens intact
+	info, err := os.Stat(filepath.Join(dir, logFile))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	reopened, err := NewFileMapStore(dir, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pilates", "Spin", "Yoga"}, reopened.Keys())
+}
+
+func TestFileMapStore_DiscardsTornWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileMapStore(dir, 0)
+	require.NoError(t, err)
+	store.Store("Yoga", classInfo(10))
+	store.Store("Pilates", classInfo(5))
+	require.NoError(t, store.(*fileMapStore).log.Close())
+
+	// Simulate a crash in the middle of writing the last record
+	path := filepath.Join(dir, logFile)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	reopened, err := NewFileMapStore(dir, 0)
+	require.NoError(t, err)
+
+	// Only the complete record survives and new writes append after it
+	assert.Equal(t, []string{"Yoga"}, reopened.Keys())
+	reopened.Store("Spin", classInfo(20))
+	require.NoError(t, reopened.(*fileMapStore).log.Close())
+
+	again, err := NewFileMapStore(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Spin", "Yoga"}, again.Keys())
+}
+
+func TestFileMapStore_ReportsWritesThatAreNotDurable(t *testing.T) {
+	store, err := NewFileMapStore(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Store("Yoga", classInfo(10)))
+
+	// Once the log can no longer be written, no write is acknowledged or applied
+	require.NoError(t, store.(*fileMapStore).log.Close())
+
+	assert.Error(t, store.Store("Pilates", classInfo(5)))
+	assert.Error(t, store.Delete("Yoga"))
+	_, err = store.Update("Yoga", func(old interface{}, exists bool) (interface{}, error) {
+		return classInfo(20), nil
+	})
+	assert.Error(t, err)
+	_, err = store.Update("Yoga", func(old interface{}, exists bool) (interface{}, error) {
+		return nil, ErrDeleteKey
+	})
+	assert.Error(t, err)
+
+	value, ok := store.Load("Yoga")
+	assert.True(t, ok)
+	assert.Equal(t, classInfo(10), value)
+	assert.Equal(t, []string{"Yoga"}, store.Keys())
+}
@@ -1,57 +1,157 @@
 package mapstore
 
 import (
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"sort"
 	"sync"
 )
 
+// DefaultShards is the number of shards used when a store is created with a non-positive shard count.
+const DefaultShards = 64
+
 // MapStore is an interface that abstracts a simple key-value store.
-// It defines methods to load, store, and delete values by key.
+// It defines methods to load, store, delete and enumerate values by key,
+// and an Update primitive that atomically replaces the value of a key.
 type MapStore interface {
-	Load(key string) (interface{}, bool) // Retrieves the value for the given key, if present
-	Store(key string, value interface{}) // Stores a value under the given key
-	Delete(key string)                   // Removes the key-value pair from the store
+	Load(key string) (interface{}, bool)       // Retrieves the value for the given key, if present
+	Store(key string, value interface{}) error // Stores a value under the given key
+	Delete(key string) error                   // Removes the key-value pair from the store
+	Keys() []string                            // Returns every key currently held in the store, sorted
+	Update(key string, fn UpdateFunc) (interface{}, error)
 }
 
-// muMapStore is a concrete implementation of MapStore using a standard Go map.
-type muMapStore struct {
+// UpdateFunc computes the new value of a key from its current value.
+// exists reports whether the key was present. Returning an error aborts the
+// update and leaves the stored value untouched, except for ErrDeleteKey which
+// removes the key instead.
+type UpdateFunc func(old interface{}, exists bool) (interface{}, error)
+
+// ErrDeleteKey is returned by an UpdateFunc to atomically delete the key it was
+// called for. Update then returns a nil value and a nil error.
+var ErrDeleteKey = errors.New("mapstore: delete key")
+
+// shard is one partition of a muMapStore: a standard Go map guarded by its own read-write mutex.
+type shard struct {
+	mu       sync.RWMutex
 	mapStore map[string]interface{}
 }
 
-// muInstance holds the singleton instance of muMapStore.
-// This prevents multiple instantiations of the map store.
-var muInstance *muMapStore
+// muMapStore is a concrete implementation of MapStore that stripes its keys
+// across a fixed number of shards. Operations on keys living in different
+// shards never wait for each other, so a burst of writes to one class does
+// not block the others.
+type muMapStore struct {
+	shards []*shard
+}
+
+var (
+	// muInstance holds the singleton instance of muMapStore.
+	// This prevents multiple instantiations of the map store.
+	muInstance MapStore
+	muOnce     sync.Once
+)
 
-// NewMuMapStore initializes and returns a singleton instance of muMapStore.
-// It ensures that the map store is created only once, using the provided mutex for thread safety during instantiation.
-func NewMuMapStore(lock *sync.Mutex) MapStore {
-	if muInstance == nil {
-		fmt.Println("Application is using Balanced RW Map Mechanism")
-		lock.Lock()
-		defer lock.Unlock()
+// NewMuMapStore initializes and returns a singleton instance of muMapStore split into the given number of shards.
+// It ensures that the map store is created only once; the shard count of later calls is ignored.
+func NewMuMapStore(shards int) MapStore {
+	muOnce.Do(func() {
+		fmt.Println("Application is using Sharded RW Map Mechanism")
+		muInstance = NewShardedMapStore(shards)
+	})
+	return muInstance
+}
 
-		// Double-check inside lock to avoid race in concurrent calls
-		if muInstance == nil {
-			muInstance = &muMapStore{
-				mapStore: make(map[string]interface{}),
-			}
+// NewShardedMapStore returns a new, empty in-memory MapStore split into the given number of shards.
+// A shard count of one behaves like a single globally locked map.
+func NewShardedMapStore(shards int) MapStore {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	store := &muMapStore{
+		shards: make([]*shard, shards),
+	}
+	for i := range store.shards {
+		store.shards[i] = &shard{
+			mapStore: make(map[string]interface{}),
 		}
 	}
-	return muInstance
+	return store
+}
+
+// shardFor returns the shard responsible for key.
+func (r *muMapStore) shardFor(key string) *shard {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return r.shards[hash.Sum32()%uint32(len(r.shards))]
 }
 
 // Store saves the given value associated with the specified key in the map.
-func (r *muMapStore) Store(key string, value interface{}) {
-	r.mapStore[key] = value
+func (r *muMapStore) Store(key string, value interface{}) error {
+	s := r.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mapStore[key] = value
+	return nil
 }
 
 // Load retrieves the value for a given key. Returns the value and a boolean indicating if the key exists.
 func (r *muMapStore) Load(key string) (interface{}, bool) {
-	val, ok := r.mapStore[key]
+	s := r.shardFor(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	val, ok := s.mapStore[key]
 	return val, ok
 }
 
 // Delete removes the key and its associated value from the map.
-func (r *muMapStore) Delete(key string) {
-	delete(r.mapStore, key)
+// Only the shard holding the key is locked.
+func (r *muMapStore) Delete(key string) error {
+	s := r.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.mapStore, key)
+	return nil
+}
+
+// Keys returns all keys present in the map in ascending order,
+// so that callers listing the store get a stable result.
+// Shards are read one after the other, so the result is not a point-in-time
+// snapshot of the whole store when writes run concurrently.
+func (r *muMapStore) Keys() []string {
+	keys := make([]string, 0)
+	for _, s := range r.shards {
+		s.mu.RLock()
+		for key := range s.mapStore {
+			keys = append(keys, key)
+		}
+		s.mu.RUnlock()
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Update atomically replaces the value of key with the result of fn.
+// No other write to the same shard can interleave between reading the old
+// value and storing the new one; writes to other shards proceed in parallel.
+func (r *muMapStore) Update(key string, fn UpdateFunc) (interface{}, error) {
+	s := r.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.mapStore[key]
+	value, err := fn(old, exists)
+	if errors.Is(err, ErrDeleteKey) {
+		delete(s.mapStore, key)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.mapStore[key] = value
+	return value, nil
 }
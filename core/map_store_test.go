@@ -0,0 +1,71 @@
+package mapstore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedMapStore_KeysAcrossShards(t *testing.T) {
+	store := NewShardedMapStore(8)
+	for i := 0; i < 20; i++ {
+		store.Store(fmt.Sprintf("class_%02d", i), i)
+	}
+
+	// Delete only touches the shard holding the key
+	store.Delete("class_05")
+	_, ok := store.Load("class_05")
+	assert.False(t, ok)
+
+	// Keys gathers every shard and returns them sorted
+	keys := store.Keys()
+	assert.Len(t, keys, 19)
+	assert.Equal(t, "class_00", keys[0])
+	assert.Equal(t, "class_19", keys[18])
+	assert.NotContains(t, keys, "class_05")
+}
+
+func TestShardedMapStore_ConcurrentUpdatesPerKey(t *testing.T) {
+	store := NewShardedMapStore(DefaultShards)
+
+	// Increment many keys concurrently; each key must see every increment
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.Update(fmt.Sprintf("class_%d", i%10), func(old interface{}, exists bool) (interface{}, error) {
+				if !exists {
+					return 1, nil
+				}
+				return old.(int) + 1, nil
+			})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		value, ok := store.Load(fmt.Sprintf("class_%d", i))
+		assert.True(t, ok)
+		assert.Equal(t, 20, value)
+	}
+}
+
+func TestShardedMapStore_UpdateCanDelete(t *testing.T) {
+	store := NewShardedMapStore(4)
+	store.Store("class_1", 1)
+
+	// Returning ErrDeleteKey removes the key atomically
+	value, err := store.Update("class_1", func(old interface{}, exists bool) (interface{}, error) {
+		assert.True(t, exists)
+		return nil, ErrDeleteKey
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, ok := store.Load("class_1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,69 @@
+package mapstore
+
+import "strings"
+
+// TypedStore is a typed view over the keys of a MapStore that start with a given prefix,
+// whose values are all of type T. Several typed stores can share one MapStore
+// as long as their prefixes differ, each seeing only its own keys.
+// It saves callers from asserting interface{} values and exposes Update with typed values.
+type TypedStore[T any] struct {
+	store  MapStore
+	prefix string
+}
+
+// NewTypedStore wraps store in a TypedStore for values of type T kept under keys starting with prefix.
+func NewTypedStore[T any](store MapStore, prefix string) TypedStore[T] {
+	return TypedStore[T]{
+		store:  store,
+		prefix: prefix,
+	}
+}
+
+// Load retrieves the value for a given key. Returns the zero value and false if the key is absent.
+func (s TypedStore[T]) Load(key string) (T, bool) {
+	value, ok := s.store.Load(s.prefix + key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return value.(T), true
+}
+
+// Store saves the given value under key.
+func (s TypedStore[T]) Store(key string, value T) error {
+	return s.store.Store(s.prefix+key, value)
+}
+
+// Delete removes the key and its value.
+func (s TypedStore[T]) Delete(key string) error {
+	return s.store.Delete(s.prefix + key)
+}
+
+// Keys returns the keys of this typed store, without their prefix, sorted.
+func (s TypedStore[T]) Keys() []string {
+	keys := make([]string, 0)
+	for _, key := range s.store.Keys() {
+		if name, ok := strings.CutPrefix(key, s.prefix); ok {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// Update atomically replaces the value of key with the result of fn and returns it.
+// When the key is absent fn receives the zero value of T and exists is false.
+// When fn returns ErrDeleteKey the key is removed and the zero value is returned.
+func (s TypedStore[T]) Update(key string, fn func(old T, exists bool) (T, error)) (T, error) {
+	value, err := s.store.Update(s.prefix+key, func(old interface{}, exists bool) (interface{}, error) {
+		var typed T
+		if exists {
+			typed = old.(T)
+		}
+		return fn(typed, exists)
+	})
+	if err != nil || value == nil {
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
@@ -0,0 +1,64 @@
+package mapstore
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedStore_UpdateIsAtomic(t *testing.T) {
+	store, err := NewFileMapStore(t.TempDir(), 0)
+	require.NoError(t, err)
+	counters := NewTypedStore[int](store, "counter:")
+
+	// Increment the same key from many goroutines at once
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := counters.Update("hits", func(old int, exists bool) (int, error) {
+				return old + 1, nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Assert that no increment was lost
+	value, ok := counters.Load("hits")
+	assert.True(t, ok)
+	assert.Equal(t, 50, value)
+}
+
+func TestTypedStore_FailedUpdateKeepsValue(t *testing.T) {
+	store, err := NewFileMapStore(t.TempDir(), 0)
+	require.NoError(t, err)
+	classes := NewTypedStore[string](store, "class:")
+	classes.Store("yoga", "original")
+
+	// An update that fails must not write anything
+	errAbort := errors.New("abort")
+	_, err = classes.Update("yoga", func(old string, exists bool) (string, error) {
+		assert.True(t, exists)
+		return "changed", errAbort
+	})
+	assert.Equal(t, errAbort, err)
+
+	value, _ := classes.Load("yoga")
+	assert.Equal(t, "original", value)
+
+	// A missing key is reported to the update function
+	_, err = classes.Update("pilates", func(old string, exists bool) (string, error) {
+		assert.False(t, exists)
+		assert.Equal(t, "", old)
+		return "created", nil
+	})
+	assert.NoError(t, err)
+	value, ok := classes.Load("pilates")
+	assert.True(t, ok)
+	assert.Equal(t, "created", value)
+}
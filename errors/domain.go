@@ -0,0 +1,127 @@
+package newError
+
+import (
+	"errors"
+	"time"
+)
+
+// Code is a stable, machine-readable identifier of an error. Clients should
+// switch on codes rather than on messages, which may be reworded.
+type Code string
+
+const (
+	CodeMalformedRequest       Code = "MALFORMED_REQUEST"
+	CodeValidationFailed       Code = "VALIDATION_FAILED"
+	CodeClassNotFound          Code = "CLASS_NOT_FOUND"
+	CodeClassAlreadyExists     Code = "CLASS_ALREADY_EXISTS"
+	CodeClassUpdateConflict    Code = "CLASS_UPDATE_CONFLICT"
+	CodeClassHasBookings       Code = "CLASS_HAS_BOOKINGS"
+	CodeClassFull              Code = "CLASS_FULL"
+	CodeDateOutOfRange         Code = "DATE_OUT_OF_RANGE"
+	CodeNoClassOccurrence      Code = "NO_CLASS_OCCURRENCE"
+	CodeBookingNotOpen         Code = "BOOKING_NOT_OPEN"
+	CodeBookingClosed          Code = "BOOKING_CLOSED"
+	CodeBookingNotFound        Code = "BOOKING_NOT_FOUND"
+	CodeDuplicateBooking       Code = "DUPLICATE_BOOKING"
+	CodeCancellationDatePassed Code = "CANCELLATION_DATE_PASSED"
+	CodeCancellationClosed     Code = "CANCELLATION_CLOSED"
+	CodeAlreadyOnWaitlist      Code = "ALREADY_ON_WAITLIST"
+	CodeNotOnWaitlist          Code = "NOT_ON_WAITLIST"
+	CodeMemberNotFound         Code = "MEMBER_NOT_FOUND"
+	CodeMemberNotActive        Code = "MEMBER_NOT_ACTIVE"
+	CodePlanNotFound           Code = "PLAN_NOT_FOUND"
+	CodeStudioNotFound         Code = "STUDIO_NOT_FOUND"
+	CodeNoValidCredit          Code = "NO_VALID_CREDIT"
+	CodeBookingNotMovable      Code = "BOOKING_NOT_MOVABLE"
+	CodeCheckInNotOpen         Code = "CHECK_IN_NOT_OPEN"
+	CodeCheckInNotAllowed      Code = "CHECK_IN_NOT_ALLOWED"
+	CodeMemberBlocked          Code = "MEMBER_BLOCKED"
+	CodeIdempotencyKeyReused   Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyKeyInUse    Code = "IDEMPOTENCY_KEY_IN_USE"
+	CodeImportRejected         Code = "IMPORT_REJECTED"
+	CodeUnauthenticated        Code = "UNAUTHENTICATED"
+	CodeForbidden              Code = "FORBIDDEN"
+	CodeInternal               Code = "INTERNAL_ERROR"
+)
+
+// DomainError is an error of the booking domain with a stable code. Field names
+// the request field a validation error is about, when there is a single one.
+// Domain errors are package-level sentinels, so errors.Is keeps working on them.
+type DomainError struct {
+	Code    Code
+	Field   string
+	message string
+}
+
+// newDomainError creates a domain error with the given code and message.
+func newDomainError(code Code, message string) *DomainError {
+	return &DomainError{Code: code, message: message}
+}
+
+// newFieldError creates a VALIDATION_FAILED error about a single request field.
+func newFieldError(field, message string) *DomainError {
+	return &DomainError{Code: CodeValidationFailed, Field: field, message: message}
+}
+
+func (err *DomainError) Error() string {
+	return err.message
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every invalid field of a request at once.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (err *ValidationError) Error() string {
+	if len(err.Fields) == 1 {
+		return err.Fields[0].Field + ": " + err.Fields[0].Message
+	}
+	return "request has invalid fields"
+}
+
+// CodeOf returns the stable code of err. Dates that fail to parse are invalid
+// requests; any other error outside the domain is an internal error.
+func CodeOf(err error) Code {
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+	var validationErr *ValidationError
+	var parseErr *time.ParseError
+	if errors.As(err, &validationErr) || errors.As(err, &parseErr) {
+		return CodeValidationFailed
+	}
+	return CodeInternal
+}
+
+// internalMessage is what clients are told about errors outside the domain,
+// whose own message may reveal details such as SQL statements or file paths.
+const internalMessage = "an internal error occurred"
+
+// MessageOf returns the message of err that can be shown to clients: its own
+// message for domain and validation errors, and a generic one for internal errors.
+func MessageOf(err error) string {
+	if CodeOf(err) == CodeInternal {
+		return internalMessage
+	}
+	return err.Error()
+}
+
+// FieldsOf returns the field-level details of a validation error, if any.
+func FieldsOf(err error) []FieldError {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) && domainErr.Field != "" {
+		return []FieldError{{Field: domainErr.Field, Message: err.Error()}}
+	}
+	return nil
+}
@@ -6,10 +6,64 @@ import (
 )
 
 var (
-	ErrUnmarshalling            = errors.New("error while unamrshalling")
+	ErrUnmarshalling            = newDomainError(CodeMalformedRequest, "error while unamrshalling")
 	ErrCreatingBooking          = errors.New("Error while creating booking:")
-	ErrClassNotExist            = errors.New(fmt.Sprintf("Please Check Your Class Name"))
-	ErrBookingDatePassed        = errors.New("booking for the mentioned date is not allowed for the class")
-	ErrSlotsFullForTheDate      = errors.New("booking full for the requested class on the mentioned date")
-	ErrEndTimeLessThanStartTime = errors.New("class end date can not be less than start end date")
+	ErrCancellingBooking        = errors.New("Error while cancelling booking:")
+	ErrClassNotExist            = newDomainError(CodeClassNotFound, fmt.Sprintf("Please Check Your Class Name"))
+	ErrBookingDatePassed        = newDomainError(CodeDateOutOfRange, "booking for the mentioned date is not allowed for the class")
+	ErrSlotsFullForTheDate      = newDomainError(CodeClassFull, "booking full for the requested class on the mentioned date")
+	ErrEndTimeLessThanStartTime = newFieldError("endDate", "class end date can not be less than start end date")
+	ErrClassNameRequired        = newFieldError("className", "class name is required")
+	ErrInvalidCapacity          = newFieldError("classCapacity", "class capacity must be a positive number")
+	ErrBookingNotExist          = newDomainError(CodeBookingNotFound, "no booking found for the user on the mentioned date")
+	ErrCancellationDatePassed   = newDomainError(CodeCancellationDatePassed, "booking can not be cancelled as the class date has already passed")
+	ErrAlreadyOnWaitlist        = newDomainError(CodeAlreadyOnWaitlist, "user is already on the waitlist for the class on the mentioned date")
+	ErrNotOnWaitlist            = newDomainError(CodeNotOnWaitlist, "user is not on the waitlist for the class on the mentioned date")
+	ErrInvalidSchedule          = newFieldError("schedule", "invalid class schedule")
+	ErrNoClassOccurrence        = newDomainError(CodeNoClassOccurrence, "class does not run on the mentioned date and time")
+	ErrCreatingMember           = errors.New("Error while saving member:")
+	ErrMemberNotExist           = newDomainError(CodeMemberNotFound, "no member found with the mentioned id")
+	ErrMemberNotActive          = newDomainError(CodeMemberNotActive, "member is not active and can not book classes")
+	ErrMemberNameRequired       = newFieldError("name", "member name is required")
+	ErrInvalidMemberStatus      = newFieldError("status", "member status must be active or suspended")
+	ErrDuplicateBooking         = newDomainError(CodeDuplicateBooking, "member already holds a booking for the class on the mentioned date")
+	ErrCreatingPlan             = errors.New("Error while saving plan:")
+	ErrInvalidPlan              = newDomainError(CodeValidationFailed, "plan needs a name, a type of pack or unlimited, positive validity days and, for packs, positive credits")
+	ErrPlanNotExist             = newDomainError(CodePlanNotFound, "no plan found with the mentioned id")
+	ErrNoValidCredit            = newDomainError(CodeNoValidCredit, "member has no valid class credit for the mentioned date")
+	ErrClassAlreadyExist        = newDomainError(CodeClassAlreadyExists, "a class with the mentioned name already exists")
+	ErrClassUpdateConflict      = newDomainError(CodeClassUpdateConflict, "class update would drop existing bookings; use onConflict waitlist or cancel to move them")
+	ErrClassHasBookings         = newDomainError(CodeClassHasBookings, "class has active bookings; use cascade=true to cancel them")
+	ErrInvalidConflictPolicy    = newFieldError("onConflict", "onConflict must be reject, waitlist or cancel")
+	ErrIncompleteClass          = newDomainError(CodeValidationFailed, "classCapacity, startDate and endDate are required to replace a class")
+	ErrInvalidTimezone          = newFieldError("timezone", "timezone must be an IANA time zone name such as Europe/Dublin")
+	ErrInvalidBookingDate       = newFieldError("bookingDate", "bookingDate must be a date or an RFC 3339 timestamp")
+	ErrBookingNotOpen           = newDomainError(CodeBookingNotOpen, "bookings for the class on the mentioned date are not open yet")
+	ErrBookingClosed            = newDomainError(CodeBookingClosed, "bookings for the class on the mentioned date have closed")
+	ErrCancellationClosed       = newDomainError(CodeCancellationClosed, "the cancellation deadline for the class on the mentioned date has passed")
+	ErrInvalidBookingSource     = newFieldError("source", "source must be api, web, mobile or front-desk")
+	ErrInvalidBookingPolicy     = newFieldError("bookingPolicy", "booking policy values must not be negative")
+	ErrInvalidBookingFrom       = newFieldError("from", "from must be a date or an RFC 3339 timestamp")
+	ErrInvalidBookingTo         = newFieldError("to", "to must be a date or an RFC 3339 timestamp, not before from")
+	ErrInvalidBookingStatus     = newFieldError("status", "status must be confirmed, waitlisted, cancelled, attended or no-show")
+	ErrInvalidPageLimit         = newFieldError("limit", "limit must be between 1 and 100")
+	ErrInvalidPageOffset        = newFieldError("offset", "offset must not be negative")
+	ErrBookingNotMovable        = newDomainError(CodeBookingNotMovable, "only confirmed bookings can be rescheduled or transferred")
+	ErrNothingToReschedule      = newDomainError(CodeValidationFailed, "a reschedule must change the class, the date or the member of the booking")
+	ErrCheckInNotOpen           = newDomainError(CodeCheckInNotOpen, "check-in opens an hour before the class starts")
+	ErrCheckInNotAllowed        = newDomainError(CodeCheckInNotAllowed, "only confirmed bookings can be checked in")
+	ErrMemberBlocked            = newDomainError(CodeMemberBlocked, "member is blocked from booking after repeated no-shows")
+	ErrInvalidRosterDate        = newFieldError("date", "date must be a date or an RFC 3339 timestamp")
+	ErrInvalidIdempotencyKey    = newFieldError("Idempotency-Key", "Idempotency-Key must be at most 255 characters")
+	ErrIdempotencyKeyReused     = newDomainError(CodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request body")
+	ErrInvalidImportMode        = newFieldError("mode", "mode must be all-or-nothing or best-effort")
+	ErrInvalidImportFile        = newDomainError(CodeMalformedRequest, "import must be a JSON array of classes or CSV with a header row")
+	ErrImportRejected           = newDomainError(CodeImportRejected, "no class was imported because some rows are invalid")
+	ErrIdempotencyKeyInUse      = newDomainError(CodeIdempotencyKeyInUse, "a request with the same Idempotency-Key is still being processed")
+	ErrMissingCredentials       = newDomainError(CodeUnauthenticated, "request needs an API key or a bearer token")
+	ErrInvalidCredentials       = newDomainError(CodeUnauthenticated, "API key or bearer token is invalid or expired")
+	ErrNotOwnMember             = newDomainError(CodeForbidden, "members can only act for themselves")
+	ErrForbidden                = newDomainError(CodeForbidden, "your role does not allow this request")
+	ErrStudioNotExist           = newDomainError(CodeStudioNotFound, "no studio found with the mentioned id")
+	ErrOtherStudio              = newDomainError(CodeForbidden, "your credentials are not valid for this studio")
 )
@@ -0,0 +1,150 @@
+// Package auth authenticates requests with API keys, for server-to-server
+// clients, and with HS256 or RS256 signed JWT bearer tokens, for member apps.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"glofox/config"
+	newError "glofox/errors"
+)
+
+// PrincipalKey is the key the authenticated Principal is kept under in the request context.
+const PrincipalKey = "principal"
+
+// Ways a principal can be authenticated.
+const (
+	MethodAPIKey = "api-key"
+	MethodToken  = "token"
+)
+
+// Principal is the client a request was authenticated as.
+type Principal struct {
+	Method   string // MethodAPIKey or MethodToken
+	Name     string // Name of the API key, or subject of the token
+	Role     Role
+	MemberID string // Member a token was issued to; empty for API clients and staff
+	Studio   string // Studio the credentials are limited to; empty for every studio
+}
+
+// String identifies the principal across methods, e.g. "api-key:billing".
+func (principal Principal) String() string {
+	return principal.Method + ":" + principal.Name
+}
+
+// IsMember reports whether the principal is a member acting for themselves,
+// rather than a client acting on behalf of any member.
+func (principal Principal) IsMember() bool {
+	return principal.MemberID != ""
+}
+
+// InStudio reports whether the principal may make requests to the studio with the given id.
+// Admins whose credentials name no studio act for the whole franchise; every
+// other principal only acts in the studio its credentials name, which is the
+// default studio when they name none.
+func (principal Principal) InStudio(id string) bool {
+	if principal.Role == RoleAdmin && principal.Studio == "" {
+		return true
+	}
+	return principal.Studio == id
+}
+
+// Clock tells the current time, against which tokens expire.
+type Clock interface {
+	Now() time.Time
+}
+
+// ErrNoCredentials is returned when authentication is on but neither API keys
+// nor token keys are configured, so every request would be rejected.
+var ErrNoCredentials = errors.New("authentication is on but no API keys or JWT keys are configured: add an admin key to Auth.APIKeys, or set Auth.Disabled for local development")
+
+// apiKey is a configured API key, by the SHA-256 hash of the key.
+type apiKey struct {
+	name   string
+	role   Role
+	studio string
+	hash   [sha256.Size]byte
+}
+
+// Authenticator verifies the credentials of requests against the configured
+// API keys and token keys.
+type Authenticator struct {
+	apiKeys []apiKey
+	tokens  *tokenVerifier
+}
+
+// NewAuthenticator creates an Authenticator for the credentials in cfg,
+// rejecting keys that are malformed or too weak to be safe. API keys without
+// a role are admin keys; they can not have the member role, as they are not
+// issued to a member. With authentication on, at least one API key or token
+// key is needed, as no request could be served otherwise.
+func NewAuthenticator(cfg config.AuthConfig, clock Clock) (*Authenticator, error) {
+	if !cfg.Disabled && len(cfg.APIKeys) == 0 && len(cfg.JWT.Keys) == 0 {
+		return nil, ErrNoCredentials
+	}
+	authenticator := &Authenticator{}
+	for _, key := range cfg.APIKeys {
+		hash, err := hex.DecodeString(key.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %q: hash must be a hex encoded SHA-256 hash", key.Name)
+		}
+		role := Role(key.Role)
+		if role == "" {
+			role = RoleAdmin
+		}
+		if role != RoleAdmin && role != RoleInstructor {
+			return nil, fmt.Errorf("API key %q: role must be %s or %s", key.Name, RoleAdmin, RoleInstructor)
+		}
+		authenticator.apiKeys = append(authenticator.apiKeys, apiKey{name: key.Name, role: role, studio: key.Studio, hash: [sha256.Size]byte(hash)})
+	}
+	tokens, err := newTokenVerifier(cfg.JWT, clock)
+	if err != nil {
+		return nil, err
+	}
+	authenticator.tokens = tokens
+	return authenticator, nil
+}
+
+// APIKey returns the client holding key. Every configured key is compared in
+// constant time, so the time taken tells nothing about which keys exist.
+func (authenticator *Authenticator) APIKey(key string) (Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	var found *apiKey
+	for i := range authenticator.apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], authenticator.apiKeys[i].hash[:]) == 1 {
+			found = &authenticator.apiKeys[i]
+		}
+	}
+	if found == nil {
+		return Principal{}, newError.ErrInvalidCredentials
+	}
+	return Principal{Method: MethodAPIKey, Name: found.name, Role: found.role, Studio: found.studio}, nil
+}
+
+// Token returns who a bearer token was issued to, once its signature, expiry,
+// issuer and audience check out. Tokens are issued to members, whose id is
+// the subject, unless their role claim names a staff role. A studio claim
+// limits the token to that studio.
+func (authenticator *Authenticator) Token(token string) (Principal, error) {
+	claims, err := authenticator.tokens.verify(token)
+	if err != nil {
+		return Principal{}, newError.ErrInvalidCredentials
+	}
+	role := Role(claims.Role)
+	if role == "" {
+		role = RoleMember
+	}
+	if !validRole(role) {
+		return Principal{}, newError.ErrInvalidCredentials
+	}
+	principal := Principal{Method: MethodToken, Name: claims.Subject, Role: role, Studio: claims.Studio}
+	if role == RoleMember {
+		principal.MemberID = claims.Subject
+	}
+	return principal, nil
+}
@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"glofox/config"
+	newError "glofox/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "a-shared-secret-of-at-least-32-bytes"
+
+type fixedClock time.Time
+
+func (clock fixedClock) Now() time.Time {
+	return time.Time(clock)
+}
+
+var now = time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// sign returns a token with the given header and claims, signed by sign.
+func sign(t *testing.T, header, claims map[string]any, signer func(signed []byte) []byte) string {
+	encode := func(value any) string {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signer([]byte(signed)))
+}
+
+func hmacSigner(secret string) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func rsaSigner(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return signature
+	}
+}
+
+func memberClaims(memberID string) map[string]any {
+	return map[string]any{"sub": memberID, "iss": "glofox-auth", "aud": []string{"glofox"}, "exp": now.Add(time.Hour).Unix()}
+}
+
+func TestAPIKey(t *testing.T) {
+	authenticator, err := NewAuthenticator(config.AuthConfig{APIKeys: []config.APIKey{
+		{Name: "billing", Hash: hashKey("billing-key")},
+		{Name: "front-desk", Hash: hashKey("front-desk-key"), Role: string(RoleInstructor), Studio: "downtown"},
+	}}, fixedClock(now))
+	require.NoError(t, err)
+
+	principal, err := authenticator.APIKey("front-desk-key")
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Method: MethodAPIKey, Name: "front-desk", Role: RoleInstructor, Studio: "downtown"}, principal)
+	assert.False(t, principal.IsMember())
+	assert.Equal(t, "api-key:front-desk", principal.String())
+
+	// Keys without a role are admin keys
+	principal, err = authenticator.APIKey("billing-key")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, principal.Role)
+
+	_, err = authenticator.APIKey("guessed-key")
+	assert.Equal(t, newError.ErrInvalidCredentials, err)
+	_, err = authenticator.APIKey("")
+	assert.Equal(t, newError.ErrInvalidCredentials, err)
+}
+
+func TestToken_HS256(t *testing.T) {
+	authenticator, err := NewAuthenticator(config.AuthConfig{JWT: config.JWTConfig{
+		Issuer: "glofox-auth", Audience: "glofox", LeewaySeconds: 30,
+		Keys: []config.JWTKey{{ID: "2030-06", Algorithm: HS256, Secret: testSecret}},
+	}}, fixedClock(now))
+	require.NoError(t, err)
+
+	token := sign(t, map[string]any{"alg": HS256, "typ": "JWT", "kid": "2030-06"}, memberClaims("mem_1"), hmacSigner(testSecret))
+	principal, err := authenticator.Token(token)
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Method: MethodToken, Name: "mem_1", Role: RoleMember, MemberID: "mem_1"}, principal)
+	assert.True(t, principal.IsMember())
+
+	// Staff tokens name their role and do not act as a member
+	staff := memberClaims("coach_anna")
+	staff["role"] = "instructor"
+	staff["studio"] = "harbour"
+	principal, err = authenticator.Token(sign(t, map[string]any{"alg": HS256}, staff, hmacSigner(testSecret)))
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Method: MethodToken, Name: "coach_anna", Role: RoleInstructor, Studio: "harbour"}, principal)
+	assert.False(t, principal.IsMember())
+
+	// A token without kid is checked against every key of its algorithm
+	_, err = authenticator.Token(sign(t, map[string]any{"alg": HS256}, memberClaims("mem_1"), hmacSigner(testSecret)))
+	assert.NoError(t, err)
+
+	expired := memberClaims("mem_1")
+	expired["exp"] = now.Add(-time.Minute).Unix()
+	notYet := memberClaims("mem_1")
+	notYet["nbf"] = now.Add(time.Minute).Unix()
+	withinLeeway := memberClaims("mem_1")
+	withinLeeway["exp"] = now.Add(-10 * time.Second).Unix()
+	otherIssuer := memberClaims("mem_1")
+	otherIssuer["iss"] = "someone-else"
+	otherAudience := memberClaims("mem_1")
+	otherAudience["aud"] = "reporting"
+	noExpiry := memberClaims("mem_1")
+	delete(noExpiry, "exp")
+	noSubject := memberClaims("")
+	unknownRole := memberClaims("mem_1")
+	unknownRole["role"] = "owner"
+
+	_, err = authenticator.Token(sign(t, map[string]any{"alg": HS256}, withinLeeway, hmacSigner(testSecret)))
+	assert.NoError(t, err)
+	for name, token := range map[string]string{
+		"expired":        sign(t, map[string]any{"alg": HS256}, expired, hmacSigner(testSecret)),
+		"not yet valid":  sign(t, map[string]any{"alg": HS256}, notYet, hmacSigner(testSecret)),
+		"other issuer":   sign(t, map[string]any{"alg": HS256}, otherIssuer, hmacSigner(testSecret)),
+		"other audience": sign(t, map[string]any{"alg": HS256}, otherAudience, hmacSigner(testSecret)),
+		"no expiry":      sign(t, map[string]any{"alg": HS256}, noExpiry, hmacSigner(testSecret)),
+		"no subject":     sign(t, map[string]any{"alg": HS256}, noSubject, hmacSigner(testSecret)),
+		"unknown role":   sign(t, map[string]any{"alg": HS256}, unknownRole, hmacSigner(testSecret)),
+		"wrong secret":   sign(t, map[string]any{"alg": HS256}, memberClaims("mem_1"), hmacSigner("another-secret-of-at-least-32-bytes")),
+		"unknown kid":    sign(t, map[string]any{"alg": HS256, "kid": "2029-12"}, memberClaims("mem_1"), hmacSigner(testSecret)),
+		"alg none":       sign(t, map[string]any{"alg": "none"}, memberClaims("mem_1"), func([]byte) []byte { return nil }),
+		"malformed":      "not.a-token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Token(token)
+			assert.Equal(t, newError.ErrInvalidCredentials, err)
+		})
+	}
+}
+
+func TestToken_RS256(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	authenticator, err := NewAuthenticator(config.AuthConfig{JWT: config.JWTConfig{Keys: []config.JWTKey{
+		{ID: "rsa", Algorithm: RS256, PublicKey: publicPEM},
+		{ID: "hmac", Algorithm: HS256, Secret: testSecret},
+	}}}, fixedClock(now))
+	require.NoError(t, err)
+
+	principal, err := authenticator.Token(sign(t, map[string]any{"alg": RS256, "kid": "rsa"}, memberClaims("mem_2"), rsaSigner(t, privateKey)))
+	require.NoError(t, err)
+	assert.Equal(t, "mem_2", principal.MemberID)
+
+	// The public key can not be used as an HMAC secret to forge a token
+	_, err = authenticator.Token(sign(t, map[string]any{"alg": HS256, "kid": "rsa"}, memberClaims("mem_2"), hmacSigner(publicPEM)))
+	assert.Equal(t, newError.ErrInvalidCredentials, err)
+}
+
+func TestCan(t *testing.T) {
+	admin := Principal{Role: RoleAdmin}
+	instructor := Principal{Role: RoleInstructor}
+	member := Principal{Role: RoleMember, MemberID: "mem_1"}
+
+	assert.True(t, admin.Can(ManageClasses))
+	assert.True(t, admin.Can(TransferData))
+	assert.True(t, instructor.Can(TakeAttendance))
+	assert.False(t, instructor.Can(ManageClasses))
+	assert.False(t, instructor.Can(Book))
+	assert.True(t, member.Can(Book))
+	assert.False(t, member.Can(TakeAttendance))
+	assert.False(t, member.Can(ManageMembers))
+	assert.False(t, Principal{}.Can(ViewClasses))
+}
+
+func TestInStudio(t *testing.T) {
+	franchise := Principal{Role: RoleAdmin}
+	downtown := Principal{Role: RoleInstructor, Studio: "downtown"}
+
+	assert.True(t, franchise.InStudio(""))
+	assert.True(t, franchise.InStudio("harbour"))
+	assert.True(t, downtown.InStudio("downtown"))
+	assert.False(t, downtown.InStudio("harbour"))
+	assert.False(t, downtown.InStudio(""))
+
+	// Members and instructors naming no studio belong to the default studio only
+	for _, principal := range []Principal{{Role: RoleMember, MemberID: "mem_1"}, {Role: RoleInstructor}} {
+		assert.True(t, principal.InStudio(""))
+		assert.False(t, principal.InStudio("downtown"))
+	}
+}
+
+func TestNewAuthenticator_NeedsCredentials(t *testing.T) {
+	// Nothing could be served without a single credential
+	_, err := NewAuthenticator(config.AuthConfig{}, fixedClock(now))
+	assert.Equal(t, ErrNoCredentials, err)
+
+	_, err = NewAuthenticator(config.AuthConfig{Disabled: true}, fixedClock(now))
+	assert.NoError(t, err)
+}
+
+func TestNewAuthenticator_RejectsUnsafeKeys(t *testing.T) {
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	smallPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&smallKey.PublicKey)}))
+
+	for name, cfg := range map[string]config.AuthConfig{
+		"plain API key":     {APIKeys: []config.APIKey{{Name: "billing", Hash: "billing-key"}}},
+		"member API key":    {APIKeys: []config.APIKey{{Name: "billing", Hash: hashKey("billing-key"), Role: string(RoleMember)}}},
+		"short secret":      {JWT: config.JWTConfig{Keys: []config.JWTKey{{Algorithm: HS256, Secret: "short"}}}},
+		"small RSA key":     {JWT: config.JWTConfig{Keys: []config.JWTKey{{Algorithm: RS256, PublicKey: smallPEM}}}},
+		"missing PEM":       {JWT: config.JWTConfig{Keys: []config.JWTKey{{Algorithm: RS256, PublicKey: "key"}}}},
+		"unknown algorithm": {JWT: config.JWTConfig{Keys: []config.JWTKey{{Algorithm: "none"}}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewAuthenticator(cfg, fixedClock(now))
+			assert.Error(t, err)
+		})
+	}
+}
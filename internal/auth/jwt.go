@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"glofox/config"
+)
+
+// Signing algorithms of tokens (RFC 7518).
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+const (
+	minSecretBytes = 32
+	minRSABits     = 2048
+)
+
+// verifyingKey is a configured key, able to check the signatures of one algorithm.
+type verifyingKey struct {
+	id        string
+	algorithm string
+	secret    []byte
+	publicKey *rsa.PublicKey
+}
+
+// tokenVerifier checks JWTs (RFC 7519) in compact serialization.
+type tokenVerifier struct {
+	keys     []verifyingKey
+	issuer   string
+	audience string
+	leeway   time.Duration
+	clock    Clock
+}
+
+// tokenHeader is the JOSE header of a token.
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// tokenClaims are the registered claims the verifier checks, and the role of
+// the subject. Times are NumericDates, in seconds since the epoch; the expiry is required.
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	Studio    string   `json:"studio"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience is the aud claim, which may be a single string or an array of them.
+type audience []string
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*aud = many
+	return nil
+}
+
+func newTokenVerifier(cfg config.JWTConfig, clock Clock) (*tokenVerifier, error) {
+	verifier := &tokenVerifier{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   time.Duration(cfg.LeewaySeconds) * time.Second,
+		clock:    clock,
+	}
+	for _, key := range cfg.Keys {
+		parsed := verifyingKey{id: key.ID, algorithm: key.Algorithm}
+		switch key.Algorithm {
+		case HS256:
+			if len(key.Secret) < minSecretBytes {
+				return nil, fmt.Errorf("JWT key %q: an HS256 secret needs at least %d bytes", key.ID, minSecretBytes)
+			}
+			parsed.secret = []byte(key.Secret)
+		case RS256:
+			publicKey, err := parseRSAPublicKey(key.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("JWT key %q: %w", key.ID, err)
+			}
+			parsed.publicKey = publicKey
+		default:
+			return nil, fmt.Errorf("JWT key %q: algorithm must be %s or %s", key.ID, HS256, RS256)
+		}
+		verifier.keys = append(verifier.keys, parsed)
+	}
+	return verifier, nil
+}
+
+// parseRSAPublicKey reads a PEM encoded PKIX or PKCS #1 RSA public key.
+func parseRSAPublicKey(encoded string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("an RS256 public key must be PEM encoded")
+	}
+	var publicKey *rsa.PublicKey
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("an RS256 public key must be an RSA key")
+		}
+		publicKey = rsaKey
+	}
+	if publicKey.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("an RS256 public key needs at least %d bits", minRSABits)
+	}
+	return publicKey, nil
+}
+
+// verify checks the signature of a token with the key its header names, and
+// then its claims. The algorithm of the header must be the one of the key, so
+// a token can never pick a weaker way of being checked, such as "none".
+func (verifier *tokenVerifier) verify(token string) (tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, errors.New("token must have three parts")
+	}
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return tokenClaims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return tokenClaims{}, err
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range verifier.keys {
+		if key.algorithm != header.Algorithm || (header.KeyID != "" && key.id != header.KeyID) {
+			continue
+		}
+		if key.verify(signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return tokenClaims{}, errors.New("token signature does not match any key")
+	}
+
+	var claims tokenClaims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return tokenClaims{}, err
+	}
+	return claims, verifier.checkClaims(claims)
+}
+
+// checkClaims checks that a token is meant for this service and valid now.
+func (verifier *tokenVerifier) checkClaims(claims tokenClaims) error {
+	if claims.Subject == "" {
+		return errors.New("token has no subject")
+	}
+	if claims.ExpiresAt == nil {
+		return errors.New("token has no expiry")
+	}
+	now := verifier.clock.Now()
+	if !now.Before(numericDate(*claims.ExpiresAt).Add(verifier.leeway)) {
+		return errors.New("token has expired")
+	}
+	if claims.NotBefore != nil && now.Add(verifier.leeway).Before(numericDate(*claims.NotBefore)) {
+		return errors.New("token is not valid yet")
+	}
+	if verifier.issuer != "" && claims.Issuer != verifier.issuer {
+		return errors.New("token was issued by someone else")
+	}
+	if verifier.audience != "" && !slices.Contains(claims.Audience, verifier.audience) {
+		return errors.New("token is meant for someone else")
+	}
+	return nil
+}
+
+// verify reports whether signature is a valid signature of signed under the key.
+func (key verifyingKey) verify(signed, signature []byte) bool {
+	switch key.algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, key.secret)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case RS256:
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(key.publicKey, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token.
+func decodeSegment(segment string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// numericDate converts a NumericDate into a time, to the second.
+func numericDate(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
@@ -0,0 +1,50 @@
+package auth
+
+import "slices"
+
+// Role is what a principal is to the studio, deciding what it may do.
+type Role string
+
+const (
+	RoleAdmin      Role = "admin"      // Runs the studio: manages classes, members and plans
+	RoleInstructor Role = "instructor" // Teaches classes: reads rosters and checks members in
+	RoleMember     Role = "member"     // Books classes for themselves
+)
+
+// Permission is a kind of request a role may make.
+type Permission string
+
+const (
+	ViewClasses    Permission = "classes:read"
+	ManageClasses  Permission = "classes:write"    // Create, update and delete classes
+	TakeAttendance Permission = "attendance:write" // Read rosters and check members in
+	Book           Permission = "bookings:write"   // Book, cancel and reschedule
+	ViewBookings   Permission = "bookings:read"
+	ViewMembers    Permission = "members:read"
+	ManageMembers  Permission = "members:write" // Register, update and suspend members and sell them plans
+	ViewPlans      Permission = "plans:read"
+	ManagePlans    Permission = "plans:write"
+	TransferData   Permission = "data:transfer" // Bulk import and export
+)
+
+// rolePermissions lists what every role may do. Members hold their
+// permissions for themselves only, which the routes and handlers enforce.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		ViewClasses, ManageClasses, TakeAttendance, Book, ViewBookings,
+		ViewMembers, ManageMembers, ViewPlans, ManagePlans, TransferData,
+	},
+	RoleInstructor: {ViewClasses, TakeAttendance, ViewBookings, ViewPlans},
+	RoleMember:     {ViewClasses, Book, ViewBookings, ViewMembers, ViewPlans},
+}
+
+// validRole reports whether role is one of the known roles.
+func validRole(role Role) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can reports whether the role of the principal allows permission.
+func (principal Principal) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[principal.Role], permission)
+}
@@ -0,0 +1,232 @@
+// Package bulk reads and writes classes and bookings in bulk, as JSON arrays
+// or CSV files with a header row, for imports, backups and migrations.
+// Classes use the same field names in both formats as POST /class does.
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	newError "glofox/errors"
+	"glofox/models/dto"
+)
+
+// Format is the file format of an import or export.
+type Format string
+
+const (
+	JSON Format = "json"
+	CSV  Format = "csv"
+)
+
+// classColumns are the CSV columns of a class. Weekdays are written separated
+// by spaces, and read separated by spaces, commas or semicolons.
+var classColumns = []string{
+	"className", "classCapacity", "startDate", "endDate", "timezone",
+	"weekdays", "startTime", "durationMinutes", "rrule",
+	"opensHoursBefore", "cutoffMinutes", "cancelHoursBefore",
+}
+
+// bookingColumns are the CSV columns of a booking record.
+var bookingColumns = []string{"id", "memberId", "className", "occurrence", "status", "source", "createdAt"}
+
+// DecodeClasses reads the classes of an import file. A row that can not be
+// read into a class is returned with its error, so that it can be reported
+// next to the other rows; only a file that can not be read at all fails.
+func DecodeClasses(r io.Reader, format Format) ([]dto.ClassRow, error) {
+	if format == CSV {
+		return decodeClassesCSV(r)
+	}
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", newError.ErrInvalidImportFile, err)
+	}
+	rows := make([]dto.ClassRow, 0, len(raw))
+	for _, item := range raw {
+		var row dto.ClassRow
+		if err := json.Unmarshal(item, &row.Class); err != nil {
+			row.Err = jsonRowError(err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// jsonRowError converts the failure to read a JSON row into a domain error.
+func jsonRowError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &newError.ValidationError{Fields: []newError.FieldError{{
+			Field:   typeErr.Field,
+			Message: "must be of type " + typeErr.Type.String(),
+		}}}
+	}
+	return newError.ErrUnmarshalling
+}
+
+func decodeClassesCSV(r io.Reader) ([]dto.ClassRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", newError.ErrInvalidImportFile, err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		// Spreadsheets like to start their files with a byte order mark
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		column := -1
+		for j, known := range classColumns {
+			if strings.EqualFold(name, known) {
+				column = j
+			}
+		}
+		if column < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q", newError.ErrInvalidImportFile, name)
+		}
+		columns[i] = classColumns[column]
+	}
+
+	rows := make([]dto.ClassRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %v", newError.ErrInvalidImportFile, err)
+		}
+		if err != nil {
+			rows = append(rows, dto.ClassRow{Err: &newError.ValidationError{Fields: []newError.FieldError{{
+				Field:   "row",
+				Message: fmt.Sprintf("must have %d columns like the header", len(columns)),
+			}}}})
+			continue
+		}
+		values := make(map[string]string, len(columns))
+		for i, value := range record {
+			values[columns[i]] = strings.TrimSpace(value)
+		}
+		rows = append(rows, classRow(values))
+	}
+}
+
+// classRow builds a class from the values of a CSV row, keyed by column.
+// A schedule or booking policy is only set when one of its columns has a value.
+func classRow(values map[string]string) dto.ClassRow {
+	var fields []newError.FieldError
+	number := func(column string) *int {
+		if values[column] == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(values[column])
+		if err != nil {
+			fields = append(fields, newError.FieldError{Field: column, Message: "must be a whole number"})
+			return nil
+		}
+		return &n
+	}
+
+	class := dto.Class{
+		Name:      values["className"],
+		StartDate: values["startDate"],
+		EndDate:   values["endDate"],
+		Timezone:  values["timezone"],
+	}
+	if capacity := number("classCapacity"); capacity != nil {
+		class.Capacity = *capacity
+	}
+	if values["weekdays"] != "" || values["startTime"] != "" || values["durationMinutes"] != "" || values["rrule"] != "" {
+		class.Schedule = &dto.Schedule{
+			Weekdays:  strings.FieldsFunc(values["weekdays"], isWeekdaySeparator),
+			StartTime: values["startTime"],
+			RRule:     values["rrule"],
+		}
+		if duration := number("durationMinutes"); duration != nil {
+			class.Schedule.DurationMinutes = *duration
+		}
+	}
+	policy := dto.BookingPolicy{
+		OpensHoursBefore:  number("opensHoursBefore"),
+		CutoffMinutes:     number("cutoffMinutes"),
+		CancelHoursBefore: number("cancelHoursBefore"),
+	}
+	if policy != (dto.BookingPolicy{}) {
+		class.Policy = &policy
+	}
+
+	if len(fields) > 0 {
+		return dto.ClassRow{Class: class, Err: &newError.ValidationError{Fields: fields}}
+	}
+	return dto.ClassRow{Class: class}
+}
+
+// EncodeClasses writes classes in the format DecodeClasses reads, so that an export can be imported again.
+func EncodeClasses(w io.Writer, format Format, classes []dto.Class) error {
+	if format != CSV {
+		return encodeJSON(w, classes)
+	}
+	records := make([][]string, 0, len(classes))
+	for _, class := range classes {
+		record := []string{class.Name, strconv.Itoa(class.Capacity), class.StartDate, class.EndDate, class.Timezone, "", "", "", "", "", "", ""}
+		if class.Schedule != nil {
+			record[5] = strings.Join(class.Schedule.Weekdays, " ")
+			record[6] = class.Schedule.StartTime
+			record[7] = strconv.Itoa(class.Schedule.DurationMinutes)
+			record[8] = class.Schedule.RRule
+		}
+		if class.Policy != nil {
+			record[9] = formatNumber(class.Policy.OpensHoursBefore)
+			record[10] = formatNumber(class.Policy.CutoffMinutes)
+			record[11] = formatNumber(class.Policy.CancelHoursBefore)
+		}
+		records = append(records, record)
+	}
+	return encodeCSV(w, classColumns, records)
+}
+
+// EncodeBookings writes booking records, with times as RFC 3339 timestamps.
+func EncodeBookings(w io.Writer, format Format, bookings []dto.Booking) error {
+	if format != CSV {
+		return encodeJSON(w, bookings)
+	}
+	records := make([][]string, 0, len(bookings))
+	for _, booking := range bookings {
+		records = append(records, []string{
+			booking.ID, booking.MemberID, booking.ClassName, booking.Occurrence.Format(time.RFC3339),
+			booking.Status, booking.Source, booking.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return encodeCSV(w, bookingColumns, records)
+}
+
+func isWeekdaySeparator(r rune) bool {
+	return r == ' ' || r == ',' || r == ';'
+}
+
+func formatNumber(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func encodeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func encodeCSV(w io.Writer, header []string, records [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	return writer.WriteAll(records)
+}
@@ -0,0 +1,110 @@
+package bulk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	newError "glofox/errors"
+	"glofox/models/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestDecodeClasses_CSV(t *testing.T) {
+	in := "\ufeffClassName, classCapacity,startDate,endDate,timezone,weekdays,startTime,durationMinutes,cutoffMinutes\n" +
+		"Spin,10,2030-06-01,2030-06-30,Europe/Dublin,\"Mon,Wed;Fri\",18:00,45,30\n" +
+		"Yoga,8,2030-06-01,2030-06-30,,,,,\n" +
+		"Pilates,many,2030-06-01,2030-06-30,,,,,\n" +
+		"Boxing,5\n"
+	rows, err := DecodeClasses(strings.NewReader(in), CSV)
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, dto.Class{
+		Name: "Spin", Capacity: 10, StartDate: "2030-06-01", EndDate: "2030-06-30", Timezone: "Europe/Dublin",
+		Schedule: &dto.Schedule{Weekdays: []string{"Mon", "Wed", "Fri"}, StartTime: "18:00", DurationMinutes: 45},
+		Policy:   &dto.BookingPolicy{CutoffMinutes: intPtr(30)},
+	}, rows[0].Class)
+
+	// Empty schedule and policy columns leave them out
+	assert.NoError(t, rows[1].Err)
+	assert.Equal(t, dto.Class{Name: "Yoga", Capacity: 8, StartDate: "2030-06-01", EndDate: "2030-06-30"}, rows[1].Class)
+
+	assert.Equal(t, []newError.FieldError{{Field: "classCapacity", Message: "must be a whole number"}}, newError.FieldsOf(rows[2].Err))
+	assert.Equal(t, "Pilates", rows[2].Class.Name)
+	assert.Equal(t, newError.CodeValidationFailed, newError.CodeOf(rows[3].Err))
+}
+
+func TestDecodeClasses_JSON(t *testing.T) {
+	in := `[
+		{"className": "Spin", "classCapacity": 10, "startDate": "2030-06-01", "endDate": "2030-06-30"},
+		{"className": "Yoga", "classCapacity": "eight", "startDate": "2030-06-01", "endDate": "2030-06-30"}
+	]`
+	rows, err := DecodeClasses(strings.NewReader(in), JSON)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, dto.Class{Name: "Spin", Capacity: 10, StartDate: "2030-06-01", EndDate: "2030-06-30"}, rows[0].Class)
+	assert.Equal(t, "classCapacity", newError.FieldsOf(rows[1].Err)[0].Field)
+}
+
+func TestDecodeClasses_InvalidFile(t *testing.T) {
+	for name, test := range map[string]struct {
+		format Format
+		in     string
+	}{
+		"json object":    {JSON, `{"className": "Spin"}`},
+		"malformed json": {JSON, `[{"className": `},
+		"empty csv":      {CSV, ``},
+		"unknown column": {CSV, "className,colour\nSpin,red\n"},
+		"broken quotes":  {CSV, "className\n\"Spin\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeClasses(strings.NewReader(test.in), test.format)
+			assert.ErrorIs(t, err, newError.ErrInvalidImportFile)
+		})
+	}
+}
+
+func TestEncodeClasses_RoundTrips(t *testing.T) {
+	classes := []dto.Class{
+		{
+			Name: "Spin, the hard way", Capacity: 10, StartDate: "2030-06-01", EndDate: "2030-06-30", Timezone: "Europe/Dublin",
+			Schedule: &dto.Schedule{Weekdays: []string{"Mon", "Fri"}, StartTime: "18:00", DurationMinutes: 45, RRule: "FREQ=WEEKLY;INTERVAL=2"},
+			Policy:   &dto.BookingPolicy{OpensHoursBefore: intPtr(0), CancelHoursBefore: intPtr(12)},
+		},
+		{Name: "Yoga", Capacity: 8, StartDate: "2030-06-01", EndDate: "2030-06-30"},
+	}
+	for _, format := range []Format{JSON, CSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, EncodeClasses(&out, format, classes))
+			rows, err := DecodeClasses(&out, format)
+			require.NoError(t, err)
+			require.Len(t, rows, len(classes))
+			for i, row := range rows {
+				assert.NoError(t, row.Err)
+				assert.Equal(t, classes[i], row.Class)
+			}
+		})
+	}
+}
+
+func TestEncodeBookings_CSV(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, EncodeBookings(&out, CSV, []dto.Booking{{
+		ID: "bkg_1", MemberID: "john_doe", ClassName: "Spin",
+		Occurrence: time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC), Status: dto.BookingConfirmed, Source: "plan_unlimited",
+		CreatedAt: time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC),
+	}}))
+	assert.Equal(t, "id,memberId,className,occurrence,status,source,createdAt\n"+
+		"bkg_1,john_doe,Spin,2030-06-10T18:00:00Z,confirmed,plan_unlimited,2030-06-01T09:00:00Z\n", out.String())
+}
@@ -0,0 +1,50 @@
+package route
+
+import (
+	"strings"
+
+	newError "glofox/errors"
+	"glofox/internal/auth"
+	"glofox/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader names the request header carrying the API key of a server-to-server client.
+const APIKeyHeader = "X-API-Key"
+
+// bearerPrefix starts the Authorization header of requests sending a bearer token.
+const bearerPrefix = "bearer "
+
+// authenticate rejects requests without valid credentials with 401, and
+// keeps the principal of the others in the request context for the handlers.
+// Requests send either an API key or a bearer token, never both.
+func authenticate(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		authorization := c.GetHeader("Authorization")
+
+		var principal auth.Principal
+		var err error
+		switch {
+		case key != "" && authorization != "":
+			err = newError.ErrInvalidCredentials
+		case key != "":
+			principal, err = authenticator.APIKey(key)
+		case len(authorization) > len(bearerPrefix) && strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix):
+			principal, err = authenticator.Token(strings.TrimSpace(authorization[len(bearerPrefix):]))
+		case authorization != "":
+			err = newError.ErrInvalidCredentials
+		default:
+			err = newError.ErrMissingCredentials
+		}
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="glofox"`)
+			handler.RespondError(c, err)
+			return
+		}
+
+		c.Set(auth.PrincipalKey, principal)
+		c.Next()
+	}
+}
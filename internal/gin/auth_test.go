@@ -0,0 +1,98 @@
+package route
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"glofox/config"
+	"glofox/internal/auth"
+	"glofox/internal/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "a-shared-secret-of-at-least-32-bytes"
+
+// testAuthenticator accepts the API key "front-desk-key" and tokens signed with testSecret.
+func testAuthenticator(t *testing.T) *auth.Authenticator {
+	hash := sha256.Sum256([]byte("front-desk-key"))
+	authenticator, err := auth.NewAuthenticator(config.AuthConfig{
+		APIKeys: []config.APIKey{{Name: "front-desk", Hash: hex.EncodeToString(hash[:])}},
+		JWT:     config.JWTConfig{Keys: []config.JWTKey{{Algorithm: auth.HS256, Secret: testSecret}}},
+	}, &testClock{now: time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	return authenticator
+}
+
+// memberToken returns a token for memberID valid until 2030-06-01 10:00 UTC.
+func memberToken(memberID string) string {
+	return signedToken(`{"sub":"` + memberID + `","exp":1906538400}`)
+}
+
+// signedToken returns a token with the given claims, signed with testSecret.
+func signedToken(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	signed := encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(signed))
+	return signed + "." + encode(mac.Sum(nil))
+}
+
+// authenticatedRouter answers GET /whoami with the principal of the request.
+func authenticatedRouter(t *testing.T) *gin.Engine {
+	r := gin.New()
+	r.GET("/whoami", authenticate(testAuthenticator(t)), func(c *gin.Context) {
+		principal, _ := handler.PrincipalOf(c)
+		c.String(http.StatusOK, principal.String())
+	})
+	return r
+}
+
+func getWithHeaders(r http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthenticate(t *testing.T) {
+	r := authenticatedRouter(t)
+
+	w := getWithHeaders(r, map[string]string{APIKeyHeader: "front-desk-key"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "api-key:front-desk", w.Body.String())
+
+	w = getWithHeaders(r, map[string]string{"Authorization": "Bearer " + memberToken("mem_1")})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "token:mem_1", w.Body.String())
+
+	w = getWithHeaders(r, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="glofox"`, w.Header().Get("WWW-Authenticate"))
+	assert.Contains(t, w.Body.String(), `"code":"UNAUTHENTICATED"`)
+	assert.Contains(t, w.Body.String(), `"success":false`)
+
+	for name, headers := range map[string]map[string]string{
+		"unknown API key": {APIKeyHeader: "guessed-key"},
+		"forged token":    {"Authorization": "Bearer " + memberToken("mem_1") + "x"},
+		"basic auth":      {"Authorization": "Basic Zm9vOmJhcg=="},
+		"both":            {APIKeyHeader: "front-desk-key", "Authorization": "Bearer " + memberToken("mem_1")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := getWithHeaders(r, headers)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Body.String(), "API key or bearer token is invalid or expired")
+		})
+	}
+}
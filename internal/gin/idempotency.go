@@ -0,0 +1,189 @@
+package route
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	newError "glofox/errors"
+	"glofox/internal/handler"
+	"glofox/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader names the request header carrying the idempotency key chosen by the client.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL = 24 * time.Hour
+	maxIdempotencyKeyLen  = 255
+)
+
+// idempotentResponse is the stored outcome of the first request made with an idempotency key.
+// While that request is still being handled, pending is set and no response is stored yet.
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	pending     bool
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// idempotencyStore keeps the responses of requests made with an idempotency
+// key until their time to live runs out. Keys are scoped by method and path,
+// so the same key can be used for different endpoints.
+type idempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	clock     service.Clock
+	responses map[string]*idempotentResponse
+	lastSweep time.Time
+}
+
+// newIdempotencyStore creates a store keeping responses for ttl minutes, or a day when ttl is 0.
+func newIdempotencyStore(ttl int, clock service.Clock) *idempotencyStore {
+	store := &idempotencyStore{
+		ttl:       time.Duration(ttl) * time.Minute,
+		clock:     clock,
+		responses: make(map[string]*idempotentResponse),
+	}
+	if store.ttl <= 0 {
+		store.ttl = defaultIdempotencyTTL
+	}
+	return store
+}
+
+// begin claims key for a request with the given fingerprint. It returns the
+// stored response when the request is a repeat, or nil when the caller is the
+// first and must handle the request and then call finish.
+func (store *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte) (*idempotentResponse, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.clock.Now()
+	store.sweep(now)
+	if stored, ok := store.responses[key]; ok && now.Before(stored.expires) {
+		if stored.fingerprint != fingerprint {
+			return nil, newError.ErrIdempotencyKeyReused
+		}
+		if stored.pending {
+			return nil, newError.ErrIdempotencyKeyInUse
+		}
+		return stored, nil
+	}
+	store.responses[key] = &idempotentResponse{fingerprint: fingerprint, pending: true, expires: now.Add(store.ttl)}
+	return nil, nil
+}
+
+// finish stores the response of the first request made with key. Server
+// errors are not stored, so that the client can retry them.
+func (store *idempotencyStore) finish(key string, status int, contentType string, body []byte) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	stored, ok := store.responses[key]
+	if !ok {
+		return
+	}
+	if status >= http.StatusInternalServerError {
+		delete(store.responses, key)
+		return
+	}
+	stored.pending = false
+	stored.status = status
+	stored.contentType = contentType
+	stored.body = body
+	stored.expires = store.clock.Now().Add(store.ttl)
+}
+
+// sweep drops expired responses, at most once a minute. The caller must hold mu.
+func (store *idempotencyStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < time.Minute {
+		return
+	}
+	store.lastSweep = now
+	for key, stored := range store.responses {
+		if !now.Before(stored.expires) {
+			delete(store.responses, key)
+		}
+	}
+}
+
+// recordingWriter passes the response through while keeping a copy of its body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// idempotency makes a POST endpoint safe to retry. The first request carrying
+// an Idempotency-Key header is handled as usual and its response stored; a
+// repeat with the same key and body gets that response replayed without being
+// handled again, while a repeat with a different body is rejected.
+// Requests without the header are not affected.
+func idempotency(store *idempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			handler.RespondError(c, newError.ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			handler.RespondError(c, newError.ErrUnmarshalling)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scoped := c.Request.Method + " " + c.Request.URL.Path + " " + key
+		if principal, ok := handler.PrincipalOf(c); ok {
+			// Clients can not replay each other's responses by guessing keys
+			scoped = principal.String() + " " + scoped
+		}
+		stored, err := store.begin(scoped, sha256.Sum256(body))
+		if err != nil {
+			handler.RespondError(c, err)
+			return
+		}
+		if stored != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.status, stored.contentType, stored.body)
+			c.Abort()
+			return
+		}
+
+		// A panicking handler must not keep the key claimed
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				store.finish(scoped, http.StatusInternalServerError, "", nil)
+				panic(recovered)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		store.finish(scoped, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+	}
+}
@@ -0,0 +1,108 @@
+package route
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// testClock is a Clock the test moves forward by hand.
+type testClock struct {
+	now time.Time
+}
+
+func (clock *testClock) Now() time.Time {
+	return clock.now
+}
+
+// idempotentRouter serves POST /booking through the idempotency middleware,
+// answering with the number of times the handler ran.
+func idempotentRouter(store *idempotencyStore, calls *int, status int) *gin.Engine {
+	r := gin.New()
+	r.POST("/booking", idempotency(store), func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"call": *calls})
+	})
+	return r
+}
+
+func postWithKey(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/booking", bytes.NewBufferString(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysAndRejects(t *testing.T) {
+	clock := &testClock{now: time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)}
+	calls := 0
+	r := idempotentRouter(newIdempotencyStore(60, clock), &calls, http.StatusOK)
+
+	// The first request is handled
+	w := postWithKey(r, "key-1", `{"memberId":"mem_1"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"call":1}`, w.Body.String())
+
+	// A retry with the same key and body replays the stored response
+	w = postWithKey(r, "key-1", `{"memberId":"mem_1"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"call":1}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, 1, calls)
+
+	// The same key with another body is refused
+	w = postWithKey(r, "key-1", `{"memberId":"mem_2"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"IDEMPOTENCY_KEY_REUSED"`)
+
+	// Another key, or no key at all, is a new request
+	postWithKey(r, "key-2", `{"memberId":"mem_1"}`)
+	postWithKey(r, "", `{"memberId":"mem_1"}`)
+	postWithKey(r, "", `{"memberId":"mem_1"}`)
+	assert.Equal(t, 4, calls)
+
+	// Once the time to live has passed the key can be used again
+	clock.now = clock.now.Add(61 * time.Minute)
+	w = postWithKey(r, "key-1", `{"memberId":"mem_2"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"call":5}`, w.Body.String())
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	clock := &testClock{now: time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)}
+	calls := 0
+	r := idempotentRouter(newIdempotencyStore(0, clock), &calls, http.StatusInternalServerError)
+
+	postWithKey(r, "key-1", `{}`)
+	w := postWithKey(r, "key-1", `{}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_KeyInUse(t *testing.T) {
+	store := newIdempotencyStore(0, &testClock{now: time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)})
+	r := gin.New()
+	var inner *httptest.ResponseRecorder
+	r.POST("/booking", idempotency(store), func(c *gin.Context) {
+		// A retry arriving while the first request is still handled must not run it twice
+		if inner == nil {
+			inner = postWithKey(r, "key-1", `{}`)
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	w := postWithKey(r, "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusConflict, inner.Code)
+	assert.Contains(t, inner.Body.String(), `"code":"IDEMPOTENCY_KEY_IN_USE"`)
+}
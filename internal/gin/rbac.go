@@ -0,0 +1,36 @@
+package route
+
+import (
+	newError "glofox/errors"
+	"glofox/internal/auth"
+	"glofox/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authorize rejects requests with 403 unless the role of their principal
+// allows permission. Anonymous requests, made while authentication is turned
+// off, are let through.
+func authorize(permission auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := handler.PrincipalOf(c)
+		if ok && !principal.Can(permission) {
+			handler.RespondError(c, newError.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ownMember rejects requests of members about another member than themselves
+// with 403. The member is named by the path parameter param.
+func ownMember(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := handler.PrincipalOf(c)
+		if ok && principal.IsMember() && c.Param(param) != principal.MemberID {
+			handler.RespondError(c, newError.ErrNotOwnMember)
+			return
+		}
+		c.Next()
+	}
+}
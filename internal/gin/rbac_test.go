@@ -0,0 +1,187 @@
+package route
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"glofox/config"
+	mapstore "glofox/core"
+	"glofox/internal/auth"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roleHeaders are the credentials of a client of each role: API keys for the
+// admin and the instructor, and a token for the member mem_1.
+var roleHeaders = map[auth.Role]map[string]string{
+	auth.RoleAdmin:      {APIKeyHeader: "admin-key"},
+	auth.RoleInstructor: {APIKeyHeader: "instructor-key"},
+	auth.RoleMember:     {"Authorization": "Bearer " + longLivedToken("mem_1")},
+}
+
+// longLivedToken returns a member token that expires in 2100.
+func longLivedToken(memberID string) string {
+	return signedToken(`{"sub":"` + memberID + `","exp":4102444800}`)
+}
+
+func hashHex(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// rbacRouter serves the full API on a fresh store holding the class Yoga, the
+// members mem_1 and mem_2 and a booking of each, bkg_1 and bkg_2.
+func rbacRouter(t *testing.T) http.Handler {
+	cfg := config.Config{
+		BaseRoute:  "/glofox",
+		DateFormat: "2006-01-02",
+		Timezone:   "UTC",
+		Auth: config.AuthConfig{
+			APIKeys: []config.APIKey{
+				{Name: "studio", Hash: hashHex("admin-key")},
+				{Name: "front-desk", Hash: hashHex("instructor-key"), Role: string(auth.RoleInstructor)},
+			},
+			JWT: config.JWTConfig{Keys: []config.JWTKey{{Algorithm: auth.HS256, Secret: testSecret}}},
+		},
+	}
+	repo := repository.NewMapRepository(mapstore.NewShardedMapStore(4))
+	studios, err := service.InitializeStudios(repo, cfg)
+	require.NoError(t, err)
+	services, err := studios.Studio("")
+	require.NoError(t, err)
+	require.NoError(t, services.CreateClass(dto.Class{Name: "Yoga", Capacity: 10, StartDate: "2030-06-01", EndDate: "2030-06-30",
+		Schedule: &dto.Schedule{StartTime: "07:00", DurationMinutes: 60}}))
+	for i, memberID := range []string{"mem_1", "mem_2"} {
+		require.NoError(t, repo.StoreMember(dto.Member{ID: memberID, Name: memberID, Status: dto.MemberActive}))
+		require.NoError(t, repo.StoreBooking(dto.Booking{
+			ID: []string{"bkg_1", "bkg_2"}[i], MemberID: memberID, ClassName: "Yoga",
+			Occurrence: time.Date(2030, 6, 10, 7, 0, 0, 0, time.UTC), Status: dto.BookingConfirmed,
+		}))
+	}
+
+	router, err := NewRouter(cfg, studios)
+	require.NoError(t, err)
+	return router.SetRoutes()
+}
+
+func serve(r http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/glofox"+path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRoutes_RolePermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	everyone := []auth.Role{auth.RoleAdmin, auth.RoleInstructor, auth.RoleMember}
+	staff := []auth.Role{auth.RoleAdmin, auth.RoleInstructor}
+	bookers := []auth.Role{auth.RoleAdmin, auth.RoleMember}
+	admins := []auth.Role{auth.RoleAdmin}
+
+	booking := `{"className":"Yoga","memberId":"mem_1","bookingDate":"2030-06-11"}`
+	for _, endpoint := range []struct {
+		method, path, body string
+		allowed            []auth.Role
+	}{
+		{http.MethodGet, "/class", "", everyone},
+		{http.MethodGet, "/class.ics", "", everyone},
+		{http.MethodGet, "/class/Yoga", "", everyone},
+		{http.MethodPost, "/class", `{"className":"Spin","classCapacity":5,"startDate":"2030-06-01","endDate":"2030-06-30"}`, admins},
+		{http.MethodPut, "/class/Yoga", `{"classCapacity":12,"startDate":"2030-06-01","endDate":"2030-06-30"}`, admins},
+		{http.MethodPatch, "/class/Yoga", `{"classCapacity":12}`, admins},
+		{http.MethodDelete, "/class/Yoga?cascade=true", "", admins},
+		{http.MethodGet, "/class/Yoga/roster?date=2030-06-10", "", staff},
+		{http.MethodPost, "/class/Yoga/checkin", `{"bookingDate":"2030-06-10","memberIds":["mem_1"]}`, staff},
+
+		{http.MethodPost, "/booking", booking, bookers},
+		{http.MethodDelete, "/booking", booking, bookers},
+		{http.MethodDelete, "/booking/bkg_1", "", bookers},
+		{http.MethodGet, "/booking/waitlist?className=Yoga&bookingDate=2030-06-11&memberId=mem_1", "", bookers},
+		{http.MethodPost, "/booking/bkg_1/reschedule", `{"bookingDate":"2030-06-12"}`, bookers},
+		{http.MethodGet, "/booking/bkg_1", "", everyone},
+		{http.MethodPost, "/booking/bkg_1/checkin", "", staff},
+
+		{http.MethodGet, "/member/mem_1", "", bookers},
+		{http.MethodGet, "/member/mem_1/credits", "", bookers},
+		{http.MethodGet, "/member/mem_1/credits/ledger", "", bookers},
+		{http.MethodGet, "/member/mem_1/bookings", "", bookers},
+		{http.MethodGet, "/member/mem_1/bookings.ics", "", bookers},
+		{http.MethodGet, "/member/mem_1/attendance", "", bookers},
+		{http.MethodPost, "/member", `{"name":"Max"}`, admins},
+		{http.MethodPut, "/member/mem_1", `{"name":"John"}`, admins},
+		{http.MethodDelete, "/member/mem_1", "", admins},
+		{http.MethodPost, "/member/mem_1/credits", `{"planId":"plan_1"}`, admins},
+
+		{http.MethodGet, "/plan", "", everyone},
+		{http.MethodPost, "/plan", `{"name":"Ten","type":"pack","credits":10,"validDays":30}`, admins},
+
+		{http.MethodPost, "/import/classes", `[]`, admins},
+		{http.MethodGet, "/export/classes", "", admins},
+		{http.MethodGet, "/export/bookings", "", admins},
+	} {
+		for _, role := range everyone {
+			t.Run(string(role)+" "+endpoint.method+" "+endpoint.path, func(t *testing.T) {
+				w := serve(rbacRouter(t), endpoint.method, endpoint.path, endpoint.body, roleHeaders[role])
+				if slices.Contains(endpoint.allowed, role) {
+					assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, w.Code, w.Body.String())
+					return
+				}
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.JSONEq(t, `{"success":false,"message":"your role does not allow this request","code":"FORBIDDEN"}`, w.Body.String())
+			})
+		}
+	}
+}
+
+func TestRoutes_MembersOnlyActForThemselves(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	member := roleHeaders[auth.RoleMember]
+
+	for _, endpoint := range []struct{ method, path, body string }{
+		{http.MethodPost, "/booking", `{"className":"Yoga","memberId":"mem_2","bookingDate":"2030-06-11"}`},
+		{http.MethodDelete, "/booking", `{"className":"Yoga","memberId":"mem_2","bookingDate":"2030-06-10"}`},
+		{http.MethodGet, "/booking/waitlist?className=Yoga&bookingDate=2030-06-11&memberId=mem_2", ""},
+		{http.MethodGet, "/booking/bkg_2", ""},
+		{http.MethodDelete, "/booking/bkg_2", ""},
+		{http.MethodPost, "/booking/bkg_2/reschedule", `{"bookingDate":"2030-06-12"}`},
+		{http.MethodPost, "/booking/bkg_1/reschedule", `{"memberId":"mem_2"}`},
+		{http.MethodGet, "/member/mem_2", ""},
+		{http.MethodGet, "/member/mem_2/credits", ""},
+		{http.MethodGet, "/member/mem_2/credits/ledger", ""},
+		{http.MethodGet, "/member/mem_2/bookings", ""},
+		{http.MethodGet, "/member/mem_2/bookings.ics", ""},
+		{http.MethodGet, "/member/mem_2/attendance", ""},
+	} {
+		t.Run(endpoint.method+" "+endpoint.path, func(t *testing.T) {
+			w := serve(rbacRouter(t), endpoint.method, endpoint.path, endpoint.body, member)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.JSONEq(t, `{"success":false,"message":"members can only act for themselves","code":"FORBIDDEN"}`, w.Body.String())
+		})
+	}
+
+	// Their own booking is theirs to see
+	w := serve(rbacRouter(t), http.MethodGet, "/booking/bkg_1", "", member)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRoutes_NeedCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := serve(rbacRouter(t), http.MethodGet, "/class", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"UNAUTHENTICATED"`)
+}
@@ -2,10 +2,9 @@ package route
 
 import (
 	"net/http"
-	"sync"
 
 	"glofox/config"
-	mapstore "glofox/core"
+	"glofox/internal/auth"
 	"glofox/internal/handler"
 	"glofox/internal/service"
 
@@ -14,48 +13,141 @@ import (
 
 // router holds dependencies and the Gin engine for defining and managing routes.
 type router struct {
-	gin      *gin.Engine
-	syMap    mapstore.MapStore
-	lock     *sync.Mutex
-	cfg      config.Config
-	services service.BusinessService
+	gin           *gin.Engine
+	cfg           config.Config
+	studios       service.Studios
+	services      service.BusinessService // Services of the default studio, which handlers fall back to
+	idempotency   *idempotencyStore
+	authenticator *auth.Authenticator
 }
 
 // NewRouter initializes a new router with provided dependencies.
-// It prepares the Gin engine and returns the router wrapper.
-func NewRouter(syMap mapstore.MapStore, lock *sync.Mutex, cfg config.Config, services service.BusinessService) *router {
-	return &router{
-		gin:      gin.Default(),
-		syMap:    syMap,
-		lock:     lock,
-		services: services,
-		cfg:      cfg,
+// It prepares the Gin engine and returns the router wrapper, or an error
+// when the configured credentials can not be used.
+func NewRouter(cfg config.Config, studios service.Studios) (*router, error) {
+	authenticator, err := auth.NewAuthenticator(cfg.Auth, service.SystemClock{})
+	if err != nil {
+		return nil, err
+	}
+	services, err := studios.Studio("")
+	if err != nil {
+		return nil, err
 	}
+	return &router{
+		gin:           gin.Default(),
+		studios:       studios,
+		services:      services,
+		cfg:           cfg,
+		idempotency:   newIdempotencyStore(cfg.IdempotencyTTLMinutes, service.SystemClock{}),
+		authenticator: authenticator,
+	}, nil
 }
 
 // SetRoutes defines the API endpoints and attaches route groups.
+// Every endpoint needs credentials unless authentication is turned off, and
+// each route group is limited to the roles allowed its permission.
+// The endpoints are served for the default studio under the base route and
+// for every other studio under /studios/:studioId.
 // It returns the configured HTTP handler for the server to use.
 func (router *router) SetRoutes() http.Handler {
 	baseGrp := router.gin.Group(router.cfg.BaseRoute)
-	{
-		router.Class(baseGrp)
-		router.Booking(baseGrp)
+	if !router.cfg.Auth.Disabled {
+		baseGrp.Use(authenticate(router.authenticator))
+	}
+	defaultGrp := baseGrp.Group("", inStudio(router.studios))
+	studioGrp := baseGrp.Group("/studios/:studioId", inStudio(router.studios))
+	for _, rg := range []*gin.RouterGroup{defaultGrp, studioGrp} {
+		router.Class(rg)
+		router.Booking(rg)
+		router.Member(rg)
+		router.Plan(rg)
+		router.Bulk(rg)
 	}
 	return router.gin.Handler()
 }
 
-// Class registers the endpoint for class creation under the given route group.
+// Class registers the endpoints for class creation, lookup, update and deletion under the given route group.
+// Everyone can read classes, only admins can change them and instructors take attendance.
 func (router *router) Class(rg *gin.RouterGroup) {
-	handle := handler.NewClassHandler(router.syMap, router.lock, router.services)
+	handle := handler.NewClassHandler(router.services)
+	view := rg.Group("", authorize(auth.ViewClasses))
+	{
+		view.GET("/class", handle.GetClasses)      // GET /class to list every class
+		view.GET("/class.ics", handle.GetCalendar) // GET /class.ics to subscribe to the schedule of every class
+		view.GET("/class/:name", handle.GetClass)  // GET /class/:name to fetch a class with its availability
+	}
+	manage := rg.Group("", authorize(auth.ManageClasses))
 	{
-		rg.POST("/class", handle.CreateClass) // POST /class to create a new class
+		manage.POST("/class", idempotency(router.idempotency), handle.CreateClass) // POST /class to create a new class, replayed for a repeated Idempotency-Key
+		manage.PUT("/class/:name", handle.ReplaceClass)                            // PUT /class/:name to replace a class
+		manage.PATCH("/class/:name", handle.PatchClass)                            // PATCH /class/:name to change some fields of a class
+		manage.DELETE("/class/:name", handle.DeleteClass)                          // DELETE /class/:name to delete a class, optionally cascading to its bookings
+	}
+	attendance := rg.Group("", authorize(auth.TakeAttendance))
+	{
+		attendance.GET("/class/:name/roster", handle.GetRoster)       // GET /class/:name/roster to list who is booked for an occurrence, as JSON or CSV
+		attendance.POST("/class/:name/checkin", handle.CheckInRoster) // POST /class/:name/checkin to check in members from the class roster
 	}
 }
 
-// Booking registers the endpoint for class booking under the given route group.
+// Booking registers the endpoints for class booking and cancellation under the given route group.
+// Members book, cancel and view their own bookings only, which the handlers enforce.
 func (router *router) Booking(rg *gin.RouterGroup) {
-	handle := handler.NewBookingHandler(router.syMap, router.lock, router.services)
+	handle := handler.NewBookingHandler(router.services)
+	book := rg.Group("", authorize(auth.Book))
+	{
+		book.POST("/booking", idempotency(router.idempotency), handle.CreateBooking) // POST /booking to book a class, replayed for a repeated Idempotency-Key
+		book.DELETE("/booking", handle.CancelBooking)                                // DELETE /booking to cancel a booking
+		book.DELETE("/booking/:id", handle.CancelBookingByID)                        // DELETE /booking/:id to cancel a booking by id
+		book.GET("/booking/waitlist", handle.GetWaitlistPosition)                    // GET /booking/waitlist to query a waitlist position
+		book.POST("/booking/:id/reschedule", handle.RescheduleBooking)               // POST /booking/:id/reschedule to move a booking to another date, class or member
+	}
+	view := rg.Group("", authorize(auth.ViewBookings))
+	{
+		view.GET("/booking/:id", handle.GetBooking) // GET /booking/:id to fetch a booking by id
+	}
+	attendance := rg.Group("", authorize(auth.TakeAttendance))
+	{
+		attendance.POST("/booking/:id/checkin", handle.CheckIn) // POST /booking/:id/checkin to check in for a booked class
+	}
+}
+
+// Member registers the endpoints of the member registry under the given route group.
+// Admins manage members; members can only read their own records.
+func (router *router) Member(rg *gin.RouterGroup) {
+	handle := handler.NewMemberHandler(router.services)
+	view := rg.Group("", authorize(auth.ViewMembers), ownMember("id"))
+	{
+		view.GET("/member/:id", handle.GetMember)                       // GET /member/:id to fetch a member
+		view.GET("/member/:id/credits", handle.GetCreditBalance)        // GET /member/:id/credits to fetch a member's credit balance
+		view.GET("/member/:id/credits/ledger", handle.GetCreditLedger)  // GET /member/:id/credits/ledger to list a member's credit history
+		view.GET("/member/:id/bookings", handle.GetMemberBookings)      // GET /member/:id/bookings to page through a member's bookings
+		view.GET("/member/:id/bookings.ics", handle.GetBookingCalendar) // GET /member/:id/bookings.ics to subscribe to a member's bookings
+		view.GET("/member/:id/attendance", handle.GetAttendance)        // GET /member/:id/attendance to fetch a member's attendance and no-show count
+	}
+	manage := rg.Group("", authorize(auth.ManageMembers))
+	{
+		manage.POST("/member", handle.CreateMember)             // POST /member to register a member
+		manage.PUT("/member/:id", handle.UpdateMember)          // PUT /member/:id to update a member's details or status
+		manage.DELETE("/member/:id", handle.DeactivateMember)   // DELETE /member/:id to suspend a member
+		manage.POST("/member/:id/credits", handle.PurchasePlan) // POST /member/:id/credits to grant a plan to a member
+	}
+}
+
+// Plan registers the endpoints for membership plans under the given route group.
+func (router *router) Plan(rg *gin.RouterGroup) {
+	handle := handler.NewPlanHandler(router.services)
+	rg.GET("/plan", authorize(auth.ViewPlans), handle.GetPlans)      // GET /plan to list every plan
+	rg.POST("/plan", authorize(auth.ManagePlans), handle.CreatePlan) // POST /plan to create a class pack or unlimited plan
+}
+
+// Bulk registers the endpoints for importing and exporting classes and bookings under the given route group.
+func (router *router) Bulk(rg *gin.RouterGroup) {
+	handle := handler.NewBulkHandler(router.services)
+	transfer := rg.Group("", authorize(auth.TransferData))
 	{
-		rg.POST("/booking", handle.CreateBooking) // POST /booking to book a class
+		transfer.POST("/import/classes", handle.ImportClasses)  // POST /import/classes to create classes from a CSV file or JSON array
+		transfer.GET("/export/classes", handle.ExportClasses)   // GET /export/classes to download every class as CSV or JSON
+		transfer.GET("/export/bookings", handle.ExportBookings) // GET /export/bookings to download every booking record as CSV or JSON
 	}
 }
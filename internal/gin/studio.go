@@ -0,0 +1,30 @@
+package route
+
+import (
+	newError "glofox/errors"
+	"glofox/internal/handler"
+	"glofox/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inStudio resolves the studio a request is for, named by the studioId path
+// parameter or the default studio outside /studios, and hands its business
+// service to the handlers. Credentials limited to another studio are refused
+// with 403 and unknown studios with 404.
+func inStudio(studios service.Studios) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("studioId")
+		if principal, ok := handler.PrincipalOf(c); ok && !principal.InStudio(id) {
+			handler.RespondError(c, newError.ErrOtherStudio)
+			return
+		}
+		services, err := studios.Studio(id)
+		if err != nil {
+			handler.RespondError(c, err)
+			return
+		}
+		c.Set(handler.StudioKey, services)
+		c.Next()
+	}
+}
@@ -0,0 +1,134 @@
+package route
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"glofox/config"
+	mapstore "glofox/core"
+	"glofox/internal/auth"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// studioRouter serves the default studio and the studios downtown and
+// harbour, which writes dates day first. The key "admin-key" is valid for
+// every studio and "downtown-key" for downtown only. Member tokens are
+// signed with testSecret.
+func studioRouter(t *testing.T) http.Handler {
+	cfg := config.Config{
+		BaseRoute:  "/glofox",
+		DateFormat: "2006-01-02",
+		Studios:    []config.StudioConfig{{ID: "downtown"}, {ID: "harbour", DateFormat: "02/01/2006"}},
+		Auth: config.AuthConfig{
+			APIKeys: []config.APIKey{
+				{Name: "franchise", Hash: hashHex("admin-key")},
+				{Name: "downtown", Hash: hashHex("downtown-key"), Studio: "downtown"},
+			},
+			JWT: config.JWTConfig{Keys: []config.JWTKey{{Algorithm: auth.HS256, Secret: testSecret}}},
+		},
+	}
+	studios, err := service.InitializeStudios(repository.NewMapRepository(mapstore.NewShardedMapStore(4)), cfg)
+	require.NoError(t, err)
+	router, err := NewRouter(cfg, studios)
+	require.NoError(t, err)
+	return router.SetRoutes()
+}
+
+func TestRoutes_Studios(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := studioRouter(t)
+	admin := map[string]string{APIKeyHeader: "admin-key"}
+
+	// Every studio can have a class called Yoga
+	w := serve(r, http.MethodPost, "/studios/downtown/class", `{"className":"Yoga","classCapacity":10,"startDate":"2030-06-01","endDate":"2030-06-30"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serve(r, http.MethodPost, "/studios/harbour/class", `{"className":"Yoga","classCapacity":5,"startDate":"01/06/2030","endDate":"30/06/2030"}`, admin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for path, capacity := range map[string]int{"/studios/downtown/class/Yoga": 10, "/studios/harbour/class/Yoga": 5} {
+		w = serve(r, http.MethodGet, path, "", admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Data struct {
+				Capacity int `json:"classCapacity"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, capacity, body.Data.Capacity, path)
+	}
+
+	// The default studio keeps the routes outside /studios, and has no Yoga class
+	w = serve(r, http.MethodGet, "/class/Yoga", "", admin)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"CLASS_NOT_FOUND"`)
+
+	w = serve(r, http.MethodGet, "/studios/uptown/class", "", admin)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"success":false,"message":"no studio found with the mentioned id","code":"STUDIO_NOT_FOUND"}`, w.Body.String())
+}
+
+func TestRoutes_CredentialsOfAStudio(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := studioRouter(t)
+	downtown := map[string]string{APIKeyHeader: "downtown-key"}
+
+	w := serve(r, http.MethodGet, "/studios/downtown/class", "", downtown)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, path := range []string{"/studios/harbour/class", "/class", "/studios/uptown/class"} {
+		w = serve(r, http.MethodGet, path, "", downtown)
+		assert.Equal(t, http.StatusForbidden, w.Code, path)
+		assert.JSONEq(t, `{"success":false,"message":"your credentials are not valid for this studio","code":"FORBIDDEN"}`, w.Body.String())
+	}
+}
+
+func TestRoutes_MembersStayInTheirStudio(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := studioRouter(t)
+	downtown := map[string]string{"Authorization": "Bearer " + signedToken(`{"sub":"mem_1","studio":"downtown","exp":4102444800}`)}
+	unscoped := map[string]string{"Authorization": "Bearer " + longLivedToken("mem_1")}
+
+	w := serve(r, http.MethodGet, "/studios/downtown/class", "", downtown)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serve(r, http.MethodGet, "/class", "", unscoped)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// A member of downtown cannot reach harbour, and a member naming no
+	// studio belongs to the default studio rather than to every studio
+	for _, tc := range []struct {
+		path    string
+		headers map[string]string
+	}{
+		{"/studios/harbour/class", downtown},
+		{"/class", downtown},
+		{"/studios/downtown/class", unscoped},
+		{"/studios/harbour/class", unscoped},
+	} {
+		w = serve(r, http.MethodGet, tc.path, "", tc.headers)
+		assert.Equal(t, http.StatusForbidden, w.Code, tc.path)
+		assert.Contains(t, w.Body.String(), `"code":"FORBIDDEN"`, tc.path)
+	}
+}
+
+func TestRoutes_IdempotencyKeysPerStudio(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := studioRouter(t)
+	headers := map[string]string{APIKeyHeader: "admin-key", IdempotencyKeyHeader: "create-spin"}
+	body := `{"className":"Spin","classCapacity":5,"startDate":"2030-06-01","endDate":"2030-06-30"}`
+
+	// The same key and body create the class in each studio rather than replaying
+	w := serve(r, http.MethodPost, "/studios/downtown/class", body, headers)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = serve(r, http.MethodPost, "/class", body, headers)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+
+	w = serve(r, http.MethodGet, "/class/Spin", "", headers)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -2,14 +2,12 @@ package handler
 
 import (
 	"glofox/constants"
-	mapstore "glofox/core"
 	newError "glofox/errors"
 	"glofox/internal/service"
 	"glofox/models/dto"
 	"glofox/utils"
 	"log"
 	"net/http"
-	"sync"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,28 +15,30 @@ import (
 // BookingHandler defines the interface for handling booking-related HTTP requests.
 type BookingHandler interface {
 	CreateBooking(c *gin.Context)
+	CancelBooking(c *gin.Context)
+	CancelBookingByID(c *gin.Context)
+	GetBooking(c *gin.Context)
+	RescheduleBooking(c *gin.Context)
+	CheckIn(c *gin.Context)
+	GetWaitlistPosition(c *gin.Context)
 }
 
 // booking is the concrete implementation of BookingHandler.
-// It holds the shared state, mutex, and business service required to process booking requests.
+// It holds the business service required to process booking requests.
 type booking struct {
-	syMap   mapstore.MapStore
-	lock    *sync.Mutex
 	service service.BusinessService
 }
 
 // NewBookingHandler constructs and returns a new BookingHandler with injected dependencies.
-func NewBookingHandler(syMap mapstore.MapStore, lock *sync.Mutex, services service.BusinessService) BookingHandler {
+func NewBookingHandler(services service.BusinessService) BookingHandler {
 	return &booking{
-		syMap:   syMap,
-		lock:    lock,
 		service: services,
 	}
 }
 
 // CreateBooking handles the POST /booking endpoint.
 // It validates and binds the request payload, delegates business logic to the service layer,
-// and returns a structured JSON response.
+// and returns a structured JSON response. Members book for themselves and may leave out memberId.
 func (booking *booking) CreateBooking(c *gin.Context) {
 	var bookingInfo dto.BookingInfo
 
@@ -46,18 +46,202 @@ func (booking *booking) CreateBooking(c *gin.Context) {
 	err := c.ShouldBindJSON(&bookingInfo)
 	if err != nil {
 		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
-		c.JSON(http.StatusBadRequest, utils.CreateResp(false, newError.ErrUnmarshalling.Error()))
+		RespondError(c, bindError(err))
+		return
+	}
+
+	// Members can only book for themselves
+	bookingInfo.MemberID, err = actingMember(c, namedMember(&bookingInfo))
+	if err != nil {
+		RespondError(c, err)
 		return
 	}
 
 	// Call the service layer to process the booking
-	err = booking.service.CreateBooking(bookingInfo)
+	result, err := serviceFor(c, booking.service).CreateBooking(bookingInfo)
 	if err != nil {
 		log.Println(newError.ErrCreatingBooking.Error(), err.Error())
-		c.JSON(http.StatusBadRequest, utils.CreateResp(false, err.Error()))
+		RespondError(c, err)
+		return
+	}
+
+	// A waitlisted request is accepted but not yet a booking
+	if result.Status == dto.BookingWaitlisted {
+		c.JSON(http.StatusAccepted, utils.CreateResp(true, constants.WaitlistJoin, result))
 		return
 	}
 
 	// Return a success response
-	c.JSON(http.StatusOK, utils.CreateResp(true, constants.BookingSucces))
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.BookingSucces, result))
+}
+
+// CancelBooking handles the DELETE /booking endpoint.
+// It binds the class, user and date identifying the booking, asks the service layer
+// to release the slot, and returns a structured JSON response.
+func (booking *booking) CancelBooking(c *gin.Context) {
+	var bookingInfo dto.BookingInfo
+
+	// Attempt to bind the incoming JSON payload to the BookingInfo struct
+	err := c.ShouldBindJSON(&bookingInfo)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	// Members can only cancel their own bookings
+	bookingInfo.MemberID, err = actingMember(c, namedMember(&bookingInfo))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	// Call the service layer to cancel the booking
+	err = serviceFor(c, booking.service).CancelBooking(bookingInfo)
+	if err != nil {
+		log.Println(newError.ErrCancellingBooking.Error(), err.Error())
+		RespondError(c, err)
+		return
+	}
+
+	// Return a success response
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.BookingCancel))
+}
+
+// CancelBookingByID handles the DELETE /booking/:id endpoint.
+// It cancels the booking or waitlist place with the given id and returns the cancelled booking.
+func (booking *booking) CancelBookingByID(c *gin.Context) {
+	services := serviceFor(c, booking.service)
+
+	// Members can only cancel their own bookings
+	record, err := services.GetBooking(c.Param("id"))
+	if err == nil {
+		err = ownBooking(c, record)
+	}
+	if err == nil {
+		record, err = services.CancelBookingByID(c.Param("id"))
+	}
+	if err != nil {
+		log.Println(newError.ErrCancellingBooking.Error(), err.Error())
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.BookingCancel, record))
+}
+
+// GetBooking handles the GET /booking/:id endpoint.
+// It returns the booking record with its member, occurrence, status and source channel.
+func (booking *booking) GetBooking(c *gin.Context) {
+	record, err := serviceFor(c, booking.service).GetBooking(c.Param("id"))
+	if err == nil {
+		err = ownBooking(c, record)
+	}
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.BookingFetch, record))
+}
+
+// RescheduleBooking handles the POST /booking/:id/reschedule endpoint.
+// It moves the booking to the class, date or member given in the payload in
+// one step, and returns the moved booking.
+func (booking *booking) RescheduleBooking(c *gin.Context) {
+	var info dto.RescheduleInfo
+
+	// Attempt to bind the incoming JSON payload to the RescheduleInfo struct
+	err := c.ShouldBindJSON(&info)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	// Members can only move their own bookings, and only to themselves
+	if err = booking.ownReschedule(c, info); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	moved, err := serviceFor(c, booking.service).RescheduleBooking(c.Param("id"), info)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.BookingMoved, moved))
+}
+
+// ownReschedule refuses members a reschedule of a booking that is not theirs,
+// or one handing their booking to another member.
+func (booking *booking) ownReschedule(c *gin.Context, info dto.RescheduleInfo) error {
+	principal, ok := PrincipalOf(c)
+	if !ok || !principal.IsMember() {
+		return nil
+	}
+	if info.MemberID != "" && info.MemberID != principal.MemberID {
+		return newError.ErrNotOwnMember
+	}
+	record, err := serviceFor(c, booking.service).GetBooking(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return ownBooking(c, record)
+}
+
+// CheckIn handles the POST /booking/:id/checkin endpoint.
+// It marks the booking as attended and returns it.
+func (booking *booking) CheckIn(c *gin.Context) {
+	record, err := serviceFor(c, booking.service).CheckIn(c.Param("id"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.CheckedIn, record))
+}
+
+// GetWaitlistPosition handles the GET /booking/waitlist endpoint.
+// It reads the class, user and date from the query string and returns the
+// user's current position on the waitlist for that date.
+func (booking *booking) GetWaitlistPosition(c *gin.Context) {
+	var bookingInfo dto.BookingInfo
+
+	// Attempt to bind the query parameters to the BookingInfo struct
+	err := c.ShouldBindQuery(&bookingInfo)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	bookingInfo.MemberID, err = actingMember(c, namedMember(&bookingInfo))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	position, err := serviceFor(c, booking.service).GetWaitlistPosition(bookingInfo)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.WaitlistFetch, dto.BookingResult{
+		Status:           dto.BookingWaitlisted,
+		WaitlistPosition: position,
+	}))
+}
+
+// namedMember returns the member a booking request names and clears the
+// deprecated userName, which older clients send in place of memberId.
+func namedMember(info *dto.BookingInfo) string {
+	memberID := info.MemberID
+	if memberID == "" {
+		memberID = info.UserName
+	}
+	info.UserName = ""
+	return memberID
 }
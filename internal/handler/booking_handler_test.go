@@ -4,10 +4,11 @@ import (
 	"bytes"
 	"glofox/constants"
 	newError "glofox/errors"
+	"glofox/internal/auth"
+	"glofox/internal/ical"
 	"glofox/models/dto"
 	"net/http"
 	"net/http/httptest"
-	"sync"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -20,51 +21,142 @@ type MockBusinessService struct {
 	mock.Mock
 }
 
-func (m *MockBusinessService) CreateBooking(bookingInfo dto.BookingInfo) error {
+func (m *MockBusinessService) CreateBooking(bookingInfo dto.BookingInfo) (dto.BookingResult, error) {
+	args := m.Called(bookingInfo)
+	return args.Get(0).(dto.BookingResult), args.Error(1)
+}
+func (m *MockBusinessService) GetWaitlistPosition(bookingInfo dto.BookingInfo) (int, error) {
+	args := m.Called(bookingInfo)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockBusinessService) CancelBooking(bookingInfo dto.BookingInfo) error {
 	args := m.Called(bookingInfo)
 	return args.Error(0)
 }
+func (m *MockBusinessService) CancelBookingByID(id string) (dto.Booking, error) {
+	args := m.Called(id)
+	return args.Get(0).(dto.Booking), args.Error(1)
+}
+func (m *MockBusinessService) GetBooking(id string) (dto.Booking, error) {
+	args := m.Called(id)
+	return args.Get(0).(dto.Booking), args.Error(1)
+}
+func (m *MockBusinessService) GetMemberBookings(memberID string, query dto.BookingQuery) (dto.BookingPage, error) {
+	args := m.Called(memberID, query)
+	return args.Get(0).(dto.BookingPage), args.Error(1)
+}
+func (m *MockBusinessService) RescheduleBooking(id string, info dto.RescheduleInfo) (dto.Booking, error) {
+	args := m.Called(id, info)
+	return args.Get(0).(dto.Booking), args.Error(1)
+}
+func (m *MockBusinessService) CheckIn(id string) (dto.Booking, error) {
+	args := m.Called(id)
+	return args.Get(0).(dto.Booking), args.Error(1)
+}
+func (m *MockBusinessService) GetRoster(className string, query dto.RosterQuery) (dto.Roster, error) {
+	args := m.Called(className, query)
+	return args.Get(0).(dto.Roster), args.Error(1)
+}
+func (m *MockBusinessService) CheckInRoster(className string, roster dto.RosterCheckIn) (dto.RosterCheckInResult, error) {
+	args := m.Called(className, roster)
+	return args.Get(0).(dto.RosterCheckInResult), args.Error(1)
+}
+func (m *MockBusinessService) MarkNoShows() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+func (m *MockBusinessService) GetAttendance(memberID string) (dto.Attendance, error) {
+	args := m.Called(memberID)
+	return args.Get(0).(dto.Attendance), args.Error(1)
+}
+func (m *MockBusinessService) ClassCalendar() (ical.Calendar, error) {
+	args := m.Called()
+	return args.Get(0).(ical.Calendar), args.Error(1)
+}
+func (m *MockBusinessService) MemberCalendar(memberID string) (ical.Calendar, error) {
+	args := m.Called(memberID)
+	return args.Get(0).(ical.Calendar), args.Error(1)
+}
+func (m *MockBusinessService) ImportClasses(rows []dto.ClassRow, mode string) (dto.ImportResult, error) {
+	args := m.Called(rows, mode)
+	return args.Get(0).(dto.ImportResult), args.Error(1)
+}
+func (m *MockBusinessService) ExportClasses() ([]dto.Class, error) {
+	args := m.Called()
+	return args.Get(0).([]dto.Class), args.Error(1)
+}
+func (m *MockBusinessService) ExportBookings() ([]dto.Booking, error) {
+	args := m.Called()
+	return args.Get(0).([]dto.Booking), args.Error(1)
+}
 func (m *MockBusinessService) CreateClass(classData dto.Class) error {
 	args := m.Called(classData)
 	return args.Error(0)
 }
-
-// Mocking the MapStore (No actual behavior needed for this test)
-type MockMapStore struct {
-	mock.Mock
+func (m *MockBusinessService) UpdateClass(name string, update dto.ClassUpdate) (dto.ClassChange, error) {
+	args := m.Called(name, update)
+	return args.Get(0).(dto.ClassChange), args.Error(1)
 }
-
-func (m *MockMapStore) Store(key string, value interface{}) {
-	m.Called(key, value)
+func (m *MockBusinessService) DeleteClass(name string, cascade bool) (dto.ClassChange, error) {
+	args := m.Called(name, cascade)
+	return args.Get(0).(dto.ClassChange), args.Error(1)
 }
-
-func (m *MockMapStore) Load(key string) (interface{}, bool) {
-	args := m.Called(key)
-	return args.Get(0), args.Bool(1)
+func (m *MockBusinessService) GetClasses() ([]dto.ClassDetails, error) {
+	args := m.Called()
+	return args.Get(0).([]dto.ClassDetails), args.Error(1)
 }
-func (m *MockMapStore) Delete(key string) {
-	m.Delete(key)
+func (m *MockBusinessService) GetClass(name string) (dto.ClassDetails, error) {
+	args := m.Called(name)
+	return args.Get(0).(dto.ClassDetails), args.Error(1)
 }
-
-func (m *MockMapStore) PrintMap() {
-	m.Called()
+func (m *MockBusinessService) CreateMember(info dto.MemberInfo) (dto.Member, error) {
+	args := m.Called(info)
+	return args.Get(0).(dto.Member), args.Error(1)
+}
+func (m *MockBusinessService) GetMember(id string) (dto.Member, error) {
+	args := m.Called(id)
+	return args.Get(0).(dto.Member), args.Error(1)
+}
+func (m *MockBusinessService) UpdateMember(id string, info dto.MemberInfo) (dto.Member, error) {
+	args := m.Called(id, info)
+	return args.Get(0).(dto.Member), args.Error(1)
+}
+func (m *MockBusinessService) DeactivateMember(id string) (dto.Member, error) {
+	args := m.Called(id)
+	return args.Get(0).(dto.Member), args.Error(1)
+}
+func (m *MockBusinessService) CreatePlan(info dto.PlanInfo) (dto.Plan, error) {
+	args := m.Called(info)
+	return args.Get(0).(dto.Plan), args.Error(1)
+}
+func (m *MockBusinessService) GetPlans() ([]dto.Plan, error) {
+	args := m.Called()
+	return args.Get(0).([]dto.Plan), args.Error(1)
+}
+func (m *MockBusinessService) PurchasePlan(memberID string, info dto.PurchaseInfo) (dto.CreditBalance, error) {
+	args := m.Called(memberID, info)
+	return args.Get(0).(dto.CreditBalance), args.Error(1)
+}
+func (m *MockBusinessService) GetCreditBalance(memberID string) (dto.CreditBalance, error) {
+	args := m.Called(memberID)
+	return args.Get(0).(dto.CreditBalance), args.Error(1)
+}
+func (m *MockBusinessService) GetCreditLedger(memberID string) ([]dto.CreditEntry, error) {
+	args := m.Called(memberID)
+	return args.Get(0).([]dto.CreditEntry), args.Error(1)
 }
 
 // Test cases for CreateBooking handler
 func TestCreateBooking_ValidInput(t *testing.T) {
 	// Prepare mock service
 	mockService := new(MockBusinessService)
-	mockService.On("CreateBooking", mock.AnythingOfType("dto.BookingInfo")).Return(nil).Once()
-
-	// Prepare mock MapStore and lock
-	mockMapStore := new(MockMapStore)
-	mockMutex := &sync.Mutex{}
+	mockService.On("CreateBooking", mock.AnythingOfType("dto.BookingInfo")).Return(dto.BookingResult{Status: dto.BookingConfirmed}, nil).Once()
 
 	// Initialize the handler with mock dependencies
-	handler := NewBookingHandler(mockMapStore, mockMutex, mockService)
+	handler := NewBookingHandler(mockService)
 
 	// Create a new Gin context with the booking info as the body
-	body := `{"UserName":"john_doe","BookingDate":"2025-05-10","ClassName":"YogaClass"}`
+	body := `{"MemberID":"john_doe","BookingDate":"2025-05-10","ClassName":"YogaClass"}`
 	w := performRequestBookingHandler("POST", "/booking", body, handler)
 
 	// Check the response code and message
@@ -79,15 +171,11 @@ func TestCreateBooking_InvalidPayload(t *testing.T) {
 	// Prepare mock service (won't be called in this case)
 	mockService := new(MockBusinessService)
 
-	// Prepare mock MapStore and lock
-	mockMapStore := new(MockMapStore)
-	mockMutex := &sync.Mutex{}
-
 	// Initialize the handler with mock dependencies
-	handler := NewBookingHandler(mockMapStore, mockMutex, mockService)
+	handler := NewBookingHandler(mockService)
 
 	// Create an invalid booking info (malformed JSON)
-	body := `{"UserName": "john_doe", "BookingDate": "2025-05-10"`
+	body := `{"MemberID": "john_doe", "BookingDate": "2025-05-10"`
 
 	// Create a new Gin context with the invalid body
 	w := performRequestBookingHandler("POST", "/booking", body, handler)
@@ -100,27 +188,301 @@ func TestCreateBooking_InvalidPayload(t *testing.T) {
 func TestCreateBooking_ServiceError(t *testing.T) {
 	// Prepare mock service
 	mockService := new(MockBusinessService)
-	mockService.On("CreateBooking", mock.AnythingOfType("dto.BookingInfo")).Return(newError.ErrBookingDatePassed).Once()
-
-	// Prepare mock MapStore and lock
-	mockMapStore := new(MockMapStore)
-	mockMutex := &sync.Mutex{}
+	mockService.On("CreateBooking", mock.AnythingOfType("dto.BookingInfo")).Return(dto.BookingResult{}, newError.ErrBookingDatePassed).Once()
 
 	// Initialize the handler with mock dependencies
-	handler := NewBookingHandler(mockMapStore, mockMutex, mockService)
+	handler := NewBookingHandler(mockService)
 
 	// Create a valid booking info (request body)
-	body := `{"UserName":"john_doe","BookingDate":"2025-05-10","ClassName":"YogaClass"}`
+	body := `{"MemberID":"john_doe","BookingDate":"2025-05-10","ClassName":"YogaClass"}`
 	w := performRequestBookingHandler("POST", "/booking", body, handler)
 
-	// Check the response code and message
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	// Check that a date outside the class is Unprocessable Entity (422) with its stable code
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	assert.Contains(t, w.Body.String(), newError.ErrBookingDatePassed.Error())
+	assert.Contains(t, w.Body.String(), `"code":"DATE_OUT_OF_RANGE"`)
 
 	// Assert that CreateBooking was called once with the correct argument
 	mockService.AssertExpectations(t)
 }
 
+func TestCreateBooking_JoinedWaitlist(t *testing.T) {
+	// Prepare mock service placing the user on the waitlist
+	mockService := new(MockBusinessService)
+	mockService.On("CreateBooking", mock.AnythingOfType("dto.BookingInfo")).Return(dto.BookingResult{
+		Status:           dto.BookingWaitlisted,
+		WaitlistPosition: 2,
+	}, nil).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	body := `{"MemberID":"john_doe","BookingDate":"2025-05-10","ClassName":"YogaClass","JoinWaitlist":true}`
+	w := performRequestBookingHandler("POST", "/booking", body, handler)
+
+	// Check that the response is Accepted (202) rather than a normal booking success
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), constants.WaitlistJoin)
+	assert.Contains(t, w.Body.String(), `"waitlistPosition":2`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateBooking_MemberBooksForThemselves(t *testing.T) {
+	// Prepare mock service expecting the member of the token
+	mockService := new(MockBusinessService)
+	mockService.On("CreateBooking", dto.BookingInfo{ClassName: "YogaClass", MemberID: "john_doe", BookingDate: "2025-05-10"}).
+		Return(dto.BookingResult{Status: dto.BookingConfirmed}, nil).Twice()
+
+	handler := NewBookingHandler(mockService)
+
+	// Authenticate every request as the member john_doe
+	r := gin.Default()
+	r.POST("/booking", func(c *gin.Context) {
+		c.Set(auth.PrincipalKey, auth.Principal{Method: auth.MethodToken, Name: "john_doe", MemberID: "john_doe"})
+	}, handler.CreateBooking)
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/booking", bytes.NewBufferString(body)))
+		return w
+	}
+
+	// The member may name themselves or leave memberId out
+	assert.Equal(t, http.StatusOK, post(`{"memberId":"john_doe","bookingDate":"2025-05-10","className":"YogaClass"}`).Code)
+	assert.Equal(t, http.StatusOK, post(`{"bookingDate":"2025-05-10","className":"YogaClass"}`).Code)
+
+	// Booking for somebody else is forbidden
+	w := post(`{"memberId":"jane_doe","bookingDate":"2025-05-10","className":"YogaClass"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"FORBIDDEN"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateBooking_AcceptsDeprecatedUserName(t *testing.T) {
+	// Prepare mock service expecting the member named by userName
+	mockService := new(MockBusinessService)
+	mockService.On("CreateBooking", dto.BookingInfo{ClassName: "YogaClass", MemberID: "john_doe", BookingDate: "2025-05-10"}).
+		Return(dto.BookingResult{Status: dto.BookingConfirmed}, nil).Twice()
+
+	handler := NewBookingHandler(mockService)
+
+	// Clients written before the member registry still send userName
+	w := performRequestBookingHandler("POST", "/booking", `{"userName":"john_doe","bookingDate":"2025-05-10","className":"YogaClass"}`, handler)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// memberId wins when both are sent
+	w = performRequestBookingHandler("POST", "/booking", `{"memberId":"john_doe","userName":"jane_doe","bookingDate":"2025-05-10","className":"YogaClass"}`, handler)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetWaitlistPosition_Success(t *testing.T) {
+	// Prepare mock service
+	mockService := new(MockBusinessService)
+	mockService.On("GetWaitlistPosition", dto.BookingInfo{
+		ClassName:   "YogaClass",
+		MemberID:    "john_doe",
+		BookingDate: "2025-05-10",
+	}).Return(3, nil).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/booking/waitlist", handler.GetWaitlistPosition)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/booking/waitlist?className=YogaClass&memberId=john_doe&bookingDate=2025-05-10", nil))
+
+	// Check the response code and position
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"waitlistPosition":3`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetWaitlistPosition_NotOnWaitlist(t *testing.T) {
+	// Prepare mock service reporting the user is not waitlisted
+	mockService := new(MockBusinessService)
+	mockService.On("GetWaitlistPosition", mock.AnythingOfType("dto.BookingInfo")).Return(0, newError.ErrNotOnWaitlist).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/booking/waitlist", handler.GetWaitlistPosition)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/booking/waitlist?className=YogaClass&memberId=john_doe&bookingDate=2025-05-10", nil))
+
+	// Check that the response code is NotFound (404)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrNotOnWaitlist.Error())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetBooking_Handler(t *testing.T) {
+	// Prepare mock service holding a single booking
+	mockService := new(MockBusinessService)
+	mockService.On("GetBooking", "bkg_1").Return(dto.Booking{
+		ID:        "bkg_1",
+		MemberID:  "john_doe",
+		ClassName: "YogaClass",
+		Status:    dto.BookingConfirmed,
+		Source:    dto.SourceMobile,
+	}, nil).Once()
+	mockService.On("GetBooking", "bkg_2").Return(dto.Booking{}, newError.ErrBookingNotExist).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	// The waitlist route must keep working next to the id route
+	r := gin.Default()
+	r.GET("/booking/waitlist", handler.GetWaitlistPosition)
+	r.GET("/booking/:id", handler.GetBooking)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/booking/bkg_1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.BookingFetch)
+	assert.Contains(t, w.Body.String(), `"source":"mobile"`)
+
+	// An unknown id is NotFound (404)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/booking/bkg_2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"BOOKING_NOT_FOUND"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRescheduleBooking_Handler(t *testing.T) {
+	// Prepare mock service moving one booking and refusing to move another into a full class
+	mockService := new(MockBusinessService)
+	mockService.On("RescheduleBooking", "bkg_1", dto.RescheduleInfo{BookingDate: "2025-05-12"}).
+		Return(dto.Booking{ID: "bkg_1", ClassName: "YogaClass", Status: dto.BookingConfirmed}, nil).Once()
+	mockService.On("RescheduleBooking", "bkg_2", dto.RescheduleInfo{ClassName: "Spin", BookingDate: "2025-05-12"}).
+		Return(dto.Booking{}, newError.ErrSlotsFullForTheDate).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/booking/:id/reschedule", handler.RescheduleBooking)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/booking/bkg_1/reschedule", bytes.NewBufferString(`{"bookingDate":"2025-05-12"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.BookingMoved)
+	assert.Contains(t, w.Body.String(), `"id":"bkg_1"`)
+
+	// A full target is a Conflict (409)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/booking/bkg_2/reschedule", bytes.NewBufferString(`{"className":"Spin","bookingDate":"2025-05-12"}`)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"CLASS_FULL"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCancelBookingByID_Handler(t *testing.T) {
+	// Prepare mock service holding a booking of john_doe and one of jane_doe
+	mockService := new(MockBusinessService)
+	mockService.On("GetBooking", "bkg_1").Return(dto.Booking{ID: "bkg_1", MemberID: "john_doe", Status: dto.BookingConfirmed}, nil).Once()
+	mockService.On("GetBooking", "bkg_2").Return(dto.Booking{ID: "bkg_2", MemberID: "jane_doe", Status: dto.BookingConfirmed}, nil).Once()
+	mockService.On("GetBooking", "bkg_3").Return(dto.Booking{}, newError.ErrBookingNotExist).Once()
+	mockService.On("CancelBookingByID", "bkg_1").Return(dto.Booking{ID: "bkg_1", MemberID: "john_doe", Status: dto.BookingCancelled}, nil).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	// Authenticate every request as the member john_doe
+	r := gin.Default()
+	r.DELETE("/booking/:id", func(c *gin.Context) {
+		c.Set(auth.PrincipalKey, auth.Principal{Method: auth.MethodToken, Name: "john_doe", MemberID: "john_doe"})
+	}, handler.CancelBookingByID)
+	cancel := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/booking/"+id, nil))
+		return w
+	}
+
+	w := cancel("bkg_1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.BookingCancel)
+	assert.Contains(t, w.Body.String(), `"status":"cancelled"`)
+
+	// The booking of another member is left alone
+	w = cancel("bkg_2")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"FORBIDDEN"`)
+
+	// An unknown id is NotFound (404)
+	w = cancel("bkg_3")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"BOOKING_NOT_FOUND"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCancelBooking_ValidInput(t *testing.T) {
+	// Prepare mock service
+	mockService := new(MockBusinessService)
+	mockService.On("CancelBooking", mock.AnythingOfType("dto.BookingInfo")).Return(nil).Once()
+
+	// Initialize the handler with mock dependencies
+	handler := NewBookingHandler(mockService)
+
+	body := `{"MemberID":"john_doe","BookingDate":"2025-05-10","ClassName":"YogaClass"}`
+	w := performCancelRequest(body, handler)
+
+	// Check the response code and message
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.BookingCancel)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCancelBooking_InvalidPayload(t *testing.T) {
+	// Prepare mock service (won't be called in this case)
+	mockService := new(MockBusinessService)
+	handler := NewBookingHandler(mockService)
+
+	// Malformed JSON
+	body := `{"MemberID": "john_doe"`
+	w := performCancelRequest(body, handler)
+
+	// Check that the response code is BadRequest (400) and contains the error message
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrUnmarshalling.Error())
+}
+
+func TestCancelBooking_ServiceError(t *testing.T) {
+	// Prepare mock service reporting a missing booking
+	mockService := new(MockBusinessService)
+	mockService.On("CancelBooking", mock.AnythingOfType("dto.BookingInfo")).Return(newError.ErrBookingNotExist).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	body := `{"MemberID":"john_doe","BookingDate":"2025-05-10","ClassName":"YogaClass"}`
+	w := performCancelRequest(body, handler)
+
+	// Check that a missing booking is NotFound (404) with its stable code
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrBookingNotExist.Error())
+	assert.Contains(t, w.Body.String(), `"code":"BOOKING_NOT_FOUND"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func performCancelRequest(body string, handler BookingHandler) *httptest.ResponseRecorder {
+	// Set up the Gin router and add the handler
+	r := gin.Default()
+	r.DELETE("/booking", handler.CancelBooking)
+
+	// Create and record the DELETE request
+	req := httptest.NewRequest(http.MethodDelete, "/booking", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
 func performRequestBookingHandler(method, url, body string, handler BookingHandler) *httptest.ResponseRecorder {
 	// Set up the Gin router and add the handler
 	r := gin.Default()
@@ -135,3 +497,28 @@ func performRequestBookingHandler(method, url, body string, handler BookingHandl
 
 	return w
 }
+
+func TestCheckIn_Handler(t *testing.T) {
+	// Prepare mock service checking in one booking and refusing another that is too early
+	mockService := new(MockBusinessService)
+	mockService.On("CheckIn", "bkg_1").Return(dto.Booking{ID: "bkg_1", Status: dto.BookingAttended}, nil).Once()
+	mockService.On("CheckIn", "bkg_2").Return(dto.Booking{}, newError.ErrCheckInNotOpen).Once()
+
+	handler := NewBookingHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/booking/:id/checkin", handler.CheckIn)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/booking/bkg_1/checkin", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.CheckedIn)
+	assert.Contains(t, w.Body.String(), `"status":"attended"`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/booking/bkg_2/checkin", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"CHECK_IN_NOT_OPEN"`)
+
+	mockService.AssertExpectations(t)
+}
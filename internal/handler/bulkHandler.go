@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"glofox/constants"
+	newError "glofox/errors"
+	"glofox/internal/bulk"
+	"glofox/internal/service"
+	"glofox/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkHandler defines the interface for handling bulk import and export requests.
+type BulkHandler interface {
+	ImportClasses(c *gin.Context)
+	ExportClasses(c *gin.Context)
+	ExportBookings(c *gin.Context)
+}
+
+// bulkHandler is the concrete implementation of BulkHandler.
+// It converts between files and records and leaves the rules to the business service.
+type bulkHandler struct {
+	service service.BusinessService
+}
+
+// NewBulkHandler creates a new instance of BulkHandler with dependencies injected.
+func NewBulkHandler(services service.BusinessService) BulkHandler {
+	return &bulkHandler{
+		service: services,
+	}
+}
+
+// ImportClasses handles the POST /import/classes endpoint.
+// The body is a CSV file when sent as text/csv and a JSON array of classes
+// otherwise; the mode query parameter picks all-or-nothing or best-effort.
+// The outcome of every row is returned, also when the import is rejected.
+func (bulkHandler *bulkHandler) ImportClasses(c *gin.Context) {
+	format := bulk.JSON
+	if c.ContentType() == utils.CSVContentType {
+		format = bulk.CSV
+	}
+	rows, err := bulk.DecodeClasses(c.Request.Body, format)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	result, err := serviceFor(c, bulkHandler.service).ImportClasses(rows, c.Query("mode"))
+	if errors.Is(err, newError.ErrImportRejected) {
+		resp := utils.CreateErrorResp(err)
+		resp.Data = result
+		c.JSON(errorStatus(err), resp)
+		return
+	}
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.Imported, result))
+}
+
+// ExportClasses handles the GET /export/classes endpoint.
+// It returns every class as a file that POST /import/classes accepts, in CSV
+// when the client asks for text/csv and as a JSON array otherwise.
+func (bulkHandler *bulkHandler) ExportClasses(c *gin.Context) {
+	classes, err := serviceFor(c, bulkHandler.service).ExportClasses()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	format := exportFormat(c)
+	var body bytes.Buffer
+	if err = bulk.EncodeClasses(&body, format, classes); err != nil {
+		RespondError(c, err)
+		return
+	}
+	respondExport(c, "classes", format, body.Bytes())
+}
+
+// ExportBookings handles the GET /export/bookings endpoint.
+// It returns every booking record, whatever its status, as CSV or a JSON array.
+func (bulkHandler *bulkHandler) ExportBookings(c *gin.Context) {
+	bookings, err := serviceFor(c, bulkHandler.service).ExportBookings()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	format := exportFormat(c)
+	var body bytes.Buffer
+	if err = bulk.EncodeBookings(&body, format, bookings); err != nil {
+		RespondError(c, err)
+		return
+	}
+	respondExport(c, "bookings", format, body.Bytes())
+}
+
+// exportFormat returns the format the client asked an export in.
+func exportFormat(c *gin.Context) bulk.Format {
+	if wantsCSV(c) {
+		return bulk.CSV
+	}
+	return bulk.JSON
+}
+
+// respondExport answers with an export as an attachment named after what it holds.
+func respondExport(c *gin.Context, name string, format bulk.Format, body []byte) {
+	attachment(c, name+"."+string(format))
+	contentType := gin.MIMEJSON
+	if format == bulk.CSV {
+		contentType = utils.CSVContentType
+	}
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", body)
+}
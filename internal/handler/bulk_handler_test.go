@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"glofox/constants"
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportClasses_Handler(t *testing.T) {
+	spin := dto.Class{Name: "Spin", Capacity: 10, StartDate: "2030-06-01", EndDate: "2030-06-30"}
+	rejected := dto.ImportResult{Mode: dto.ImportAllOrNothing, Total: 2, Imported: []string{}, Failed: []dto.ImportFailure{
+		{Row: 2, ClassName: "Yoga", Code: newError.CodeClassAlreadyExists, Message: newError.ErrClassAlreadyExist.Error()},
+	}}
+
+	// Prepare mock service rejecting the JSON import and taking the CSV one
+	mockService := new(MockBusinessService)
+	mockService.On("ImportClasses", []dto.ClassRow{{Class: spin}, {Class: dto.Class{Name: "Yoga"}}}, "").
+		Return(rejected, newError.ErrImportRejected).Once()
+	mockService.On("ImportClasses", []dto.ClassRow{{Class: spin}}, dto.ImportBestEffort).
+		Return(dto.ImportResult{Mode: dto.ImportBestEffort, Total: 1, Imported: []string{"Spin"}, Failed: []dto.ImportFailure{}}, nil).Once()
+
+	handler := NewBulkHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/import/classes", handler.ImportClasses)
+
+	// A rejected import still reports every row
+	body := `[{"className":"Spin","classCapacity":10,"startDate":"2030-06-01","endDate":"2030-06-30"},{"className":"Yoga"}]`
+	req := httptest.NewRequest(http.MethodPost, "/import/classes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"IMPORT_REJECTED"`)
+	assert.Contains(t, w.Body.String(), `"failed":[{"row":2,"className":"Yoga","code":"CLASS_ALREADY_EXISTS"`)
+
+	req = httptest.NewRequest(http.MethodPost, "/import/classes?mode=best-effort",
+		strings.NewReader("className,classCapacity,startDate,endDate\nSpin,10,2030-06-01,2030-06-30\n"))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.Imported)
+	assert.Contains(t, w.Body.String(), `"imported":["Spin"]`)
+
+	// A file that is not a list of classes is rejected before the service sees it
+	req = httptest.NewRequest(http.MethodPost, "/import/classes", strings.NewReader(`{"className":"Spin"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"MALFORMED_REQUEST"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestExport_Handler(t *testing.T) {
+	// Prepare mock service with one class and one booking
+	mockService := new(MockBusinessService)
+	mockService.On("ExportClasses").Return([]dto.Class{
+		{Name: "Spin", Capacity: 10, StartDate: "2030-06-01", EndDate: "2030-06-30", Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}},
+	}, nil).Twice()
+	mockService.On("ExportBookings").Return([]dto.Booking{{
+		ID: "bkg_1", MemberID: "mem_1", ClassName: "Spin", Occurrence: time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC),
+		Status: dto.BookingConfirmed, Source: "plan_unlimited", CreatedAt: time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC),
+	}}, nil).Once()
+
+	handler := NewBulkHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/export/classes", handler.ExportClasses)
+	r.GET("/export/bookings", handler.ExportBookings)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export/classes", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=classes.json", w.Header().Get("Content-Disposition"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), `[`))
+	assert.Contains(t, w.Body.String(), `"className": "Spin"`)
+
+	req := httptest.NewRequest(http.MethodGet, "/export/classes", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=classes.csv", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "className,classCapacity,startDate,endDate,timezone,weekdays,startTime,durationMinutes,rrule,opensHoursBefore,cutoffMinutes,cancelHoursBefore\n"+
+		"Spin,10,2030-06-01,2030-06-30,,,18:00,45,,,,\n", w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/export/bookings", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "bkg_1,mem_1,Spin,2030-06-10T18:00:00Z,confirmed")
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"bytes"
+	"glofox/internal/ical"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarContentType is the media type of iCalendar streams.
+const CalendarContentType = "text/calendar; charset=utf-8"
+
+// respondCalendar answers with the calendars as one iCalendar stream.
+func respondCalendar(c *gin.Context, calendars ...ical.Calendar) {
+	var body bytes.Buffer
+	if err := ical.Encode(&body, calendars...); err != nil {
+		RespondError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, CalendarContentType, body.Bytes())
+}
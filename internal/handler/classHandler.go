@@ -2,14 +2,15 @@ package handler
 
 import (
 	"glofox/constants"
-	mapstore "glofox/core"
 	newError "glofox/errors"
 	"glofox/internal/service"
 	"glofox/models/dto"
 	"glofox/utils"
 	"log"
 	"net/http"
-	"sync"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,23 +18,27 @@ import (
 // ClassHandler defines the interface for handling class-related HTTP requests.
 type ClassHandler interface {
 	CreateClass(c *gin.Context)
+	GetClasses(c *gin.Context)
+	GetClass(c *gin.Context)
+	ReplaceClass(c *gin.Context)
+	PatchClass(c *gin.Context)
+	DeleteClass(c *gin.Context)
+	GetCalendar(c *gin.Context)
+	GetRoster(c *gin.Context)
+	CheckInRoster(c *gin.Context)
 }
 
 // class is the concrete implementation of ClassHandler.
 // It provides logic for handling class creation requests.
-// It uses shared map storage, a mutex for thread safety, and a business service for core logic.
+// It delegates the core logic, including concurrency control, to the business service.
 type class struct {
-	syMap   mapstore.MapStore
-	lock    *sync.Mutex
 	service service.BusinessService
 }
 
 // NewClassHandler creates a new instance of ClassHandler with dependencies injected.
 // This sets up the handler to be used in HTTP routing.
-func NewClassHandler(syMap mapstore.MapStore, lock *sync.Mutex, services service.BusinessService) ClassHandler {
+func NewClassHandler(services service.BusinessService) ClassHandler {
 	return &class{
-		syMap:   syMap,
-		lock:    lock,
 		service: services,
 	}
 }
@@ -48,17 +53,174 @@ func (class *class) CreateClass(c *gin.Context) {
 	err := c.ShouldBindJSON(&classData)
 	if err != nil {
 		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
-		c.JSON(http.StatusBadRequest, utils.CreateResp(false, newError.ErrUnmarshalling.Error()))
+		RespondError(c, bindError(err))
 		return
 	}
 
 	// Call business logic to handle class creation
-	err = class.service.CreateClass(classData)
+	err = serviceFor(c, class.service).CreateClass(classData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, utils.CreateResp(false, err.Error()))
+		RespondError(c, err)
 		return
 	}
 
 	// Respond with success if everything went well
 	c.JSON(http.StatusOK, utils.CreateResp(true, constants.ClassSuccess))
 }
+
+// GetClasses handles GET /class endpoint.
+// It returns every stored class along with its per-date availability.
+func (class *class) GetClasses(c *gin.Context) {
+	classes, err := serviceFor(c, class.service).GetClasses()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.ClassFetched, classes))
+}
+
+// GetClass handles GET /class/:name endpoint.
+// It returns the capacity, date range and per-date availability of the requested class.
+func (class *class) GetClass(c *gin.Context) {
+	classDetails, err := serviceFor(c, class.service).GetClass(c.Param("name"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.ClassFetched, classDetails))
+}
+
+// ReplaceClass handles PUT /class/:name endpoint.
+// It replaces the capacity, dates and schedule of the class as a whole.
+func (class *class) ReplaceClass(c *gin.Context) {
+	class.updateClass(c, true)
+}
+
+// PatchClass handles PATCH /class/:name endpoint.
+// Only the fields present in the payload are changed.
+func (class *class) PatchClass(c *gin.Context) {
+	class.updateClass(c, false)
+}
+
+// updateClass binds a class update, applies it through the service layer and
+// reports the resulting class together with any moved or cancelled bookings.
+func (class *class) updateClass(c *gin.Context, replace bool) {
+	var update dto.ClassUpdate
+
+	// Bind and validate the incoming JSON payload
+	err := c.ShouldBindJSON(&update)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+	update.Replace = replace
+
+	change, err := serviceFor(c, class.service).UpdateClass(c.Param("name"), update)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.ClassUpdated, change))
+}
+
+// DeleteClass handles DELETE /class/:name endpoint.
+// With ?cascade=true the active bookings of the class are cancelled; otherwise
+// a class that still has them is refused with 409 Conflict.
+func (class *class) DeleteClass(c *gin.Context) {
+	cascade, err := strconv.ParseBool(c.DefaultQuery("cascade", "false"))
+	if err != nil {
+		RespondError(c, &newError.ValidationError{Fields: []newError.FieldError{{Field: "cascade", Message: "must be true or false"}}})
+		return
+	}
+
+	change, err := serviceFor(c, class.service).DeleteClass(c.Param("name"), cascade)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.ClassDeleted, change))
+}
+
+// GetCalendar handles the GET /class.ics endpoint.
+// It returns the schedule of every class as an iCalendar feed to subscribe to.
+func (class *class) GetCalendar(c *gin.Context) {
+	calendar, err := serviceFor(c, class.service).ClassCalendar()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	respondCalendar(c, calendar)
+}
+
+// rosterHeader names the columns of a roster exported as CSV.
+var rosterHeader = []string{"class", "occurrence", "memberId", "name", "status", "checkedIn", "waitlistPosition", "bookingId"}
+
+// GetRoster handles the GET /class/:name/roster endpoint.
+// It lists the booked and waitlisted members of the class occurrence on the
+// date given in the query string, as JSON or, for clients accepting text/csv,
+// as a CSV file ready to print.
+func (class *class) GetRoster(c *gin.Context) {
+	var query dto.RosterQuery
+
+	// Attempt to bind the query parameters to the RosterQuery struct
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	roster, err := serviceFor(c, class.service).GetRoster(c.Param("name"), query)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, utils.CreateResp(true, constants.RosterFetched, roster))
+		return
+	}
+	occurrence := roster.Occurrence.Format(time.RFC3339)
+	records := make([][]string, 0, len(roster.Booked)+len(roster.Waitlisted))
+	for _, entry := range slices.Concat(roster.Booked, roster.Waitlisted) {
+		position := ""
+		if entry.WaitlistPosition > 0 {
+			position = strconv.Itoa(entry.WaitlistPosition)
+		}
+		records = append(records, []string{
+			roster.ClassName, occurrence, entry.MemberID, entry.Name, entry.Status,
+			strconv.FormatBool(entry.CheckedIn), position, entry.BookingID,
+		})
+	}
+	filename := roster.ClassName + "-" + roster.Occurrence.Format("2006-01-02-1504") + ".csv"
+	respondCSV(c, http.StatusOK, filename, rosterHeader, records)
+}
+
+// CheckInRoster handles the POST /class/:name/checkin endpoint.
+// It checks in the listed members for one occurrence of the class and reports
+// the members that could not be checked in next to those that were.
+func (class *class) CheckInRoster(c *gin.Context) {
+	var roster dto.RosterCheckIn
+
+	// Attempt to bind the incoming JSON payload to the RosterCheckIn struct
+	err := c.ShouldBindJSON(&roster)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	result, err := serviceFor(c, class.service).CheckInRoster(c.Param("name"), roster)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.RosterChecked, result))
+}
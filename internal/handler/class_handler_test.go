@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"glofox/constants"
 	newError "glofox/errors"
+	"glofox/internal/ical"
+	"glofox/models/dto"
 	"net/http"
-	"sync"
 	"testing"
+	"time"
 
 	"net/http/httptest"
 
@@ -21,15 +23,11 @@ func TestCreateClass_ValidInput(t *testing.T) {
 	mockService := new(MockBusinessService)
 	mockService.On("CreateClass", mock.AnythingOfType("dto.Class")).Return(nil).Once()
 
-	// Prepare mock MapStore and lock
-	mockMapStore := new(MockMapStore)
-	mockMutex := &sync.Mutex{}
-
 	// Initialize the handler with mock dependencies
-	handler := NewClassHandler(mockMapStore, mockMutex, mockService)
+	handler := NewClassHandler(mockService)
 
 	// Create a new Gin context with the class data as the body
-	body := `{"Name":"Yoga Class","Capacity":30,"StartDate":"2025-06-01","EndDate":"2025-06-10"}`
+	body := `{"className":"Yoga Class","classCapacity":30,"startDate":"2025-06-01","endDate":"2025-06-10"}`
 	w := performRequest("POST", "/class", body, handler)
 
 	// Check the response code and message
@@ -44,12 +42,8 @@ func TestCreateClass_InvalidPayload(t *testing.T) {
 	// Prepare mock service (won't be called in this case)
 	mockService := new(MockBusinessService)
 
-	// Prepare mock MapStore and lock
-	mockMapStore := new(MockMapStore)
-	mockMutex := &sync.Mutex{}
-
 	// Initialize the handler with mock dependencies
-	handler := NewClassHandler(mockMapStore, mockMutex, mockService)
+	handler := NewClassHandler(mockService)
 
 	// Create an invalid class info (malformed JSON)
 	body := `{"Name": "Yoga Class", "Capacity": 30, "StartDate": "2025-06-01"`
@@ -67,20 +61,17 @@ func TestCreateClass_ServiceError(t *testing.T) {
 	mockService := new(MockBusinessService)
 	mockService.On("CreateClass", mock.AnythingOfType("dto.Class")).Return(newError.ErrEndTimeLessThanStartTime).Once()
 
-	// Prepare mock MapStore and lock
-	mockMapStore := new(MockMapStore)
-	mockMutex := &sync.Mutex{}
-
 	// Initialize the handler with mock dependencies
-	handler := NewClassHandler(mockMapStore, mockMutex, mockService)
+	handler := NewClassHandler(mockService)
 
 	// Create a valid class info (request body)
-	body := `{"Name":"Yoga Class","Capacity":30,"StartDate":"2025-06-01","EndDate":"2025-05-10"}`
+	body := `{"className":"Yoga Class","classCapacity":30,"startDate":"2025-06-01","endDate":"2025-05-10"}`
 	w := performRequest("POST", "/class", body, handler)
 
-	// Check the response code and message
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	// Check that the invalid field is reported as Unprocessable Entity (422)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	assert.Contains(t, w.Body.String(), newError.ErrEndTimeLessThanStartTime.Error())
+	assert.Contains(t, w.Body.String(), `"field":"endDate"`)
 
 	// Assert that CreateClass was called once with the correct argument
 	mockService.AssertExpectations(t)
@@ -100,3 +91,243 @@ func performRequest(method, url, body string, handler ClassHandler) *httptest.Re
 
 	return w
 }
+
+func TestGetClasses_Success(t *testing.T) {
+	// Prepare mock service returning a single class
+	mockService := new(MockBusinessService)
+	mockService.On("GetClasses").Return([]dto.ClassDetails{{Name: "Yoga Class", Capacity: 30}}, nil).Once()
+
+	// Initialize the handler with mock dependencies
+	handler := NewClassHandler(mockService)
+
+	// Set up the Gin router and perform the request
+	r := gin.Default()
+	r.GET("/class", handler.GetClasses)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/class", nil))
+
+	// Check the response code and payload
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.ClassFetched)
+	assert.Contains(t, w.Body.String(), `"className":"Yoga Class"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetClass_Success(t *testing.T) {
+	// Prepare mock service returning the class details
+	mockService := new(MockBusinessService)
+	mockService.On("GetClass", "Yoga").Return(dto.ClassDetails{
+		Name:         "Yoga",
+		Capacity:     30,
+		Availability: []dto.ClassAvailability{{Date: "2025-06-01", Booked: 1, Remaining: 29}},
+	}, nil).Once()
+
+	// Initialize the handler with mock dependencies
+	handler := NewClassHandler(mockService)
+
+	// Set up the Gin router and perform the request
+	r := gin.Default()
+	r.GET("/class/:name", handler.GetClass)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/class/Yoga", nil))
+
+	// Check the response code and payload
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"remaining":29`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetClass_NotFound(t *testing.T) {
+	// Prepare mock service reporting a missing class
+	mockService := new(MockBusinessService)
+	mockService.On("GetClass", "Unknown").Return(dto.ClassDetails{}, newError.ErrClassNotExist).Once()
+
+	// Initialize the handler with mock dependencies
+	handler := NewClassHandler(mockService)
+
+	// Set up the Gin router and perform the request
+	r := gin.Default()
+	r.GET("/class/:name", handler.GetClass)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/class/Unknown", nil))
+
+	// Check that the response code is NotFound (404) and contains the error message
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrClassNotExist.Error())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateClass_Conflict(t *testing.T) {
+	// Prepare mock service reporting an existing class
+	mockService := new(MockBusinessService)
+	mockService.On("CreateClass", mock.AnythingOfType("dto.Class")).Return(newError.ErrClassAlreadyExist).Once()
+
+	handler := NewClassHandler(mockService)
+
+	body := `{"className": "Yoga Class", "classCapacity": 30, "startDate": "2025-06-01", "endDate": "2025-06-10"}`
+	w := performRequest("POST", "/class", body, handler)
+
+	// Check that the response code is Conflict (409)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrClassAlreadyExist.Error())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateClass_PutAndPatch(t *testing.T) {
+	// Prepare mock service expecting a full replacement and a partial update
+	mockService := new(MockBusinessService)
+	mockService.On("UpdateClass", "Yoga", mock.MatchedBy(func(update dto.ClassUpdate) bool {
+		return update.Replace && *update.Capacity == 20 && *update.StartDate == "2025-06-01"
+	})).Return(dto.ClassChange{Class: &dto.ClassDetails{Name: "Yoga", Capacity: 20}}, nil).Once()
+	mockService.On("UpdateClass", "Yoga", mock.MatchedBy(func(update dto.ClassUpdate) bool {
+		return !update.Replace && *update.Capacity == 5 && update.StartDate == nil && update.OnConflict == dto.ConflictWaitlist
+	})).Return(dto.ClassChange{}, newError.ErrClassUpdateConflict).Once()
+
+	handler := NewClassHandler(mockService)
+
+	r := gin.Default()
+	r.PUT("/class/:name", handler.ReplaceClass)
+	r.PATCH("/class/:name", handler.PatchClass)
+
+	// PUT replaces the class
+	w := httptest.NewRecorder()
+	body := `{"classCapacity": 20, "startDate": "2025-06-01", "endDate": "2025-06-10"}`
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/class/Yoga", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.ClassUpdated)
+
+	// PATCH only sends what changes; a conflict is reported as 409
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/class/Yoga", bytes.NewBufferString(`{"classCapacity": 5, "onConflict": "waitlist"}`)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteClass_Handler(t *testing.T) {
+	// Prepare mock service refusing to drop bookings unless asked to cascade
+	mockService := new(MockBusinessService)
+	mockService.On("DeleteClass", "Yoga", false).Return(dto.ClassChange{}, newError.ErrClassHasBookings).Once()
+	mockService.On("DeleteClass", "Yoga", true).Return(dto.ClassChange{Cancelled: []dto.AffectedBooking{{MemberID: "mem_1"}}}, nil).Once()
+
+	handler := NewClassHandler(mockService)
+
+	r := gin.Default()
+	r.DELETE("/class/:name", handler.DeleteClass)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/class/Yoga", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/class/Yoga?cascade=true", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"memberId":"mem_1"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCheckInRoster_Handler(t *testing.T) {
+	// Prepare mock service checking in one member and failing the other
+	roster := dto.RosterCheckIn{BookingDate: "2030-06-10", MemberIDs: []string{"mem_1", "mem_2"}}
+	mockService := new(MockBusinessService)
+	mockService.On("CheckInRoster", "Yoga", roster).Return(dto.RosterCheckInResult{
+		CheckedIn: []dto.Booking{{ID: "bkg_1", MemberID: "mem_1", Status: dto.BookingAttended}},
+		Failed:    []dto.CheckInFailure{{MemberID: "mem_2", Code: "BOOKING_NOT_FOUND", Message: "booking does not exist"}},
+	}, nil).Once()
+	mockService.On("CheckInRoster", "Boxing", roster).Return(dto.RosterCheckInResult{}, newError.ErrClassNotExist).Once()
+
+	handler := NewClassHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/class/:name/checkin", handler.CheckInRoster)
+
+	// A partial check-in still succeeds and lists the failures
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/class/Yoga/checkin", bytes.NewBufferString(`{"bookingDate":"2030-06-10","memberIds":["mem_1","mem_2"]}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.RosterChecked)
+	assert.Contains(t, w.Body.String(), `"status":"attended"`)
+	assert.Contains(t, w.Body.String(), `"memberId":"mem_2"`)
+
+	// An unknown class fails the whole roster
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/class/Boxing/checkin", bytes.NewBufferString(`{"bookingDate":"2030-06-10","memberIds":["mem_1","mem_2"]}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetRoster_Handler(t *testing.T) {
+	// Prepare mock service with one member checked in and one on the waitlist
+	mockService := new(MockBusinessService)
+	mockService.On("GetRoster", "Yoga", dto.RosterQuery{Date: "2030-06-10"}).Return(dto.Roster{
+		ClassName:  "Yoga",
+		Occurrence: time.Date(2030, 6, 10, 7, 0, 0, 0, time.UTC),
+		Capacity:   1,
+		Booked:     []dto.RosterEntry{{MemberID: "mem_1", Name: "John", BookingID: "bkg_1", Status: dto.BookingAttended, CheckedIn: true}},
+		Waitlisted: []dto.RosterEntry{{MemberID: "mem_2", Name: "Jane, Doe", BookingID: "bkg_2", Status: dto.BookingWaitlisted, WaitlistPosition: 1}},
+	}, nil).Twice()
+
+	handler := NewClassHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/class/:name/roster", handler.GetRoster)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/class/Yoga/roster?date=2030-06-10", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.RosterFetched)
+	assert.Contains(t, w.Body.String(), `"checkedIn":true`)
+	assert.Contains(t, w.Body.String(), `"waitlistPosition":1`)
+
+	// Instructors asking for CSV get a printable file
+	req := httptest.NewRequest(http.MethodGet, "/class/Yoga/roster?date=2030-06-10", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=Yoga-2030-06-10-0700.csv`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "class,occurrence,memberId,name,status,checkedIn,waitlistPosition,bookingId\n"+
+		"Yoga,2030-06-10T07:00:00Z,mem_1,John,attended,true,,bkg_1\n"+
+		"Yoga,2030-06-10T07:00:00Z,mem_2,\"Jane, Doe\",waitlisted,false,1,bkg_2\n", w.Body.String())
+
+	// A missing date is reported on the query parameter
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/class/Yoga/roster", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"date"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetCalendar_Handler(t *testing.T) {
+	// Prepare mock service with the schedule of one class
+	mockService := new(MockBusinessService)
+	mockService.On("ClassCalendar").Return(ical.Calendar{Method: ical.MethodPublish, Events: []ical.Event{{
+		UID:     "class-Yoga-20300610T070000Z@glofox",
+		Start:   time.Date(2030, 6, 10, 7, 0, 0, 0, time.UTC),
+		End:     time.Date(2030, 6, 10, 8, 0, 0, 0, time.UTC),
+		Summary: "Yoga",
+	}}}, nil).Once()
+
+	handler := NewClassHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/class.ics", handler.GetCalendar)
+	r.GET("/class/:name", handler.GetClass)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/class.ics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, CalendarContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "UID:class-Yoga-20300610T070000Z@glofox\r\n")
+	assert.Contains(t, w.Body.String(), "DTEND:20300610T080000Z\r\n")
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"glofox/utils"
+	"mime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wantsCSV reports whether the client prefers CSV over JSON.
+func wantsCSV(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, utils.CSVContentType) == utils.CSVContentType
+}
+
+// respondCSV answers with the header row and records as a CSV attachment named filename.
+func respondCSV(c *gin.Context, status int, filename string, header []string, records [][]string) {
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	// Writing to a buffer can not fail
+	_ = writer.Write(header)
+	_ = writer.WriteAll(records)
+	attachment(c, filename)
+	c.Data(status, utils.CSVContentType+"; charset=utf-8", body.Bytes())
+}
+
+// attachment makes clients save the response as a file named filename.
+func attachment(c *gin.Context, filename string) {
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	newError "glofox/errors"
+	"glofox/utils"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// init makes the binding rules name fields the way clients send them, after
+// their JSON key or query parameter, rather than after the Go struct field.
+func init() {
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+// requestFieldName returns the JSON key or, failing that, the query parameter of a field.
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// errorStatuses maps every error code to the HTTP status it is answered with.
+// Codes that are not listed are internal errors.
+var errorStatuses = map[newError.Code]int{
+	newError.CodeMalformedRequest: http.StatusBadRequest,
+
+	newError.CodeUnauthenticated: http.StatusUnauthorized,
+	newError.CodeForbidden:       http.StatusForbidden,
+
+	newError.CodeValidationFailed:       http.StatusUnprocessableEntity,
+	newError.CodeDateOutOfRange:         http.StatusUnprocessableEntity,
+	newError.CodeNoClassOccurrence:      http.StatusUnprocessableEntity,
+	newError.CodeBookingNotOpen:         http.StatusUnprocessableEntity,
+	newError.CodeBookingClosed:          http.StatusUnprocessableEntity,
+	newError.CodeCancellationDatePassed: http.StatusUnprocessableEntity,
+	newError.CodeCancellationClosed:     http.StatusUnprocessableEntity,
+	newError.CodeCheckInNotOpen:         http.StatusUnprocessableEntity,
+
+	newError.CodeClassNotFound:   http.StatusNotFound,
+	newError.CodeBookingNotFound: http.StatusNotFound,
+	newError.CodeNotOnWaitlist:   http.StatusNotFound,
+	newError.CodeMemberNotFound:  http.StatusNotFound,
+	newError.CodePlanNotFound:    http.StatusNotFound,
+	newError.CodeStudioNotFound:  http.StatusNotFound,
+
+	newError.CodeClassAlreadyExists:  http.StatusConflict,
+	newError.CodeClassUpdateConflict: http.StatusConflict,
+	newError.CodeClassHasBookings:    http.StatusConflict,
+	newError.CodeClassFull:           http.StatusConflict,
+	newError.CodeDuplicateBooking:    http.StatusConflict,
+	newError.CodeAlreadyOnWaitlist:   http.StatusConflict,
+	newError.CodeMemberNotActive:     http.StatusConflict,
+	newError.CodeNoValidCredit:       http.StatusConflict,
+	newError.CodeBookingNotMovable:   http.StatusConflict,
+	newError.CodeCheckInNotAllowed:   http.StatusConflict,
+	newError.CodeMemberBlocked:       http.StatusConflict,
+	newError.CodeIdempotencyKeyInUse: http.StatusConflict,
+
+	newError.CodeIdempotencyKeyReused: http.StatusUnprocessableEntity,
+	newError.CodeImportRejected:       http.StatusUnprocessableEntity,
+}
+
+// errorStatus returns the HTTP status an error is answered with.
+func errorStatus(err error) int {
+	if status, ok := errorStatuses[newError.CodeOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// RespondError aborts the request with err. Clients asking for
+// application/problem+json get an RFC 7807 problem document, every other
+// client gets the standard response envelope; both carry the stable error code.
+// It is exported for the middleware of the router, which answers in the same way.
+func RespondError(c *gin.Context, err error) {
+	status := errorStatus(err)
+	if c.NegotiateFormat(gin.MIMEJSON, utils.ProblemContentType) == utils.ProblemContentType {
+		c.Header("Content-Type", utils.ProblemContentType)
+		c.AbortWithStatusJSON(status, utils.CreateProblem(status, err, c.Request.URL.Path))
+		return
+	}
+	c.AbortWithStatusJSON(status, utils.CreateErrorResp(err))
+}
+
+// bindError converts a failure to bind a request into a domain error. Values
+// of the wrong type and failed binding rules are reported per field; anything
+// else, such as malformed JSON, is ErrUnmarshalling.
+func bindError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &newError.ValidationError{Fields: []newError.FieldError{{
+			Field:   typeErr.Field,
+			Message: "must be of type " + typeErr.Type.String(),
+		}}}
+	}
+	var ruleErrs validator.ValidationErrors
+	if errors.As(err, &ruleErrs) {
+		fields := make([]newError.FieldError, 0, len(ruleErrs))
+		for _, ruleErr := range ruleErrs {
+			message := "failed the " + ruleErr.Tag() + " rule"
+			if ruleErr.Tag() == "required" {
+				message = "is required"
+			}
+			fields = append(fields, newError.FieldError{Field: ruleErr.Field(), Message: message})
+		}
+		return &newError.ValidationError{Fields: fields}
+	}
+	return newError.ErrUnmarshalling
+}
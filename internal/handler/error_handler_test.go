@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"glofox/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorStatus(t *testing.T) {
+	// Every domain error is answered with the status of its code
+	cases := map[error]int{
+		newError.ErrClassNotExist:        http.StatusNotFound,
+		newError.ErrMemberNotExist:       http.StatusNotFound,
+		newError.ErrSlotsFullForTheDate:  http.StatusConflict,
+		newError.ErrDuplicateBooking:     http.StatusConflict,
+		newError.ErrBookingDatePassed:    http.StatusUnprocessableEntity,
+		newError.ErrBookingClosed:        http.StatusUnprocessableEntity,
+		newError.ErrInvalidTimezone:      http.StatusUnprocessableEntity,
+		newError.ErrUnmarshalling:        http.StatusBadRequest,
+		errors.New("database is locked"): http.StatusInternalServerError,
+	}
+	for err, status := range cases {
+		assert.Equal(t, status, errorStatus(err), err.Error())
+	}
+}
+
+// performProblemRequest posts a booking asking for problem details in the response.
+func performProblemRequest(body string, handler BookingHandler) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.POST("/booking", handler.CreateBooking)
+
+	req := httptest.NewRequest(http.MethodPost, "/booking", bytes.NewBufferString(body))
+	req.Header.Set("Accept", utils.ProblemContentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRespondError_ProblemDetails(t *testing.T) {
+	// Prepare mock service reporting a full class
+	mockService := new(MockBusinessService)
+	mockService.On("CreateBooking", mock.AnythingOfType("dto.BookingInfo")).Return(dto.BookingResult{}, newError.ErrSlotsFullForTheDate).Once()
+
+	w := performProblemRequest(`{"className":"Yoga","memberId":"mem_1","bookingDate":"2025-06-01"}`, NewBookingHandler(mockService))
+
+	// Check that an RFC 7807 document is returned with the stable code
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem utils.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, utils.Problem{
+		Type:     "about:blank",
+		Title:    "Conflict",
+		Status:   http.StatusConflict,
+		Detail:   newError.ErrSlotsFullForTheDate.Error(),
+		Instance: "/booking",
+		Code:     newError.CodeClassFull,
+	}, problem)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRespondError_FieldDetails(t *testing.T) {
+	// The service is never reached when a field has the wrong type
+	mockService := new(MockBusinessService)
+
+	w := performProblemRequest(`{"className":"Yoga","memberId":"mem_1","joinWaitlist":"yes"}`, NewBookingHandler(mockService))
+
+	// Check that the invalid field is listed in the problem details
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var problem utils.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, newError.CodeValidationFailed, problem.Code)
+	assert.Equal(t, []newError.FieldError{{Field: "joinWaitlist", Message: "must be of type bool"}}, problem.Errors)
+
+	// Without asking for problem details the standard envelope carries the same code and fields
+	w = performRequestBookingHandler(http.MethodPost, "/booking", `{"className":"Yoga","memberId":"mem_1","joinWaitlist":"yes"}`, NewBookingHandler(mockService))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"success":false`)
+	assert.Contains(t, w.Body.String(), `"code":"VALIDATION_FAILED"`)
+	assert.Contains(t, w.Body.String(), `"field":"joinWaitlist"`)
+}
+
+func TestRespondError_RequiredFields(t *testing.T) {
+	// The service is never reached when a required field is left out
+	mockService := new(MockBusinessService)
+
+	w := performProblemRequest(`{"memberId":"mem_1","bookingDate":"2025-06-01"}`, NewBookingHandler(mockService))
+
+	// Check that the missing field is listed under its JSON name
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var problem utils.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, newError.CodeValidationFailed, problem.Code)
+	assert.Equal(t, []newError.FieldError{{Field: "className", Message: "is required"}}, problem.Errors)
+
+	// Every missing field of a class is reported at once, including those of its schedule
+	w = performRequest(http.MethodPost, "/class", `{"className":"Yoga","schedule":{"weekdays":["MO"]}}`, NewClassHandler(mockService))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	for _, field := range []string{"classCapacity", "startDate", "endDate", "startTime", "durationMinutes"} {
+		assert.Contains(t, w.Body.String(), `"field":"`+field+`"`)
+	}
+	assert.NotContains(t, w.Body.String(), `"field":"className"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRespondError_HidesInternalErrors(t *testing.T) {
+	// Prepare mock service failing with a storage error
+	mockService := new(MockBusinessService)
+	mockService.On("CreateBooking", mock.AnythingOfType("dto.BookingInfo")).
+		Return(dto.BookingResult{}, errors.New("open /var/lib/glofox/glofox.db: disk I/O error")).Twice()
+
+	// Neither the problem document nor the envelope reveals the storage error
+	w := performProblemRequest(`{"className":"Yoga","memberId":"mem_1","bookingDate":"2025-06-01"}`, NewBookingHandler(mockService))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var problem utils.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, newError.CodeInternal, problem.Code)
+	assert.Equal(t, "an internal error occurred", problem.Detail)
+
+	w = performRequestBookingHandler(http.MethodPost, "/booking", `{"className":"Yoga","memberId":"mem_1","bookingDate":"2025-06-01"}`, NewBookingHandler(mockService))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"success":false,"message":"an internal error occurred","code":"INTERNAL_ERROR"}`, w.Body.String())
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"glofox/constants"
+	newError "glofox/errors"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"glofox/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberHandler defines the interface for handling member-related HTTP requests.
+type MemberHandler interface {
+	CreateMember(c *gin.Context)
+	GetMember(c *gin.Context)
+	UpdateMember(c *gin.Context)
+	DeactivateMember(c *gin.Context)
+	PurchasePlan(c *gin.Context)
+	GetCreditBalance(c *gin.Context)
+	GetCreditLedger(c *gin.Context)
+	GetMemberBookings(c *gin.Context)
+	GetBookingCalendar(c *gin.Context)
+	GetAttendance(c *gin.Context)
+}
+
+// member is the concrete implementation of MemberHandler.
+// It holds the business service required to manage the member registry.
+type member struct {
+	service service.BusinessService
+}
+
+// NewMemberHandler constructs and returns a new MemberHandler with injected dependencies.
+func NewMemberHandler(services service.BusinessService) MemberHandler {
+	return &member{
+		service: services,
+	}
+}
+
+// CreateMember handles the POST /member endpoint.
+// It registers a new active member and returns it with its assigned id.
+func (member *member) CreateMember(c *gin.Context) {
+	var memberInfo dto.MemberInfo
+
+	// Attempt to bind the incoming JSON payload to the MemberInfo struct
+	err := c.ShouldBindJSON(&memberInfo)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	created, err := serviceFor(c, member.service).CreateMember(memberInfo)
+	if err != nil {
+		log.Println(newError.ErrCreatingMember.Error(), err.Error())
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.CreateResp(true, constants.MemberSaved, created))
+}
+
+// GetMember handles the GET /member/:id endpoint.
+func (member *member) GetMember(c *gin.Context) {
+	found, err := serviceFor(c, member.service).GetMember(c.Param("id"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.MemberFetched, found))
+}
+
+// UpdateMember handles the PUT /member/:id endpoint.
+// Fields omitted from the payload keep their current value.
+func (member *member) UpdateMember(c *gin.Context) {
+	var memberInfo dto.MemberInfo
+
+	// Attempt to bind the incoming JSON payload to the MemberInfo struct
+	err := c.ShouldBindJSON(&memberInfo)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	updated, err := serviceFor(c, member.service).UpdateMember(c.Param("id"), memberInfo)
+	if err != nil {
+		log.Println(newError.ErrCreatingMember.Error(), err.Error())
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.MemberSaved, updated))
+}
+
+// DeactivateMember handles the DELETE /member/:id endpoint.
+// The member is suspended rather than removed, so its booking history is kept.
+func (member *member) DeactivateMember(c *gin.Context) {
+	deactivated, err := serviceFor(c, member.service).DeactivateMember(c.Param("id"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.MemberDeact, deactivated))
+}
+
+// PurchasePlan handles the POST /member/:id/credits endpoint.
+// It grants the requested plan to the member and returns the new balance.
+func (member *member) PurchasePlan(c *gin.Context) {
+	var purchaseInfo dto.PurchaseInfo
+
+	// Attempt to bind the incoming JSON payload to the PurchaseInfo struct
+	err := c.ShouldBindJSON(&purchaseInfo)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	balance, err := serviceFor(c, member.service).PurchasePlan(c.Param("id"), purchaseInfo)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.CreateResp(true, constants.PlanPurchase, balance))
+}
+
+// GetCreditBalance handles the GET /member/:id/credits endpoint.
+func (member *member) GetCreditBalance(c *gin.Context) {
+	balance, err := serviceFor(c, member.service).GetCreditBalance(c.Param("id"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.CreditFetched, balance))
+}
+
+// GetCreditLedger handles the GET /member/:id/credits/ledger endpoint.
+// It lists every purchase, consumption and refund so front desk can explain the balance.
+func (member *member) GetCreditLedger(c *gin.Context) {
+	ledger, err := serviceFor(c, member.service).GetCreditLedger(c.Param("id"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.LedgerFetched, ledger))
+}
+
+// GetMemberBookings handles the GET /member/:id/bookings endpoint.
+// It pages through the past and upcoming bookings of a member, optionally
+// narrowed down with from, to and status query parameters.
+func (member *member) GetMemberBookings(c *gin.Context) {
+	var query dto.BookingQuery
+
+	// Attempt to bind the query parameters to the BookingQuery struct
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	page, err := serviceFor(c, member.service).GetMemberBookings(c.Param("id"), query)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.HistoryFetch, page))
+}
+
+// GetBookingCalendar handles the GET /member/:id/bookings.ics endpoint.
+// It returns the bookings of a member as an iCalendar feed to subscribe to.
+func (member *member) GetBookingCalendar(c *gin.Context) {
+	calendar, err := serviceFor(c, member.service).MemberCalendar(c.Param("id"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	respondCalendar(c, calendar)
+}
+
+// GetAttendance handles the GET /member/:id/attendance endpoint.
+// It returns how often the member attended and missed booked classes.
+func (member *member) GetAttendance(c *gin.Context) {
+	attendance, err := serviceFor(c, member.service).GetAttendance(c.Param("id"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.AttendFetched, attendance))
+}
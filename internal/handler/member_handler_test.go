@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"bytes"
+	"glofox/constants"
+	newError "glofox/errors"
+	"glofox/internal/ical"
+	"glofox/models/dto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func performMemberRequest(method, url, body string, handler MemberHandler) *httptest.ResponseRecorder {
+	// Set up the Gin router with every member route
+	r := gin.Default()
+	r.POST("/member", handler.CreateMember)
+	r.GET("/member/:id", handler.GetMember)
+	r.PUT("/member/:id", handler.UpdateMember)
+	r.DELETE("/member/:id", handler.DeactivateMember)
+	r.POST("/member/:id/credits", handler.PurchasePlan)
+	r.GET("/member/:id/credits", handler.GetCreditBalance)
+	r.GET("/member/:id/credits/ledger", handler.GetCreditLedger)
+	r.GET("/member/:id/bookings", handler.GetMemberBookings)
+	r.GET("/member/:id/bookings.ics", handler.GetBookingCalendar)
+	r.GET("/member/:id/attendance", handler.GetAttendance)
+
+	// Create and record the request
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, url, bytes.NewBufferString(body)))
+	return w
+}
+
+func TestCreateMember_ReturnsID(t *testing.T) {
+	// Prepare mock service assigning an id to the member
+	mockService := new(MockBusinessService)
+	mockService.On("CreateMember", dto.MemberInfo{Name: "John Doe", Email: "john@example.com"}).
+		Return(dto.Member{ID: "mem_1", Name: "John Doe", Email: "john@example.com", Status: dto.MemberActive}, nil).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodPost, "/member", `{"name":"John Doe","email":"john@example.com"}`, handler)
+
+	// Check that the member is created with its id
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), constants.MemberSaved)
+	assert.Contains(t, w.Body.String(), `"id":"mem_1"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateMember_ValidationError(t *testing.T) {
+	// Prepare mock service rejecting a member without a name
+	mockService := new(MockBusinessService)
+	mockService.On("CreateMember", dto.MemberInfo{}).Return(dto.Member{}, newError.ErrMemberNameRequired).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodPost, "/member", `{}`, handler)
+
+	// Check that the response code is Unprocessable Entity (422)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrMemberNameRequired.Error())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetMember_NotFound(t *testing.T) {
+	// Prepare mock service without the requested member
+	mockService := new(MockBusinessService)
+	mockService.On("GetMember", "mem_404").Return(dto.Member{}, newError.ErrMemberNotExist).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodGet, "/member/mem_404", "", handler)
+
+	// Check that the response code is NotFound (404)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrMemberNotExist.Error())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateMember_Success(t *testing.T) {
+	// Prepare mock service suspending the member
+	mockService := new(MockBusinessService)
+	mockService.On("UpdateMember", "mem_1", dto.MemberInfo{Status: dto.MemberSuspended}).
+		Return(dto.Member{ID: "mem_1", Status: dto.MemberSuspended}, nil).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodPut, "/member/mem_1", `{"status":"suspended"}`, handler)
+
+	// Check the response code and the new status
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"suspended"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDeactivateMember_NotFound(t *testing.T) {
+	// Prepare mock service without the requested member
+	mockService := new(MockBusinessService)
+	mockService.On("DeactivateMember", "mem_404").Return(dto.Member{}, newError.ErrMemberNotExist).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodDelete, "/member/mem_404", "", handler)
+
+	// Check that the response code is NotFound (404)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPurchasePlan_UnknownPlan(t *testing.T) {
+	// Prepare mock service without the requested plan
+	mockService := new(MockBusinessService)
+	mockService.On("PurchasePlan", "mem_1", dto.PurchaseInfo{PlanID: "plan_404"}).Return(dto.CreditBalance{}, newError.ErrPlanNotExist).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodPost, "/member/mem_1/credits", `{"planId":"plan_404"}`, handler)
+
+	// Check that the response code is NotFound (404)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrPlanNotExist.Error())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetCreditBalanceAndLedger(t *testing.T) {
+	// Prepare mock service with a member holding three credits
+	mockService := new(MockBusinessService)
+	mockService.On("GetCreditBalance", "mem_1").Return(dto.CreditBalance{MemberID: "mem_1", Credits: 3}, nil).Once()
+	mockService.On("GetCreditLedger", "mem_1").Return([]dto.CreditEntry{{Seq: 1, Type: dto.CreditPurchase, Credits: 3}}, nil).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	// Check the balance and the ledger explaining it
+	w := performMemberRequest(http.MethodGet, "/member/mem_1/credits", "", handler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"credits":3`)
+
+	w = performMemberRequest(http.MethodGet, "/member/mem_1/credits/ledger", "", handler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"type":"purchase"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetMemberBookings_Handler(t *testing.T) {
+	// Prepare mock service expecting the query string to be bound
+	mockService := new(MockBusinessService)
+	mockService.On("GetMemberBookings", "mem_1", dto.BookingQuery{
+		From:   "2030-06-01",
+		Status: []string{"confirmed", "attended"},
+		Limit:  10,
+	}).Return(dto.BookingPage{
+		Bookings: []dto.Booking{{ID: "bkg_1", MemberID: "mem_1", Status: dto.BookingConfirmed}},
+		Total:    11,
+		Limit:    10,
+	}, nil).Once()
+	mockService.On("GetMemberBookings", "mem_1", dto.BookingQuery{Status: []string{"gone"}}).
+		Return(dto.BookingPage{}, newError.ErrInvalidBookingStatus).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodGet, "/member/mem_1/bookings?from=2030-06-01&status=confirmed&status=attended&limit=10", "", handler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"bkg_1"`)
+	assert.Contains(t, w.Body.String(), `"total":11`)
+
+	// An unknown status is a validation failure on that parameter
+	w = performMemberRequest(http.MethodGet, "/member/mem_1/bookings?status=gone", "", handler)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"status"`)
+
+	// A limit that is not a number is rejected before reaching the service
+	w = performMemberRequest(http.MethodGet, "/member/mem_1/bookings?limit=ten", "", handler)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetAttendance_Handler(t *testing.T) {
+	// Prepare mock service with a member blocked after repeated no-shows
+	blockedUntil := time.Date(2030, 6, 18, 18, 0, 0, 0, time.UTC)
+	mockService := new(MockBusinessService)
+	mockService.On("GetAttendance", "mem_1").Return(dto.Attendance{MemberID: "mem_1", Attended: 4, NoShows: 2, BlockedUntil: &blockedUntil}, nil).Once()
+	mockService.On("GetAttendance", "mem_2").Return(dto.Attendance{}, newError.ErrMemberNotExist).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodGet, "/member/mem_1/attendance", "", handler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), constants.AttendFetched)
+	assert.Contains(t, w.Body.String(), `"noShows":2`)
+	assert.Contains(t, w.Body.String(), `"blockedUntil":"2030-06-18T18:00:00Z"`)
+
+	w = performMemberRequest(http.MethodGet, "/member/mem_2/attendance", "", handler)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetBookingCalendar_Handler(t *testing.T) {
+	// Prepare mock service with one booking and one cancellation
+	start := time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC)
+	mockService := new(MockBusinessService)
+	mockService.On("MemberCalendar", "mem_1").Return(ical.Calendar{Method: ical.MethodPublish, Events: []ical.Event{
+		{UID: "bkg_1@glofox", Start: start, Summary: "Spin", Status: ical.StatusConfirmed},
+		{UID: "bkg_2@glofox", Start: start, Summary: "Spin", Status: ical.StatusCancelled, Sequence: 1},
+	}}, nil).Once()
+	mockService.On("MemberCalendar", "mem_2").Return(ical.Calendar{}, newError.ErrMemberNotExist).Once()
+
+	handler := NewMemberHandler(mockService)
+
+	w := performMemberRequest(http.MethodGet, "/member/mem_1/bookings.ics", "", handler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, CalendarContentType, w.Header().Get("Content-Type"))
+	// Subscribed calendars read a single calendar, cancelled bookings included
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "BEGIN:VCALENDAR\r\n"))
+	assert.NotContains(t, w.Body.String(), "METHOD:CANCEL")
+	assert.Contains(t, w.Body.String(), "UID:bkg_2@glofox\r\n")
+	assert.Contains(t, w.Body.String(), "SEQUENCE:1\r\nSUMMARY:Spin\r\nSTATUS:CANCELLED\r\n")
+
+	w = performMemberRequest(http.MethodGet, "/member/mem_2/bookings.ics", "", handler)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"glofox/constants"
+	newError "glofox/errors"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"glofox/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanHandler defines the interface for handling membership plan HTTP requests.
+type PlanHandler interface {
+	CreatePlan(c *gin.Context)
+	GetPlans(c *gin.Context)
+}
+
+// plan is the concrete implementation of PlanHandler.
+type plan struct {
+	service service.BusinessService
+}
+
+// NewPlanHandler constructs and returns a new PlanHandler with injected dependencies.
+func NewPlanHandler(services service.BusinessService) PlanHandler {
+	return &plan{
+		service: services,
+	}
+}
+
+// CreatePlan handles the POST /plan endpoint.
+// It stores a class pack or unlimited plan and returns it with its assigned id.
+func (plan *plan) CreatePlan(c *gin.Context) {
+	var planInfo dto.PlanInfo
+
+	// Attempt to bind the incoming JSON payload to the PlanInfo struct
+	err := c.ShouldBindJSON(&planInfo)
+	if err != nil {
+		log.Println(newError.ErrUnmarshalling.Error(), err.Error())
+		RespondError(c, bindError(err))
+		return
+	}
+
+	created, err := serviceFor(c, plan.service).CreatePlan(planInfo)
+	if err != nil {
+		log.Println(newError.ErrCreatingPlan.Error(), err.Error())
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.CreateResp(true, constants.PlanSaved, created))
+}
+
+// GetPlans handles the GET /plan endpoint.
+func (plan *plan) GetPlans(c *gin.Context) {
+	plans, err := serviceFor(c, plan.service).GetPlans()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.CreateResp(true, constants.PlanFetched, plans))
+}
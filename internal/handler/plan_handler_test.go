@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"bytes"
+	"glofox/constants"
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func performPlanRequest(method, body string, handler PlanHandler) *httptest.ResponseRecorder {
+	// Set up the Gin router with the plan routes
+	r := gin.Default()
+	r.POST("/plan", handler.CreatePlan)
+	r.GET("/plan", handler.GetPlans)
+
+	// Create and record the request
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, "/plan", bytes.NewBufferString(body)))
+	return w
+}
+
+func TestCreatePlan_Success(t *testing.T) {
+	// Prepare mock service storing a class pack
+	mockService := new(MockBusinessService)
+	info := dto.PlanInfo{Name: "10 classes", Type: dto.PlanPack, Credits: 10, ValidDays: 90}
+	mockService.On("CreatePlan", info).Return(dto.Plan{ID: "plan_1", Name: info.Name, Type: info.Type, Credits: 10, ValidDays: 90}, nil).Once()
+
+	handler := NewPlanHandler(mockService)
+
+	w := performPlanRequest(http.MethodPost, `{"name":"10 classes","type":"pack","credits":10,"validDays":90}`, handler)
+
+	// Check that the plan is created with its id
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), constants.PlanSaved)
+	assert.Contains(t, w.Body.String(), `"id":"plan_1"`)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreatePlan_Invalid(t *testing.T) {
+	// Prepare mock service rejecting the plan
+	mockService := new(MockBusinessService)
+	mockService.On("CreatePlan", dto.PlanInfo{Name: "Trial", Type: "trial", ValidDays: 30}).Return(dto.Plan{}, newError.ErrInvalidPlan).Once()
+
+	handler := NewPlanHandler(mockService)
+
+	w := performPlanRequest(http.MethodPost, `{"name":"Trial","type":"trial","validDays":30}`, handler)
+
+	// Check that the response code is Unprocessable Entity (422)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), newError.ErrInvalidPlan.Error())
+
+	mockService.AssertExpectations(t)
+}
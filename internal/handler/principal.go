@@ -0,0 +1,44 @@
+package handler
+
+import (
+	newError "glofox/errors"
+	"glofox/internal/auth"
+	"glofox/models/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrincipalOf returns the client a request was authenticated as. It reports
+// false when authentication is turned off and the request is anonymous.
+// It is exported for the middleware of the router, which also needs it.
+func PrincipalOf(c *gin.Context) (auth.Principal, bool) {
+	value, ok := c.Get(auth.PrincipalKey)
+	if !ok {
+		return auth.Principal{}, false
+	}
+	principal, ok := value.(auth.Principal)
+	return principal, ok
+}
+
+// actingMember returns the member a request acts for. Members can only act
+// for themselves: an empty memberID is theirs and any other one is refused.
+// API clients act for whichever member they name.
+func actingMember(c *gin.Context, memberID string) (string, error) {
+	principal, ok := PrincipalOf(c)
+	if !ok || !principal.IsMember() {
+		return memberID, nil
+	}
+	if memberID != "" && memberID != principal.MemberID {
+		return "", newError.ErrNotOwnMember
+	}
+	return principal.MemberID, nil
+}
+
+// ownBooking refuses members access to a booking held by another member.
+func ownBooking(c *gin.Context, record dto.Booking) error {
+	principal, ok := PrincipalOf(c)
+	if ok && principal.IsMember() && record.MemberID != principal.MemberID {
+		return newError.ErrNotOwnMember
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"glofox/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StudioKey is the key the business service of the studio a request is for is
+// kept under in the request context.
+const StudioKey = "studio"
+
+// serviceFor returns the business service of the studio the request is for,
+// as resolved by the router, or fallback when the router resolved none.
+func serviceFor(c *gin.Context, fallback service.BusinessService) service.BusinessService {
+	if value, ok := c.Get(StudioKey); ok {
+		if services, ok := value.(service.BusinessService); ok {
+			return services
+		}
+	}
+	return fallback
+}
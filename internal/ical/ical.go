@@ -0,0 +1,169 @@
+// Package ical writes iCalendar (RFC 5545) streams, so that class schedules
+// and bookings can be subscribed to from calendar applications.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ProdID identifies the product that wrote a calendar.
+const ProdID = "-//Glofox//Class Booking API//EN"
+
+// Methods of a calendar (RFC 5546). A published calendar holds the current
+// events; a cancelling one withdraws events published before.
+const (
+	MethodPublish = "PUBLISH"
+	MethodCancel  = "CANCEL"
+)
+
+// Statuses of an event.
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	maxLineOctets = 75
+	dateFormat    = "20060102"
+	localFormat   = "20060102T150405"
+	utcFormat     = "20060102T150405Z"
+)
+
+// Calendar is a VCALENDAR object with its events.
+type Calendar struct {
+	Name   string // Shown by calendar applications as the name of a subscribed calendar
+	Method string
+	Events []Event
+}
+
+// Event is a VEVENT. Start and End are written in the location they are in:
+// as UTC times for UTC, or as local times referring to a VTIMEZONE otherwise.
+// An all-day event starts at the local midnight of its first day and ends at
+// the local midnight after its last day. Without an End the event ends as it starts.
+type Event struct {
+	UID         string
+	Sequence    int
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Summary     string
+	Description string
+	Status      string
+}
+
+// Encode writes the calendars to w as one iCalendar stream, each with the
+// time zones its events refer to. Events are written in order of their start.
+func Encode(w io.Writer, calendars ...Calendar) error {
+	out := &writer{w: bufio.NewWriter(w)}
+	for _, calendar := range calendars {
+		out.calendar(calendar)
+	}
+	return out.flush()
+}
+
+// writer writes content lines, keeping the first error it runs into.
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (out *writer) flush() error {
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+func (out *writer) calendar(calendar Calendar) {
+	events := slices.Clone(calendar.Events)
+	slices.SortStableFunc(events, func(a, b Event) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return strings.Compare(a.UID, b.UID)
+	})
+
+	out.line("BEGIN", "VCALENDAR")
+	out.line("VERSION", "2.0")
+	out.line("PRODID", ProdID)
+	out.line("CALSCALE", "GREGORIAN")
+	if calendar.Method != "" {
+		out.line("METHOD", calendar.Method)
+	}
+	if calendar.Name != "" {
+		out.line("X-WR-CALNAME", escape(calendar.Name))
+	}
+	for _, zone := range zonesOf(events) {
+		out.timezone(zone)
+	}
+	for _, event := range events {
+		out.event(event)
+	}
+	out.line("END", "VCALENDAR")
+}
+
+func (out *writer) event(event Event) {
+	out.line("BEGIN", "VEVENT")
+	out.line("UID", event.UID)
+	out.line("DTSTAMP", event.Stamp.UTC().Format(utcFormat))
+	out.time("DTSTART", event.Start, event.AllDay)
+	if !event.End.IsZero() {
+		out.time("DTEND", event.End, event.AllDay)
+	}
+	if event.Sequence > 0 {
+		out.line("SEQUENCE", strconv.Itoa(event.Sequence))
+	}
+	out.line("SUMMARY", escape(event.Summary))
+	if event.Description != "" {
+		out.line("DESCRIPTION", escape(event.Description))
+	}
+	if event.Status != "" {
+		out.line("STATUS", event.Status)
+	}
+	out.line("END", "VEVENT")
+}
+
+// time writes a DATE, a UTC DATE-TIME or a local DATE-TIME with its TZID.
+func (out *writer) time(name string, t time.Time, allDay bool) {
+	switch {
+	case allDay:
+		out.line(name+";VALUE=DATE", t.Format(dateFormat))
+	case t.Location() == time.UTC:
+		out.line(name, t.Format(utcFormat))
+	default:
+		out.line(name+";TZID="+t.Location().String(), t.Format(localFormat))
+	}
+}
+
+// line writes a content line, folded after 75 octets without splitting a character.
+func (out *writer) line(name, value string) {
+	if out.err != nil {
+		return
+	}
+	line := name + ":" + value
+	var folded strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > maxLineOctets {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	folded.WriteString("\r\n")
+	_, out.err = out.w.WriteString(folded.String())
+}
+
+// escape escapes a TEXT value.
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
@@ -0,0 +1,69 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(t *testing.T, calendars ...Calendar) string {
+	var out bytes.Buffer
+	require.NoError(t, Encode(&out, calendars...))
+	return out.String()
+}
+
+func TestEncode_EscapesAndFoldsText(t *testing.T) {
+	summary := "Yoga; stretch, breathe \\ relax\nthen Café time with a rather long name that keeps on going ☕☕☕"
+	out := encode(t, Calendar{Events: []Event{{
+		UID:     "evt_1@glofox",
+		Stamp:   time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC),
+		Start:   time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC),
+		Summary: summary,
+	}}})
+
+	// Every line ends in CRLF and is at most 75 octets long
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+
+	// Unfolding restores the escaped text without splitting a character
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	assert.Contains(t, unfolded, "\r\nSUMMARY:"+`Yoga\; stretch\, breathe \\ relax\nthen Café time with a rather long name that keeps on going ☕☕☕`+"\r\n")
+	assert.Contains(t, unfolded, "\r\nDTSTART:20300610T180000Z\r\n")
+	assert.NotContains(t, out, "DTEND")
+	assert.NotContains(t, out, "METHOD")
+}
+
+func TestEncode_TimeZones(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	out := encode(t, Calendar{Method: MethodPublish, Events: []Event{
+		{UID: "b", Start: time.Date(2030, 11, 4, 9, 0, 0, 0, newYork), End: time.Date(2030, 11, 4, 10, 0, 0, 0, newYork)},
+		{UID: "a", Start: time.Date(2030, 10, 1, 9, 0, 0, 0, newYork), End: time.Date(2030, 10, 1, 10, 0, 0, 0, newYork)},
+		{UID: "c", Start: time.Date(2030, 10, 1, 9, 0, 0, 0, kolkata), End: time.Date(2030, 10, 1, 10, 0, 0, 0, kolkata)},
+		{UID: "d", Start: time.Date(2030, 10, 2, 0, 0, 0, 0, newYork), End: time.Date(2030, 10, 3, 0, 0, 0, 0, newYork), AllDay: true},
+	}})
+
+	// New York falls back on November 3rd, between its two events
+	assert.Contains(t, out, "BEGIN:VTIMEZONE\r\nTZID:America/New_York\r\n"+
+		"BEGIN:DAYLIGHT\r\nDTSTART:20300310T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nTZNAME:EDT\r\nEND:DAYLIGHT\r\n"+
+		"BEGIN:STANDARD\r\nDTSTART:20301103T020000\r\nTZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\nTZNAME:EST\r\nEND:STANDARD\r\n"+
+		"END:VTIMEZONE\r\n")
+	assert.Contains(t, out, "TZID:Asia/Kolkata\r\nBEGIN:STANDARD\r\n")
+	assert.Contains(t, out, "TZOFFSETTO:+0530\r\n")
+	assert.Contains(t, out, "DTSTART;TZID=America/New_York:20301104T090000\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20301002\r\nDTEND;VALUE=DATE:20301003\r\n")
+
+	// Events are ordered by start, then by UID
+	assert.Less(t, strings.Index(out, "UID:c"), strings.Index(out, "UID:a"))
+	assert.Less(t, strings.Index(out, "UID:a"), strings.Index(out, "UID:d"))
+	assert.Less(t, strings.Index(out, "UID:d"), strings.Index(out, "UID:b"))
+}
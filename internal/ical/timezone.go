@@ -0,0 +1,96 @@
+package ical
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// zone is a time zone events refer to, with the span of time they cover.
+type zone struct {
+	loc         *time.Location
+	first, last time.Time
+}
+
+// zonesOf returns the time zones of the events that are neither in UTC nor all-day, sorted by name.
+func zonesOf(events []Event) []zone {
+	var zones []zone
+	for _, event := range events {
+		loc := event.Start.Location()
+		if event.AllDay || loc == time.UTC {
+			continue
+		}
+		index := slices.IndexFunc(zones, func(z zone) bool { return z.loc.String() == loc.String() })
+		if index < 0 {
+			zones = append(zones, zone{loc: loc, first: event.Start, last: event.Start})
+			index = len(zones) - 1
+		}
+		end := event.End
+		if end.IsZero() {
+			end = event.Start
+		}
+		if event.Start.Before(zones[index].first) {
+			zones[index].first = event.Start
+		}
+		if end.After(zones[index].last) {
+			zones[index].last = end
+		}
+	}
+	slices.SortFunc(zones, func(a, b zone) int { return strings.Compare(a.loc.String(), b.loc.String()) })
+	return zones
+}
+
+// timezone writes a VTIMEZONE with one observance for every offset the zone
+// uses between its first and last event, taken from the zone database. Each
+// observance starts at the transition into it, so no recurrence rules are needed.
+func (out *writer) timezone(z zone) {
+	out.line("BEGIN", "VTIMEZONE")
+	out.line("TZID", z.loc.String())
+	t := z.first.In(z.loc)
+	for {
+		start, end := t.ZoneBounds()
+		if start.IsZero() {
+			start = t
+		}
+		out.observance(start)
+		if end.IsZero() || end.After(z.last) {
+			break
+		}
+		t = end
+	}
+	out.line("END", "VTIMEZONE")
+}
+
+// observance writes the STANDARD or DAYLIGHT period beginning at onset. A
+// period moving the clocks forward is written as daylight saving time, which
+// keeps the output independent of how the zone database flags it.
+func (out *writer) observance(onset time.Time) {
+	name, offset := onset.Zone()
+	_, before := onset.Add(-time.Second).Zone()
+	kind := "STANDARD"
+	if offset > before {
+		kind = "DAYLIGHT"
+	}
+	out.line("BEGIN", kind)
+	// The onset is a local time in the offset in use before it
+	out.line("DTSTART", onset.In(time.FixedZone("", before)).Format(localFormat))
+	out.line("TZOFFSETFROM", formatOffset(before))
+	out.line("TZOFFSETTO", formatOffset(offset))
+	out.line("TZNAME", name)
+	out.line("END", kind)
+}
+
+// formatOffset formats a UTC offset in seconds as ±hhmm, or ±hhmmss when it has seconds.
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	formatted := fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+	if seconds := offset % 60; seconds != 0 {
+		formatted += fmt.Sprintf("%02d", seconds)
+	}
+	return formatted
+}
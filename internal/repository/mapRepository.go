@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	mapstore "glofox/core"
+	"glofox/models/dto"
+)
+
+// mapRepository is a Repository backed by a MapStore, either the in-memory
+// map or the durable file store. Classes are stored under their name, and
+// members, plans, credit accounts and bookings under their id, each in its own key namespace.
+// Active bookings are also indexed by class occurrence and member, and every
+// booking by the member who holds it. Studios other than the default one keep
+// their namespaces under a prefix of their own.
+type mapRepository struct {
+	syMap    mapstore.MapStore
+	classes  mapstore.TypedStore[dto.ClassInfo]
+	members  mapstore.TypedStore[dto.Member]
+	plans    mapstore.TypedStore[dto.Plan]
+	credits  mapstore.TypedStore[dto.CreditAccount]
+	bookings mapstore.TypedStore[dto.Booking]
+	slots    mapstore.TypedStore[string]
+	history  mapstore.TypedStore[[]string]
+}
+
+// Key prefixes separating the kinds of values kept in the shared MapStore.
+const (
+	classPrefix   = "class:"
+	memberPrefix  = "member:"
+	planPrefix    = "plan:"
+	creditPrefix  = "credits:"
+	bookingPrefix = "booking:"
+	slotPrefix    = "bookingslot:"
+	historyPrefix = "memberbookings:"
+	studioPrefix  = "studio:"
+)
+
+// NewMapRepository wraps the given MapStore in a Repository of the default studio.
+func NewMapRepository(syMap mapstore.MapStore) Repository {
+	return newMapRepository(syMap, "")
+}
+
+// newMapRepository wraps syMap in the Repository of studio. The default studio
+// keeps its keys unprefixed, as they were before there were studios.
+func newMapRepository(syMap mapstore.MapStore, studio string) *mapRepository {
+	prefix := ""
+	if studio != "" {
+		prefix = studioPrefix + studio + "/"
+	}
+	return &mapRepository{
+		syMap:    syMap,
+		classes:  mapstore.NewTypedStore[dto.ClassInfo](syMap, prefix+classPrefix),
+		members:  mapstore.NewTypedStore[dto.Member](syMap, prefix+memberPrefix),
+		plans:    mapstore.NewTypedStore[dto.Plan](syMap, prefix+planPrefix),
+		credits:  mapstore.NewTypedStore[dto.CreditAccount](syMap, prefix+creditPrefix),
+		bookings: mapstore.NewTypedStore[dto.Booking](syMap, prefix+bookingPrefix),
+		slots:    mapstore.NewTypedStore[string](syMap, prefix+slotPrefix),
+		history:  mapstore.NewTypedStore[[]string](syMap, prefix+historyPrefix),
+	}
+}
+
+// Studio returns the repository of the studio with the given id on the same MapStore.
+func (repo *mapRepository) Studio(id string) Repository {
+	return newMapRepository(repo.syMap, id)
+}
+
+// LoadClass retrieves the class stored under name.
+func (repo *mapRepository) LoadClass(name string) (dto.ClassInfo, bool, error) {
+	info, exist := repo.classes.Load(name)
+	return info, exist, nil
+}
+
+// StoreClass saves the class under name, replacing any previous value.
+func (repo *mapRepository) StoreClass(name string, info dto.ClassInfo) error {
+	return repo.classes.Store(name, info)
+}
+
+// DeleteClass removes the class stored under name.
+func (repo *mapRepository) DeleteClass(name string) error {
+	return repo.classes.Delete(name)
+}
+
+// ClassNames returns the name of every stored class, sorted.
+func (repo *mapRepository) ClassNames() ([]string, error) {
+	return repo.classes.Keys(), nil
+}
+
+// UpdateClass atomically replaces the class stored under name with the result of fn.
+// fn works on a copy, so the stored value is never mutated in place.
+func (repo *mapRepository) UpdateClass(name string, fn ClassUpdateFunc) (dto.ClassInfo, error) {
+	return repo.classes.Update(name, func(old dto.ClassInfo, exists bool) (dto.ClassInfo, error) {
+		if !exists {
+			return fn(old, false)
+		}
+		return fn(cloneClass(old), true)
+	})
+}
+
+// LoadMember retrieves the member stored under id.
+func (repo *mapRepository) LoadMember(id string) (dto.Member, bool, error) {
+	member, exist := repo.members.Load(id)
+	return member, exist, nil
+}
+
+// StoreMember saves the member under its id, replacing any previous value.
+func (repo *mapRepository) StoreMember(member dto.Member) error {
+	return repo.members.Store(member.ID, member)
+}
+
+// UpdateMember atomically replaces the member stored under id with the result of fn.
+func (repo *mapRepository) UpdateMember(id string, fn MemberUpdateFunc) (dto.Member, error) {
+	return repo.members.Update(id, fn)
+}
+
+// LoadPlan retrieves the plan stored under id.
+func (repo *mapRepository) LoadPlan(id string) (dto.Plan, bool, error) {
+	plan, exist := repo.plans.Load(id)
+	return plan, exist, nil
+}
+
+// StorePlan saves the plan under its id, replacing any previous value.
+func (repo *mapRepository) StorePlan(plan dto.Plan) error {
+	return repo.plans.Store(plan.ID, plan)
+}
+
+// Plans returns every stored plan ordered by id.
+func (repo *mapRepository) Plans() ([]dto.Plan, error) {
+	plans := make([]dto.Plan, 0)
+	for _, id := range repo.plans.Keys() {
+		if plan, exist := repo.plans.Load(id); exist {
+			plans = append(plans, plan)
+		}
+	}
+	return plans, nil
+}
+
+// LoadCredits retrieves the credit account of the member with the given id.
+func (repo *mapRepository) LoadCredits(memberID string) (dto.CreditAccount, bool, error) {
+	account, exist := repo.credits.Load(memberID)
+	return account, exist, nil
+}
+
+// UpdateCredits atomically replaces the credit account of a member with the result of fn.
+// fn works on a copy, so the stored value is never mutated in place.
+func (repo *mapRepository) UpdateCredits(memberID string, fn CreditUpdateFunc) (dto.CreditAccount, error) {
+	return repo.credits.Update(memberID, func(old dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+		return fn(cloneCredits(old), exists)
+	})
+}
+
+// LoadBooking retrieves the booking record stored under id.
+func (repo *mapRepository) LoadBooking(id string) (dto.Booking, bool, error) {
+	booking, exist := repo.bookings.Load(id)
+	return booking, exist, nil
+}
+
+// StoreBooking saves the booking record under its id and indexes it by its
+// occurrence while it is active. When a write fails, the ones before it are
+// taken back, so the record and its indexes never disagree.
+func (repo *mapRepository) StoreBooking(booking dto.Booking) error {
+	var undo undoLog
+	err := record(&undo, repo.bookings, booking.ID, func() error {
+		return repo.bookings.Store(booking.ID, booking)
+	})
+	if err == nil {
+		err = repo.indexSlot(&undo, booking)
+	}
+	if err == nil {
+		err = repo.indexMember(&undo, booking.MemberID, booking.ID)
+	}
+	if err != nil {
+		undo.rollback()
+	}
+	return err
+}
+
+// UpdateBooking replaces the booking record stored under id with the result
+// of fn and moves its index entries along. The record itself is replaced
+// atomically; when a later index write fails, the record and the index
+// entries written so far are put back as they were.
+func (repo *mapRepository) UpdateBooking(id string, fn BookingUpdateFunc) (dto.Booking, error) {
+	var previous dto.Booking
+	var existed bool
+	booking, err := repo.bookings.Update(id, func(old dto.Booking, exists bool) (dto.Booking, error) {
+		previous, existed = old, exists
+		return fn(old, exists)
+	})
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	undo := undoLog{restore(repo.bookings, id, previous, existed)}
+
+	// A booking moved to another occurrence no longer holds its old place
+	if existed && slotKey(previous.ClassName, previous.Occurrence, previous.MemberID) !=
+		slotKey(booking.ClassName, booking.Occurrence, booking.MemberID) {
+		released := previous
+		released.Status = dto.BookingCancelled
+		err = repo.indexSlot(&undo, released)
+	}
+	if err == nil {
+		err = repo.indexSlot(&undo, booking)
+	}
+	if err == nil && previous.MemberID != booking.MemberID {
+		err = repo.unindexMember(&undo, previous.MemberID, booking.ID)
+		if err == nil {
+			err = repo.indexMember(&undo, booking.MemberID, booking.ID)
+		}
+	}
+	if err != nil {
+		undo.rollback()
+		return dto.Booking{}, err
+	}
+	return booking, nil
+}
+
+// MemberBookings reads the bookings of a member through the member index, so
+// that only the bookings of that member are loaded.
+func (repo *mapRepository) MemberBookings(memberID string, filter BookingFilter) ([]dto.Booking, error) {
+	ids, _ := repo.history.Load(memberID)
+	bookings := make([]dto.Booking, 0, len(ids))
+	for _, id := range ids {
+		booking, exist := repo.bookings.Load(id)
+		if exist && filter.matches(booking) {
+			bookings = append(bookings, booking)
+		}
+	}
+	sortBookings(bookings)
+	return bookings, nil
+}
+
+// Bookings reads every booking record matching filter.
+func (repo *mapRepository) Bookings(filter BookingFilter) ([]dto.Booking, error) {
+	bookings := make([]dto.Booking, 0)
+	for _, id := range repo.bookings.Keys() {
+		booking, exist := repo.bookings.Load(id)
+		if exist && filter.matches(booking) {
+			bookings = append(bookings, booking)
+		}
+	}
+	sortBookings(bookings)
+	return bookings, nil
+}
+
+// indexMember adds a booking to the index of the member holding it.
+func (repo *mapRepository) indexMember(undo *undoLog, memberID, id string) error {
+	return record(undo, repo.history, memberID, func() error {
+		_, err := repo.history.Update(memberID, func(ids []string, exists bool) ([]string, error) {
+			if slices.Contains(ids, id) {
+				return ids, nil
+			}
+			return append(slices.Clone(ids), id), nil
+		})
+		return err
+	})
+}
+
+// unindexMember drops a booking from the index of a member who no longer holds it.
+func (repo *mapRepository) unindexMember(undo *undoLog, memberID, id string) error {
+	return record(undo, repo.history, memberID, func() error {
+		_, err := repo.history.Update(memberID, func(ids []string, exists bool) ([]string, error) {
+			index := slices.Index(ids, id)
+			if index < 0 {
+				return ids, nil
+			}
+			if len(ids) == 1 {
+				return nil, mapstore.ErrDeleteKey
+			}
+			return slices.Delete(slices.Clone(ids), index, index+1), nil
+		})
+		return err
+	})
+}
+
+// FindBooking retrieves the active booking record of a member for a class occurrence.
+func (repo *mapRepository) FindBooking(className string, occurrence time.Time, memberID string) (dto.Booking, bool, error) {
+	id, exist := repo.slots.Load(slotKey(className, occurrence, memberID))
+	if !exist {
+		return dto.Booking{}, false, nil
+	}
+	booking, exist := repo.bookings.Load(id)
+	if !exist || !activeBooking(booking) || booking.ClassName != className ||
+		!booking.Occurrence.Equal(occurrence) || booking.MemberID != memberID {
+		return dto.Booking{}, false, nil
+	}
+	return booking, true, nil
+}
+
+// indexSlot points the occurrence index at an active booking, and drops the
+// entry of a booking that is no longer active.
+func (repo *mapRepository) indexSlot(undo *undoLog, booking dto.Booking) error {
+	key := slotKey(booking.ClassName, booking.Occurrence, booking.MemberID)
+	if activeBooking(booking) {
+		return record(undo, repo.slots, key, func() error {
+			return repo.slots.Store(key, booking.ID)
+		})
+	}
+	if id, exist := repo.slots.Load(key); exist && id == booking.ID {
+		return record(undo, repo.slots, key, func() error {
+			return repo.slots.Delete(key)
+		})
+	}
+	return nil
+}
+
+// slotKey identifies the place of a member in a class occurrence. The class
+// name and member id are escaped, so that a "|" in them can not make two
+// places share a key; names without "|" or "%" keep the key they always had.
+func slotKey(className string, occurrence time.Time, memberID string) string {
+	return slotKeyEscaper.Replace(className) + "|" + occurrence.UTC().Format(time.RFC3339) + "|" + slotKeyEscaper.Replace(memberID)
+}
+
+// slotKeyEscaper escapes the separator of slot keys, and the escape character itself.
+var slotKeyEscaper = strings.NewReplacer("%", "%25", "|", "%7C")
+
+// undoLog takes back the writes of a change spanning several keys when a
+// later write of the change fails.
+type undoLog []func() error
+
+// record performs write and, once it succeeded, adds to undo how to put back
+// the value key held before.
+func record[T any](undo *undoLog, store mapstore.TypedStore[T], key string, write func() error) error {
+	old, exist := store.Load(key)
+	if err := write(); err != nil {
+		return err
+	}
+	*undo = append(*undo, restore(store, key, old, exist))
+	return nil
+}
+
+// restore returns a function putting old back under key, or deleting key when it did not exist.
+func restore[T any](store mapstore.TypedStore[T], key string, old T, exist bool) func() error {
+	return func() error {
+		if exist {
+			return store.Store(key, old)
+		}
+		return store.Delete(key)
+	}
+}
+
+// rollback takes back the recorded writes, latest first. A write that can not
+// be taken back is only logged, as the change has failed already.
+func (undo undoLog) rollback() {
+	for i := len(undo) - 1; i >= 0; i-- {
+		if err := undo[i](); err != nil {
+			log.Println("Error: failed to roll back booking write", err)
+		}
+	}
+}
+
+// Close releases the underlying store when it holds resources such as files.
+func (repo *mapRepository) Close() error {
+	if closer, ok := repo.syMap.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
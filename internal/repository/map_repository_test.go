@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mapstore "glofox/core"
+	"glofox/models/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errWriteFailed = errors.New("write failed")
+
+// failingStore fails every write to a key starting with failOn, as a file
+// store does when its log can not be written.
+type failingStore struct {
+	mapstore.MapStore
+	failOn string
+}
+
+func (store *failingStore) failing(key string) bool {
+	return store.failOn != "" && strings.HasPrefix(key, store.failOn)
+}
+
+func (store *failingStore) Store(key string, value interface{}) error {
+	if store.failing(key) {
+		return errWriteFailed
+	}
+	return store.MapStore.Store(key, value)
+}
+
+func (store *failingStore) Delete(key string) error {
+	if store.failing(key) {
+		return errWriteFailed
+	}
+	return store.MapStore.Delete(key)
+}
+
+func (store *failingStore) Update(key string, fn mapstore.UpdateFunc) (interface{}, error) {
+	if store.failing(key) {
+		return nil, errWriteFailed
+	}
+	return store.MapStore.Update(key, fn)
+}
+
+func TestMapRepository_BookingWritesRollBack(t *testing.T) {
+	store := &failingStore{MapStore: mapstore.NewShardedMapStore(4)}
+	repo := NewMapRepository(store)
+	occurrence := time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC)
+	booking := dto.Booking{ID: "bkg_1", MemberID: "john_doe", ClassName: "Spin", Occurrence: occurrence, Status: dto.BookingConfirmed}
+
+	// The member index can not be written, so the record and its place are taken back
+	store.failOn = historyPrefix
+	assert.Equal(t, errWriteFailed, repo.StoreBooking(booking))
+	_, exist, err := repo.LoadBooking("bkg_1")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	_, exist, err = repo.FindBooking("Spin", occurrence, "john_doe")
+	require.NoError(t, err)
+	assert.False(t, exist)
+
+	store.failOn = ""
+	require.NoError(t, repo.StoreBooking(booking))
+
+	// A transfer that can not be indexed leaves the booking with its holder
+	store.failOn = historyPrefix
+	_, err = repo.UpdateBooking("bkg_1", func(current dto.Booking, exists bool) (dto.Booking, error) {
+		current.MemberID = "jane_doe"
+		return current, nil
+	})
+	assert.Equal(t, errWriteFailed, err)
+	loaded, _, err := repo.LoadBooking("bkg_1")
+	require.NoError(t, err)
+	assert.Equal(t, "john_doe", loaded.MemberID)
+	found, exist, err := repo.FindBooking("Spin", occurrence, "john_doe")
+	require.NoError(t, err)
+	require.True(t, exist)
+	assert.Equal(t, "bkg_1", found.ID)
+	_, exist, err = repo.FindBooking("Spin", occurrence, "jane_doe")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	history, err := repo.MemberBookings("john_doe", BookingFilter{})
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestMapRepository_SlotKeysDoNotCollide(t *testing.T) {
+	repo := NewMapRepository(mapstore.NewShardedMapStore(4))
+	morning := time.Date(2030, 6, 10, 7, 0, 0, 0, time.UTC)
+	evening := time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC)
+
+	// Unescaped, both places would be "a|2030-06-10T07:00:00Z|b|2030-06-10T18:00:00Z|c"
+	first := dto.Booking{ID: "bkg_1", ClassName: "a", Occurrence: morning, MemberID: "b|2030-06-10T18:00:00Z|c", Status: dto.BookingConfirmed}
+	second := dto.Booking{ID: "bkg_2", ClassName: "a|2030-06-10T07:00:00Z|b", Occurrence: evening, MemberID: "c", Status: dto.BookingConfirmed}
+	require.NoError(t, repo.StoreBooking(first))
+	require.NoError(t, repo.StoreBooking(second))
+
+	for _, booking := range []dto.Booking{first, second} {
+		found, exist, err := repo.FindBooking(booking.ClassName, booking.Occurrence, booking.MemberID)
+		require.NoError(t, err)
+		require.True(t, exist, booking.ID)
+		assert.Equal(t, booking.ID, found.ID)
+	}
+
+	// Ordinary names keep their key
+	assert.Equal(t, "Yoga Class|2030-06-10T18:00:00Z|mem_1", slotKey("Yoga Class", evening, "mem_1"))
+}
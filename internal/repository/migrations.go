@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the versioned schema migrations. Every version N has a
+// "N_name.up.sql" file applying it and a "N_name.down.sql" file reverting it.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is a single versioned schema change.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations reads the embedded migration files ordered by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		base, direction, found := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		prefix, name, ok := strings.Cut(base, "_")
+		version, convErr := strconv.Atoi(prefix)
+		if !found || !ok || convErr != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		body, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if byVersion[version] == nil {
+			byVersion[version] = &migration{version: version, name: name}
+		}
+		switch direction {
+		case "up":
+			byVersion[version].up = string(body)
+		case "down":
+			byVersion[version].down = string(body)
+		default:
+			return nil, fmt.Errorf("invalid migration direction in %q", entry.Name())
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d is missing its up or down file", m.version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// schemaVersion returns the highest applied migration version, creating the
+// bookkeeping table on first use.
+func schemaVersion(db *sql.DB) (int, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err = db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	return int(version.Int64), err
+}
+
+// MigrateUp applies every pending migration in version order, each in its own transaction.
+func MigrateUp(db *sql.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		err = inTx(db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m.up); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", m.version, m.name, err)
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+				m.version, time.Now().UTC().Format(time.RFC3339))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts applied migrations, newest first, until the schema is at
+// the target version. A target of zero reverts every migration.
+func MigrateDown(db *sql.DB, target int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version > current || m.version <= target {
+			continue
+		}
+		err = inTx(db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m.down); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", m.version, m.name, err)
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.version)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inTx runs fn inside a transaction, committing on success and rolling back on error.
+func inTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,4 @@
+DROP TABLE bookings;
+DROP TABLE occurrences;
+DROP TABLE classes;
+DROP TABLE members;
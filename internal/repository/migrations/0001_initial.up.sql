@@ -0,0 +1,33 @@
+CREATE TABLE members (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_name TEXT    NOT NULL UNIQUE
+);
+
+CREATE TABLE classes (
+    name                      TEXT    PRIMARY KEY,
+    capacity                  INTEGER NOT NULL,
+    start_date                TEXT    NOT NULL,
+    end_date                  TEXT    NOT NULL,
+    schedule_weekdays         TEXT,
+    schedule_start_time       TEXT,
+    schedule_duration_minutes INTEGER,
+    schedule_rrule            TEXT
+);
+
+CREATE TABLE occurrences (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    class_name TEXT    NOT NULL REFERENCES classes (name) ON DELETE CASCADE,
+    starts_at  TEXT    NOT NULL,
+    UNIQUE (class_name, starts_at)
+);
+
+CREATE TABLE bookings (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurrence_id INTEGER NOT NULL REFERENCES occurrences (id) ON DELETE CASCADE,
+    member_id     INTEGER NOT NULL REFERENCES members (id),
+    status        TEXT    NOT NULL CHECK (status IN ('confirmed', 'waitlisted')),
+    position      INTEGER NOT NULL
+);
+
+CREATE INDEX bookings_occurrence_idx ON bookings (occurrence_id);
+CREATE INDEX bookings_member_idx ON bookings (member_id);
@@ -0,0 +1,5 @@
+ALTER TABLE members DROP COLUMN created_at;
+ALTER TABLE members DROP COLUMN status;
+ALTER TABLE members DROP COLUMN email;
+ALTER TABLE members DROP COLUMN name;
+ALTER TABLE members RENAME COLUMN member_id TO user_name;
@@ -0,0 +1,6 @@
+-- Members become a registry: the free-text user name used so far is kept as the stable member id
+ALTER TABLE members RENAME COLUMN user_name TO member_id;
+ALTER TABLE members ADD COLUMN name TEXT NOT NULL DEFAULT '';
+ALTER TABLE members ADD COLUMN email TEXT NOT NULL DEFAULT '';
+ALTER TABLE members ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended'));
+ALTER TABLE members ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
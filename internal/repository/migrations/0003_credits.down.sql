@@ -0,0 +1,3 @@
+DROP TABLE credit_ledger;
+DROP TABLE credit_grants;
+DROP TABLE plans;
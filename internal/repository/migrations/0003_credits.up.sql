@@ -0,0 +1,33 @@
+CREATE TABLE plans (
+    id         TEXT    PRIMARY KEY,
+    name       TEXT    NOT NULL,
+    type       TEXT    NOT NULL CHECK (type IN ('pack', 'unlimited')),
+    credits    INTEGER NOT NULL DEFAULT 0,
+    valid_days INTEGER NOT NULL
+);
+
+CREATE TABLE credit_grants (
+    id         TEXT    PRIMARY KEY,
+    member_id  TEXT    NOT NULL REFERENCES members (member_id),
+    plan_id    TEXT    NOT NULL,
+    plan_name  TEXT    NOT NULL,
+    unlimited  INTEGER NOT NULL,
+    remaining  INTEGER NOT NULL,
+    valid_from TEXT    NOT NULL,
+    expires_at TEXT    NOT NULL,
+    position   INTEGER NOT NULL
+);
+
+CREATE TABLE credit_ledger (
+    member_id  TEXT    NOT NULL REFERENCES members (member_id),
+    seq        INTEGER NOT NULL,
+    at         TEXT    NOT NULL,
+    type       TEXT    NOT NULL CHECK (type IN ('purchase', 'consume', 'refund')),
+    grant_id   TEXT    NOT NULL,
+    credits    INTEGER NOT NULL,
+    class_name TEXT,
+    occurrence TEXT,
+    PRIMARY KEY (member_id, seq)
+);
+
+CREATE INDEX credit_grants_member_idx ON credit_grants (member_id);
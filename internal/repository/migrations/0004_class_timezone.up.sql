@@ -0,0 +1,2 @@
+-- Classes run in an IANA time zone; an empty value is UTC, as before time zones existed
+ALTER TABLE classes ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE classes DROP COLUMN policy_cancel_hours_before;
+ALTER TABLE classes DROP COLUMN policy_cutoff_minutes;
+ALTER TABLE classes DROP COLUMN policy_opens_hours_before;
@@ -0,0 +1,4 @@
+-- Per-class overrides of the studio booking policy; NULL falls back to the studio default
+ALTER TABLE classes ADD COLUMN policy_opens_hours_before INTEGER;
+ALTER TABLE classes ADD COLUMN policy_cutoff_minutes INTEGER;
+ALTER TABLE classes ADD COLUMN policy_cancel_hours_before INTEGER;
@@ -0,0 +1,14 @@
+-- Booking records outlive the class roster: they keep the identity, channel and
+-- final status of a booking after it is cancelled or the class is deleted
+CREATE TABLE booking_records (
+    id         TEXT PRIMARY KEY,
+    member_id  TEXT NOT NULL,
+    class_name TEXT NOT NULL,
+    starts_at  TEXT NOT NULL,
+    occurrence TEXT NOT NULL,
+    status     TEXT NOT NULL CHECK (status IN ('confirmed', 'waitlisted', 'cancelled', 'attended', 'no-show')),
+    source     TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX booking_records_slot_idx ON booking_records (class_name, starts_at, member_id);
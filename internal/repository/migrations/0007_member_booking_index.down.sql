@@ -0,0 +1 @@
+DROP INDEX booking_records_member_idx;
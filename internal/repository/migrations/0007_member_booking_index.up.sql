@@ -0,0 +1,2 @@
+-- Lets the booking history of a member be read without scanning every booking
+CREATE INDEX booking_records_member_idx ON booking_records (member_id, starts_at);
@@ -0,0 +1,69 @@
+-- Only the default studio fits the schema without studios; the data of the
+-- other studios is dropped
+DELETE FROM credit_ledger WHERE member_id IN (SELECT member_id FROM members WHERE studio_id <> '');
+DELETE FROM credit_grants WHERE member_id IN (SELECT member_id FROM members WHERE studio_id <> '');
+DELETE FROM booking_records WHERE studio_id <> '';
+DELETE FROM classes WHERE studio_id <> '';
+DELETE FROM members WHERE studio_id <> '';
+DELETE FROM plans WHERE studio_id <> '';
+
+DROP INDEX booking_records_slot_idx;
+CREATE INDEX booking_records_slot_idx ON booking_records (class_name, starts_at, member_id);
+
+ALTER TABLE booking_records DROP COLUMN studio_id;
+ALTER TABLE plans DROP COLUMN studio_id;
+ALTER TABLE members DROP COLUMN studio_id;
+
+CREATE TABLE global_classes (
+    name                       TEXT    PRIMARY KEY,
+    capacity                   INTEGER NOT NULL,
+    start_date                 TEXT    NOT NULL,
+    end_date                   TEXT    NOT NULL,
+    schedule_weekdays          TEXT,
+    schedule_start_time        TEXT,
+    schedule_duration_minutes  INTEGER,
+    schedule_rrule             TEXT,
+    timezone                   TEXT    NOT NULL DEFAULT '',
+    policy_opens_hours_before  INTEGER,
+    policy_cutoff_minutes      INTEGER,
+    policy_cancel_hours_before INTEGER
+);
+
+INSERT INTO global_classes (name, capacity, start_date, end_date,
+        schedule_weekdays, schedule_start_time, schedule_duration_minutes, schedule_rrule, timezone,
+        policy_opens_hours_before, policy_cutoff_minutes, policy_cancel_hours_before)
+    SELECT name, capacity, start_date, end_date,
+        schedule_weekdays, schedule_start_time, schedule_duration_minutes, schedule_rrule, timezone,
+        policy_opens_hours_before, policy_cutoff_minutes, policy_cancel_hours_before
+    FROM classes;
+
+CREATE TABLE global_occurrences (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    class_name TEXT    NOT NULL REFERENCES global_classes (name) ON DELETE CASCADE,
+    starts_at  TEXT    NOT NULL,
+    UNIQUE (class_name, starts_at)
+);
+
+INSERT INTO global_occurrences (id, class_name, starts_at) SELECT id, class_name, starts_at FROM occurrences;
+
+CREATE TABLE global_bookings (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurrence_id INTEGER NOT NULL REFERENCES global_occurrences (id) ON DELETE CASCADE,
+    member_id     INTEGER NOT NULL REFERENCES members (id),
+    status        TEXT    NOT NULL CHECK (status IN ('confirmed', 'waitlisted')),
+    position      INTEGER NOT NULL
+);
+
+INSERT INTO global_bookings (id, occurrence_id, member_id, status, position)
+    SELECT id, occurrence_id, member_id, status, position FROM bookings;
+
+DROP TABLE bookings;
+DROP TABLE occurrences;
+DROP TABLE classes;
+
+ALTER TABLE global_classes RENAME TO classes;
+ALTER TABLE global_occurrences RENAME TO occurrences;
+ALTER TABLE global_bookings RENAME TO bookings;
+
+CREATE INDEX bookings_occurrence_idx ON bookings (occurrence_id);
+CREATE INDEX bookings_member_idx ON bookings (member_id);
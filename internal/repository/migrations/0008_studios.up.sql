@@ -0,0 +1,71 @@
+-- Every class, member, plan and booking record belongs to a studio; '' is the
+-- default studio, which holds everything stored before there were studios.
+-- Class names are only unique within a studio, so classes and the tables
+-- referencing them are rebuilt with the studio in their keys. Children are
+-- copied before their parents are dropped, so no cascade deletes them.
+CREATE TABLE studio_classes (
+    studio_id                  TEXT    NOT NULL DEFAULT '',
+    name                       TEXT    NOT NULL,
+    capacity                   INTEGER NOT NULL,
+    start_date                 TEXT    NOT NULL,
+    end_date                   TEXT    NOT NULL,
+    schedule_weekdays          TEXT,
+    schedule_start_time        TEXT,
+    schedule_duration_minutes  INTEGER,
+    schedule_rrule             TEXT,
+    timezone                   TEXT    NOT NULL DEFAULT '',
+    policy_opens_hours_before  INTEGER,
+    policy_cutoff_minutes      INTEGER,
+    policy_cancel_hours_before INTEGER,
+    PRIMARY KEY (studio_id, name)
+);
+
+INSERT INTO studio_classes (name, capacity, start_date, end_date,
+        schedule_weekdays, schedule_start_time, schedule_duration_minutes, schedule_rrule, timezone,
+        policy_opens_hours_before, policy_cutoff_minutes, policy_cancel_hours_before)
+    SELECT name, capacity, start_date, end_date,
+        schedule_weekdays, schedule_start_time, schedule_duration_minutes, schedule_rrule, timezone,
+        policy_opens_hours_before, policy_cutoff_minutes, policy_cancel_hours_before
+    FROM classes;
+
+CREATE TABLE studio_occurrences (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    studio_id  TEXT    NOT NULL DEFAULT '',
+    class_name TEXT    NOT NULL,
+    starts_at  TEXT    NOT NULL,
+    UNIQUE (studio_id, class_name, starts_at),
+    FOREIGN KEY (studio_id, class_name) REFERENCES studio_classes (studio_id, name) ON DELETE CASCADE
+);
+
+INSERT INTO studio_occurrences (id, class_name, starts_at) SELECT id, class_name, starts_at FROM occurrences;
+
+CREATE TABLE studio_bookings (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurrence_id INTEGER NOT NULL REFERENCES studio_occurrences (id) ON DELETE CASCADE,
+    member_id     INTEGER NOT NULL REFERENCES members (id),
+    status        TEXT    NOT NULL CHECK (status IN ('confirmed', 'waitlisted')),
+    position      INTEGER NOT NULL
+);
+
+INSERT INTO studio_bookings (id, occurrence_id, member_id, status, position)
+    SELECT id, occurrence_id, member_id, status, position FROM bookings;
+
+DROP TABLE bookings;
+DROP TABLE occurrences;
+DROP TABLE classes;
+
+ALTER TABLE studio_classes RENAME TO classes;
+ALTER TABLE studio_occurrences RENAME TO occurrences;
+ALTER TABLE studio_bookings RENAME TO bookings;
+
+CREATE INDEX bookings_occurrence_idx ON bookings (occurrence_id);
+CREATE INDEX bookings_member_idx ON bookings (member_id);
+
+-- Member, plan and booking ids are generated and unique across studios; the
+-- studio only scopes which of them a studio can see
+ALTER TABLE members ADD COLUMN studio_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE plans ADD COLUMN studio_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE booking_records ADD COLUMN studio_id TEXT NOT NULL DEFAULT '';
+
+DROP INDEX booking_records_slot_idx;
+CREATE INDEX booking_records_slot_idx ON booking_records (studio_id, class_name, starts_at, member_id);
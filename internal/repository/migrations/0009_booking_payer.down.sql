@@ -0,0 +1 @@
+ALTER TABLE booking_records DROP COLUMN paid_by;
@@ -0,0 +1,5 @@
+-- A booking handed to another member stays paid for by the member who bought
+-- it; bookings made before transfers were tracked were paid by their holder
+ALTER TABLE booking_records ADD COLUMN paid_by TEXT NOT NULL DEFAULT '';
+
+UPDATE booking_records SET paid_by = member_id;
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"glofox/config"
+	"glofox/constants"
+	mapstore "glofox/core"
+)
+
+// Open creates the repository backend selected in the storage configuration:
+// the in-memory map (the default), the durable file store or SQLite.
+func Open(storage config.StorageConfig) (Repository, error) {
+	switch storage.Type {
+	case constants.StorageFile:
+		reqMap, err := mapstore.NewFileMapStore(storage.Dir, storage.SnapshotEvery)
+		if err != nil {
+			return nil, err
+		}
+		return NewMapRepository(reqMap), nil
+	case constants.StorageSQLite:
+		return OpenSQLite(storage.DSN)
+	default:
+		return NewMapRepository(mapstore.NewMuMapStore(storage.Shards)), nil
+	}
+}
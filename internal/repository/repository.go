@@ -0,0 +1,137 @@
+package repository
+
+import (
+	mapstore "glofox/core"
+	"glofox/models/dto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Repository abstracts the persistence of classes together with their
+// bookings and waitlists, of the members who book them, of their plans
+// and credits and of the booking records, so that the
+// service layer works with typed values regardless of the storage backend behind it.
+// A Repository holds the data of a single studio; Studio switches to another one.
+type Repository interface {
+	// Studio returns the repository of the studio with the given id, on the same
+	// storage; "" is the default studio. Studios never see each other's data.
+	Studio(id string) Repository
+
+	LoadClass(name string) (dto.ClassInfo, bool, error) // Retrieves a class and its bookings, if present
+	StoreClass(name string, info dto.ClassInfo) error   // Creates or replaces a class and its bookings
+	DeleteClass(name string) error                      // Removes a class and its bookings
+	ClassNames() ([]string, error)                      // Returns the names of every class, sorted
+	UpdateClass(name string, fn ClassUpdateFunc) (dto.ClassInfo, error)
+
+	LoadMember(id string) (dto.Member, bool, error) // Retrieves a member by id, if present
+	StoreMember(member dto.Member) error            // Creates or replaces a member
+	UpdateMember(id string, fn MemberUpdateFunc) (dto.Member, error)
+
+	LoadPlan(id string) (dto.Plan, bool, error) // Retrieves a plan by id, if present
+	StorePlan(plan dto.Plan) error              // Creates or replaces a plan
+	Plans() ([]dto.Plan, error)                 // Returns every plan, sorted by id
+
+	LoadCredits(memberID string) (dto.CreditAccount, bool, error) // Retrieves the credit account of a member, if any
+	UpdateCredits(memberID string, fn CreditUpdateFunc) (dto.CreditAccount, error)
+
+	LoadBooking(id string) (dto.Booking, bool, error) // Retrieves a booking record by id, if present
+	StoreBooking(booking dto.Booking) error           // Creates or replaces a booking record
+	UpdateBooking(id string, fn BookingUpdateFunc) (dto.Booking, error)
+	// FindBooking retrieves the confirmed or waitlisted booking record of a member for a class occurrence, if any
+	FindBooking(className string, occurrence time.Time, memberID string) (dto.Booking, bool, error)
+	// MemberBookings returns the booking records of a member matching filter, ordered by occurrence start
+	MemberBookings(memberID string, filter BookingFilter) ([]dto.Booking, error)
+	// Bookings returns every booking record matching filter, ordered by occurrence start
+	Bookings(filter BookingFilter) ([]dto.Booking, error)
+}
+
+// BookingFilter narrows down the booking records of a member. Zero values match every booking.
+type BookingFilter struct {
+	From     time.Time // Earliest occurrence start, inclusive
+	To       time.Time // Latest occurrence start, exclusive
+	Statuses []string  // Booking statuses to keep
+}
+
+// matches reports whether booking passes the filter.
+func (filter BookingFilter) matches(booking dto.Booking) bool {
+	if !filter.From.IsZero() && booking.Occurrence.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !booking.Occurrence.Before(filter.To) {
+		return false
+	}
+	return len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, booking.Status)
+}
+
+// ClassUpdateFunc computes the new state of a class from its current state.
+// exists reports whether the class was found. The function receives its own
+// copy of the class, so it may mutate the bookings and waitlist maps freely;
+// returning an error aborts the update and leaves the stored class untouched,
+// except for ErrDeleteClass which deletes the class instead.
+type ClassUpdateFunc func(info dto.ClassInfo, exists bool) (dto.ClassInfo, error)
+
+// ErrDeleteClass is returned by a ClassUpdateFunc to delete the class in the
+// same atomic step that inspected it. UpdateClass then returns the zero class.
+var ErrDeleteClass = mapstore.ErrDeleteKey
+
+// MemberUpdateFunc computes the new state of a member from its current state.
+// exists reports whether the member was found; returning an error aborts the
+// update and leaves the stored member untouched.
+type MemberUpdateFunc func(member dto.Member, exists bool) (dto.Member, error)
+
+// CreditUpdateFunc computes the new state of a member's credit account from its
+// current state. The function receives its own copy of the account; returning
+// an error aborts the update and leaves the stored account untouched.
+type CreditUpdateFunc func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error)
+
+// BookingUpdateFunc computes the new state of a booking record from its current
+// state. exists reports whether the booking was found; returning an error
+// aborts the update and leaves the stored booking untouched.
+type BookingUpdateFunc func(booking dto.Booking, exists bool) (dto.Booking, error)
+
+// activeBooking reports whether a booking still holds a place or a waitlist place in its occurrence.
+func activeBooking(booking dto.Booking) bool {
+	return booking.Status == dto.BookingConfirmed || booking.Status == dto.BookingWaitlisted
+}
+
+// sortBookings orders booking records by occurrence start, then by id.
+func sortBookings(bookings []dto.Booking) {
+	slices.SortFunc(bookings, func(a, b dto.Booking) int {
+		if c := a.Occurrence.Compare(b.Occurrence); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// cloneCredits returns a deep copy of account, always with non-nil slices.
+func cloneCredits(account dto.CreditAccount) dto.CreditAccount {
+	return dto.CreditAccount{
+		Grants: append(make([]dto.CreditGrant, 0, len(account.Grants)), account.Grants...),
+		Ledger: append(make([]dto.CreditEntry, 0, len(account.Ledger)), account.Ledger...),
+	}
+}
+
+// cloneClass returns a deep copy of info so that updates never mutate a value
+// other goroutines may be reading.
+func cloneClass(info dto.ClassInfo) dto.ClassInfo {
+	clone := info
+	if info.Schedule != nil {
+		schedule := *info.Schedule
+		schedule.Weekdays = append([]string(nil), info.Schedule.Weekdays...)
+		clone.Schedule = &schedule
+	}
+	clone.Bookings = cloneEntries(info.Bookings)
+	clone.Waitlist = cloneEntries(info.Waitlist)
+	return clone
+}
+
+// cloneEntries deep-copies a map of occurrence to user names, always returning a non-nil map.
+func cloneEntries[K comparable](entries map[K][]string) map[K][]string {
+	clone := make(map[K][]string, len(entries))
+	for key, users := range entries {
+		clone[key] = append([]string(nil), users...)
+	}
+	return clone
+}
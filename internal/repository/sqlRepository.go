@@ -0,0 +1,797 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"glofox/internal/schedule"
+	"glofox/models/dto"
+
+	_ "modernc.org/sqlite" // Registers the pure Go "sqlite" database/sql driver
+)
+
+const (
+	sqlDateFormat = "2006-01-02"
+	sqlTimeFormat = time.RFC3339
+
+	statusConfirmed  = "confirmed"
+	statusWaitlisted = "waitlisted"
+)
+
+// querier is the subset of *sql.DB and *sql.Tx used to read classes,
+// so that reads can run inside or outside a transaction.
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// sqlRepository is a Repository backed by a relational database through database/sql.
+// Classes, their occurrences, bookings and members live in separate tables so
+// that they can be queried with plain SQL for reporting. Every row belongs to
+// a studio, and a repository only reads and writes the rows of its own.
+type sqlRepository struct {
+	db     *sql.DB
+	studio string
+}
+
+// errOtherStudio reports an upsert of a row whose id another studio already holds.
+var errOtherStudio = errors.New("id is taken by another studio")
+
+// OpenSQLite opens the SQLite database at dsn (a file path or ":memory:"),
+// applies pending migrations and returns a Repository on top of it.
+func OpenSQLite(dsn string) (Repository, error) {
+	// Immediate transactions take the write lock up front, so read-modify-write updates can not race
+	db, err := sql.Open("sqlite", dsn+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection also keeps ":memory:" databases shared
+	db.SetMaxOpenConns(1)
+
+	repo, err := NewSQLRepository(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewSQLRepository migrates the schema of db to the latest version and wraps it in a Repository.
+func NewSQLRepository(db *sql.DB) (Repository, error) {
+	if err := MigrateUp(db); err != nil {
+		return nil, err
+	}
+	return &sqlRepository{
+		db: db,
+	}, nil
+}
+
+// Studio returns the repository of the studio with the given id on the same database.
+func (repo *sqlRepository) Studio(id string) Repository {
+	return &sqlRepository{db: repo.db, studio: id}
+}
+
+// LoadClass reads a class row together with the bookings and waitlist of its occurrences.
+func (repo *sqlRepository) LoadClass(name string) (dto.ClassInfo, bool, error) {
+	return loadClass(repo.db, repo.studio, name)
+}
+
+// StoreClass upserts the class row, materialises its occurrences and rewrites
+// its bookings, all inside a single transaction.
+func (repo *sqlRepository) StoreClass(name string, info dto.ClassInfo) error {
+	return inTx(repo.db, func(tx *sql.Tx) error {
+		return storeClass(tx, repo.studio, name, info)
+	})
+}
+
+// UpdateClass reads the class, applies fn and writes the result back within one transaction.
+func (repo *sqlRepository) UpdateClass(name string, fn ClassUpdateFunc) (dto.ClassInfo, error) {
+	var updated dto.ClassInfo
+	err := inTx(repo.db, func(tx *sql.Tx) error {
+		info, exists, err := loadClass(tx, repo.studio, name)
+		if err != nil {
+			return err
+		}
+		updated, err = fn(info, exists)
+		if errors.Is(err, ErrDeleteClass) {
+			updated = dto.ClassInfo{}
+			_, err = tx.Exec(`DELETE FROM classes WHERE studio_id = ? AND name = ?`, repo.studio, name)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		return storeClass(tx, repo.studio, name, updated)
+	})
+	if err != nil {
+		return dto.ClassInfo{}, err
+	}
+	return updated, nil
+}
+
+// loadClass reads a class of studio and its bookings through q.
+func loadClass(q querier, studio, name string) (dto.ClassInfo, bool, error) {
+	var (
+		info                       dto.ClassInfo
+		startDate, endDate         string
+		weekdays, startTime, rrule sql.NullString
+		durationMinutes            sql.NullInt64
+		opens, cutoff, cancel      sql.NullInt64
+	)
+	err := q.QueryRow(`SELECT capacity, start_date, end_date, timezone,
+			schedule_weekdays, schedule_start_time, schedule_duration_minutes, schedule_rrule,
+			policy_opens_hours_before, policy_cutoff_minutes, policy_cancel_hours_before
+		FROM classes WHERE studio_id = ? AND name = ?`, studio, name).
+		Scan(&info.AllowedCapacity, &startDate, &endDate, &info.Timezone, &weekdays, &startTime, &durationMinutes, &rrule,
+			&opens, &cutoff, &cancel)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.ClassInfo{}, false, nil
+	}
+	if err != nil {
+		return dto.ClassInfo{}, false, err
+	}
+
+	if info.StartDate, err = time.Parse(sqlDateFormat, startDate); err != nil {
+		return dto.ClassInfo{}, false, err
+	}
+	if info.EndDate, err = time.Parse(sqlDateFormat, endDate); err != nil {
+		return dto.ClassInfo{}, false, err
+	}
+	if startTime.Valid {
+		info.Schedule = &dto.Schedule{
+			StartTime:       startTime.String,
+			DurationMinutes: int(durationMinutes.Int64),
+			RRule:           rrule.String,
+		}
+		if weekdays.String != "" {
+			info.Schedule.Weekdays = strings.Split(weekdays.String, ",")
+		}
+	}
+	if opens.Valid || cutoff.Valid || cancel.Valid {
+		info.Policy = &dto.BookingPolicy{
+			OpensHoursBefore:  nullableInt(opens),
+			CutoffMinutes:     nullableInt(cutoff),
+			CancelHoursBefore: nullableInt(cancel),
+		}
+	}
+
+	info.Bookings = make(map[time.Time][]string)
+	info.Waitlist = make(map[time.Time][]string)
+	rows, err := q.Query(`SELECT o.starts_at, m.member_id, b.status
+		FROM bookings b
+		JOIN occurrences o ON o.id = b.occurrence_id
+		JOIN members m ON m.id = b.member_id
+		WHERE o.studio_id = ? AND o.class_name = ?
+		ORDER BY o.starts_at, b.position`, studio, name)
+	if err != nil {
+		return dto.ClassInfo{}, false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var startsAt, memberID, status string
+		if err = rows.Scan(&startsAt, &memberID, &status); err != nil {
+			return dto.ClassInfo{}, false, err
+		}
+		occurrence, err := time.Parse(sqlTimeFormat, startsAt)
+		if err != nil {
+			return dto.ClassInfo{}, false, err
+		}
+		if status == statusWaitlisted {
+			info.Waitlist[occurrence] = append(info.Waitlist[occurrence], memberID)
+		} else {
+			info.Bookings[occurrence] = append(info.Bookings[occurrence], memberID)
+		}
+	}
+	return info, true, rows.Err()
+}
+
+// storeClass upserts the class row of studio, materialises its occurrences and rewrites its bookings within tx.
+func storeClass(tx *sql.Tx, studio, name string, info dto.ClassInfo) error {
+	var weekdays, startTime, rrule sql.NullString
+	var durationMinutes sql.NullInt64
+	if info.Schedule != nil {
+		weekdays = sql.NullString{String: strings.Join(info.Schedule.Weekdays, ","), Valid: true}
+		startTime = sql.NullString{String: info.Schedule.StartTime, Valid: true}
+		durationMinutes = sql.NullInt64{Int64: int64(info.Schedule.DurationMinutes), Valid: true}
+		rrule = sql.NullString{String: info.Schedule.RRule, Valid: true}
+	}
+	var opens, cutoff, cancel sql.NullInt64
+	if info.Policy != nil {
+		opens = nullInt(info.Policy.OpensHoursBefore)
+		cutoff = nullInt(info.Policy.CutoffMinutes)
+		cancel = nullInt(info.Policy.CancelHoursBefore)
+	}
+
+	_, err := tx.Exec(`INSERT INTO classes (studio_id, name, capacity, start_date, end_date, timezone,
+			schedule_weekdays, schedule_start_time, schedule_duration_minutes, schedule_rrule,
+			policy_opens_hours_before, policy_cutoff_minutes, policy_cancel_hours_before)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (studio_id, name) DO UPDATE SET
+			capacity = excluded.capacity,
+			start_date = excluded.start_date,
+			end_date = excluded.end_date,
+			timezone = excluded.timezone,
+			schedule_weekdays = excluded.schedule_weekdays,
+			schedule_start_time = excluded.schedule_start_time,
+			schedule_duration_minutes = excluded.schedule_duration_minutes,
+			schedule_rrule = excluded.schedule_rrule,
+			policy_opens_hours_before = excluded.policy_opens_hours_before,
+			policy_cutoff_minutes = excluded.policy_cutoff_minutes,
+			policy_cancel_hours_before = excluded.policy_cancel_hours_before`,
+		studio, name, info.AllowedCapacity, info.StartDate.Format(sqlDateFormat), info.EndDate.Format(sqlDateFormat), info.Timezone,
+		weekdays, startTime, durationMinutes, rrule, opens, cutoff, cancel)
+	if err != nil {
+		return err
+	}
+
+	occurrences, err := storeOccurrences(tx, studio, name, info)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM bookings WHERE occurrence_id IN
+		(SELECT id FROM occurrences WHERE studio_id = ? AND class_name = ?)`, studio, name)
+	if err != nil {
+		return err
+	}
+	if err = storeBookings(tx, studio, occurrences, info.Bookings, statusConfirmed); err != nil {
+		return err
+	}
+	return storeBookings(tx, studio, occurrences, info.Waitlist, statusWaitlisted)
+}
+
+// nullInt converts an optional integer into a nullable column value.
+func nullInt(value *int) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*value), Valid: true}
+}
+
+// nullableInt converts a nullable column value into an optional integer.
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	converted := int(value.Int64)
+	return &converted
+}
+
+// DeleteClass removes the class; occurrences and bookings follow through ON DELETE CASCADE.
+func (repo *sqlRepository) DeleteClass(name string) error {
+	_, err := repo.db.Exec(`DELETE FROM classes WHERE studio_id = ? AND name = ?`, repo.studio, name)
+	return err
+}
+
+// ClassNames returns the name of every class in ascending order.
+func (repo *sqlRepository) ClassNames() ([]string, error) {
+	rows, err := repo.db.Query(`SELECT name FROM classes WHERE studio_id = ? ORDER BY name`, repo.studio)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Close closes the underlying database, shared by the repositories of every studio.
+func (repo *sqlRepository) Close() error {
+	return repo.db.Close()
+}
+
+// storeOccurrences makes sure every scheduled occurrence of the class, and every
+// occurrence holding bookings, has a row; rows for occurrences that no longer
+// exist are removed. It returns the occurrence ids keyed by UTC start time.
+func storeOccurrences(tx *sql.Tx, studio, name string, info dto.ClassInfo) (map[time.Time]int64, error) {
+	starts := make(map[time.Time]bool)
+	if recurrence, err := schedule.ForClass(info); err == nil {
+		for _, occurrence := range recurrence.Occurrences() {
+			starts[occurrence.Start.UTC()] = true
+		}
+	}
+	for start := range info.Bookings {
+		starts[start.UTC()] = true
+	}
+	for start := range info.Waitlist {
+		starts[start.UTC()] = true
+	}
+
+	ids := make(map[time.Time]int64, len(starts))
+	for start := range starts {
+		formatted := start.Format(sqlTimeFormat)
+		_, err := tx.Exec(`INSERT INTO occurrences (studio_id, class_name, starts_at) VALUES (?, ?, ?)
+			ON CONFLICT (studio_id, class_name, starts_at) DO NOTHING`, studio, name, formatted)
+		if err != nil {
+			return nil, err
+		}
+		var id int64
+		err = tx.QueryRow(`SELECT id FROM occurrences WHERE studio_id = ? AND class_name = ? AND starts_at = ?`,
+			studio, name, formatted).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids[start] = id
+	}
+
+	rows, err := tx.Query(`SELECT id, starts_at FROM occurrences WHERE studio_id = ? AND class_name = ?`, studio, name)
+	if err != nil {
+		return nil, err
+	}
+	stale := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		var startsAt string
+		if err = rows.Scan(&id, &startsAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		start, err := time.Parse(sqlTimeFormat, startsAt)
+		if err != nil || !starts[start] {
+			stale = append(stale, id)
+		}
+	}
+	rows.Close()
+	for _, id := range stale {
+		if _, err = tx.Exec(`DELETE FROM occurrences WHERE id = ?`, id); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// storeBookings inserts the members of every occurrence with the given status,
+// keeping their order in the position column and creating unknown members in studio.
+func storeBookings(tx *sql.Tx, studio string, occurrences map[time.Time]int64, entries map[time.Time][]string, status string) error {
+	for start, users := range entries {
+		for position, member := range users {
+			rowID, err := memberRowID(tx, studio, member)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(`INSERT INTO bookings (occurrence_id, member_id, status, position) VALUES (?, ?, ?, ?)`,
+				occurrences[start.UTC()], rowID, status, position)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// memberRowID returns the row id of the member of studio with the given member id.
+// Members referenced by bookings but never registered, such as bookings made
+// before the registry existed, get a placeholder row. Members of other
+// studios can not be referenced.
+func memberRowID(tx *sql.Tx, studio, memberID string) (int64, error) {
+	_, err := tx.Exec(`INSERT INTO members (studio_id, member_id) VALUES (?, ?) ON CONFLICT (member_id) DO NOTHING`,
+		studio, memberID)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM members WHERE studio_id = ? AND member_id = ?`, studio, memberID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errOtherStudio
+	}
+	return id, err
+}
+
+// LoadMember reads the member registered under id.
+func (repo *sqlRepository) LoadMember(id string) (dto.Member, bool, error) {
+	return loadMember(repo.db, repo.studio, id)
+}
+
+// StoreMember inserts the member or replaces the details of an existing one.
+func (repo *sqlRepository) StoreMember(member dto.Member) error {
+	return inTx(repo.db, func(tx *sql.Tx) error {
+		return storeMember(tx, repo.studio, member)
+	})
+}
+
+// UpdateMember reads the member, applies fn and writes the result back within one transaction.
+func (repo *sqlRepository) UpdateMember(id string, fn MemberUpdateFunc) (dto.Member, error) {
+	var updated dto.Member
+	err := inTx(repo.db, func(tx *sql.Tx) error {
+		member, exists, err := loadMember(tx, repo.studio, id)
+		if err != nil {
+			return err
+		}
+		updated, err = fn(member, exists)
+		if err != nil {
+			return err
+		}
+		return storeMember(tx, repo.studio, updated)
+	})
+	if err != nil {
+		return dto.Member{}, err
+	}
+	return updated, nil
+}
+
+// loadMember reads a member of studio through q.
+func loadMember(q querier, studio, id string) (dto.Member, bool, error) {
+	var member dto.Member
+	var createdAt string
+	err := q.QueryRow(`SELECT member_id, name, email, status, created_at FROM members WHERE studio_id = ? AND member_id = ?`, studio, id).
+		Scan(&member.ID, &member.Name, &member.Email, &member.Status, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.Member{}, false, nil
+	}
+	if err != nil {
+		return dto.Member{}, false, err
+	}
+	if createdAt != "" {
+		if member.CreatedAt, err = time.Parse(sqlTimeFormat, createdAt); err != nil {
+			return dto.Member{}, false, err
+		}
+	}
+	return member, true, nil
+}
+
+// storeMember upserts a member row of studio within tx.
+func storeMember(tx *sql.Tx, studio string, member dto.Member) error {
+	var createdAt string
+	if !member.CreatedAt.IsZero() {
+		createdAt = member.CreatedAt.UTC().Format(sqlTimeFormat)
+	}
+	result, err := tx.Exec(`INSERT INTO members (studio_id, member_id, name, email, status, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (member_id) DO UPDATE SET
+			name = excluded.name,
+			email = excluded.email,
+			status = excluded.status,
+			created_at = excluded.created_at
+		WHERE members.studio_id = excluded.studio_id`,
+		studio, member.ID, member.Name, member.Email, member.Status, createdAt)
+	return upserted(result, err)
+}
+
+// upserted turns an upsert that changed no row, because the id is held by
+// another studio, into errOtherStudio.
+func upserted(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errOtherStudio
+	}
+	return nil
+}
+
+// LoadPlan reads the plan with the given id.
+func (repo *sqlRepository) LoadPlan(id string) (dto.Plan, bool, error) {
+	var plan dto.Plan
+	err := repo.db.QueryRow(`SELECT id, name, type, credits, valid_days FROM plans WHERE studio_id = ? AND id = ?`, repo.studio, id).
+		Scan(&plan.ID, &plan.Name, &plan.Type, &plan.Credits, &plan.ValidDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.Plan{}, false, nil
+	}
+	if err != nil {
+		return dto.Plan{}, false, err
+	}
+	return plan, true, nil
+}
+
+// StorePlan inserts the plan or replaces an existing one with the same id.
+func (repo *sqlRepository) StorePlan(plan dto.Plan) error {
+	result, err := repo.db.Exec(`INSERT INTO plans (studio_id, id, name, type, credits, valid_days) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			type = excluded.type,
+			credits = excluded.credits,
+			valid_days = excluded.valid_days
+		WHERE plans.studio_id = excluded.studio_id`,
+		repo.studio, plan.ID, plan.Name, plan.Type, plan.Credits, plan.ValidDays)
+	return upserted(result, err)
+}
+
+// Plans returns every plan ordered by id.
+func (repo *sqlRepository) Plans() ([]dto.Plan, error) {
+	rows, err := repo.db.Query(`SELECT id, name, type, credits, valid_days FROM plans WHERE studio_id = ? ORDER BY id`, repo.studio)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := make([]dto.Plan, 0)
+	for rows.Next() {
+		var plan dto.Plan
+		if err = rows.Scan(&plan.ID, &plan.Name, &plan.Type, &plan.Credits, &plan.ValidDays); err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, rows.Err()
+}
+
+// LoadCredits reads the grants and ledger of a member.
+func (repo *sqlRepository) LoadCredits(memberID string) (dto.CreditAccount, bool, error) {
+	return loadCredits(repo.db, repo.studio, memberID)
+}
+
+// UpdateCredits reads the credit account of a member, applies fn and writes the
+// result back within one transaction.
+func (repo *sqlRepository) UpdateCredits(memberID string, fn CreditUpdateFunc) (dto.CreditAccount, error) {
+	var updated dto.CreditAccount
+	err := inTx(repo.db, func(tx *sql.Tx) error {
+		account, exists, err := loadCredits(tx, repo.studio, memberID)
+		if err != nil {
+			return err
+		}
+		updated, err = fn(account, exists)
+		if err != nil {
+			return err
+		}
+		return storeCredits(tx, repo.studio, memberID, updated)
+	})
+	if err != nil {
+		return dto.CreditAccount{}, err
+	}
+	return updated, nil
+}
+
+// loadCredits reads the grants and ledger of a member of studio through q. The
+// account exists once the member has been granted at least one plan.
+func loadCredits(q querier, studio, memberID string) (dto.CreditAccount, bool, error) {
+	account := dto.CreditAccount{
+		Grants: make([]dto.CreditGrant, 0),
+		Ledger: make([]dto.CreditEntry, 0),
+	}
+
+	rows, err := q.Query(`SELECT g.id, g.plan_id, g.plan_name, g.unlimited, g.remaining, g.valid_from, g.expires_at
+		FROM credit_grants g JOIN members m ON m.member_id = g.member_id
+		WHERE m.studio_id = ? AND g.member_id = ? ORDER BY g.position`, studio, memberID)
+	if err != nil {
+		return dto.CreditAccount{}, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var grant dto.CreditGrant
+		var validFrom, expiresAt string
+		err = rows.Scan(&grant.ID, &grant.PlanID, &grant.PlanName, &grant.Unlimited, &grant.Remaining, &validFrom, &expiresAt)
+		if err != nil {
+			return dto.CreditAccount{}, false, err
+		}
+		if grant.ValidFrom, err = time.Parse(sqlTimeFormat, validFrom); err != nil {
+			return dto.CreditAccount{}, false, err
+		}
+		if grant.ExpiresAt, err = time.Parse(sqlTimeFormat, expiresAt); err != nil {
+			return dto.CreditAccount{}, false, err
+		}
+		account.Grants = append(account.Grants, grant)
+	}
+	if err = rows.Err(); err != nil {
+		return dto.CreditAccount{}, false, err
+	}
+
+	entries, err := q.Query(`SELECT l.seq, l.at, l.type, l.grant_id, l.credits, l.class_name, l.occurrence
+		FROM credit_ledger l JOIN members m ON m.member_id = l.member_id
+		WHERE m.studio_id = ? AND l.member_id = ? ORDER BY l.seq`, studio, memberID)
+	if err != nil {
+		return dto.CreditAccount{}, false, err
+	}
+	defer entries.Close()
+	for entries.Next() {
+		var entry dto.CreditEntry
+		var at string
+		var className, occurrence sql.NullString
+		err = entries.Scan(&entry.Seq, &at, &entry.Type, &entry.GrantID, &entry.Credits, &className, &occurrence)
+		if err != nil {
+			return dto.CreditAccount{}, false, err
+		}
+		if entry.At, err = time.Parse(sqlTimeFormat, at); err != nil {
+			return dto.CreditAccount{}, false, err
+		}
+		entry.ClassName = className.String
+		if occurrence.Valid {
+			start, err := time.Parse(sqlTimeFormat, occurrence.String)
+			if err != nil {
+				return dto.CreditAccount{}, false, err
+			}
+			entry.Occurrence = &start
+		}
+		account.Ledger = append(account.Ledger, entry)
+	}
+	if err = entries.Err(); err != nil {
+		return dto.CreditAccount{}, false, err
+	}
+
+	return account, len(account.Grants) > 0 || len(account.Ledger) > 0, nil
+}
+
+// storeCredits rewrites the grants and the ledger of a member of studio within tx.
+func storeCredits(tx *sql.Tx, studio, memberID string, account dto.CreditAccount) error {
+	// Accounts may only reference known members of the studio; legacy ids get a placeholder row
+	if _, err := memberRowID(tx, studio, memberID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM credit_grants WHERE member_id = ?`, memberID); err != nil {
+		return err
+	}
+	for position, grant := range account.Grants {
+		_, err := tx.Exec(`INSERT INTO credit_grants
+				(id, member_id, plan_id, plan_name, unlimited, remaining, valid_from, expires_at, position)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			grant.ID, memberID, grant.PlanID, grant.PlanName, grant.Unlimited, grant.Remaining,
+			grant.ValidFrom.UTC().Format(sqlTimeFormat), grant.ExpiresAt.UTC().Format(sqlTimeFormat), position)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM credit_ledger WHERE member_id = ?`, memberID); err != nil {
+		return err
+	}
+	for _, entry := range account.Ledger {
+		var className, occurrence sql.NullString
+		if entry.ClassName != "" {
+			className = sql.NullString{String: entry.ClassName, Valid: true}
+		}
+		if entry.Occurrence != nil {
+			occurrence = sql.NullString{String: entry.Occurrence.UTC().Format(sqlTimeFormat), Valid: true}
+		}
+		_, err := tx.Exec(`INSERT INTO credit_ledger (member_id, seq, at, type, grant_id, credits, class_name, occurrence)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			memberID, entry.Seq, entry.At.UTC().Format(sqlTimeFormat), entry.Type, entry.GrantID, entry.Credits,
+			className, occurrence)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadBooking reads a booking record by id.
+func (repo *sqlRepository) LoadBooking(id string) (dto.Booking, bool, error) {
+	return loadBooking(repo.db, `WHERE studio_id = ? AND id = ?`, repo.studio, id)
+}
+
+// StoreBooking upserts a booking record.
+func (repo *sqlRepository) StoreBooking(booking dto.Booking) error {
+	return inTx(repo.db, func(tx *sql.Tx) error {
+		return storeBooking(tx, repo.studio, booking)
+	})
+}
+
+// UpdateBooking reads the booking record, applies fn and writes the result back within one transaction.
+func (repo *sqlRepository) UpdateBooking(id string, fn BookingUpdateFunc) (dto.Booking, error) {
+	var updated dto.Booking
+	err := inTx(repo.db, func(tx *sql.Tx) error {
+		booking, exists, err := loadBooking(tx, `WHERE studio_id = ? AND id = ?`, repo.studio, id)
+		if err != nil {
+			return err
+		}
+		updated, err = fn(booking, exists)
+		if err != nil {
+			return err
+		}
+		return storeBooking(tx, repo.studio, updated)
+	})
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	return updated, nil
+}
+
+// FindBooking reads the confirmed or waitlisted booking record of a member for a class occurrence.
+func (repo *sqlRepository) FindBooking(className string, occurrence time.Time, memberID string) (dto.Booking, bool, error) {
+	return loadBooking(repo.db, `WHERE studio_id = ? AND class_name = ? AND starts_at = ? AND member_id = ?
+		AND status IN ('confirmed', 'waitlisted')`,
+		repo.studio, className, occurrence.UTC().Format(sqlTimeFormat), memberID)
+}
+
+// MemberBookings reads the bookings of a member matching filter, using the member index of the booking records.
+func (repo *sqlRepository) MemberBookings(memberID string, filter BookingFilter) ([]dto.Booking, error) {
+	return queryBookings(repo.db, filter, []string{`studio_id = ?`, `member_id = ?`}, repo.studio, memberID)
+}
+
+// Bookings reads every booking record matching filter.
+func (repo *sqlRepository) Bookings(filter BookingFilter) ([]dto.Booking, error) {
+	return queryBookings(repo.db, filter, []string{`studio_id = ?`}, repo.studio)
+}
+
+// queryBookings reads the booking records matching filter and the where
+// conditions with their args through q, ordered by occurrence start, then id.
+func queryBookings(q querier, filter BookingFilter, where []string, args ...any) ([]dto.Booking, error) {
+	if !filter.From.IsZero() {
+		where = append(where, `starts_at >= ?`)
+		args = append(args, filter.From.UTC().Format(sqlTimeFormat))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, `starts_at < ?`)
+		args = append(args, filter.To.UTC().Format(sqlTimeFormat))
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, `status IN (?`+strings.Repeat(`, ?`, len(filter.Statuses)-1)+`)`)
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	query := bookingColumns
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	rows, err := q.Query(query+` ORDER BY starts_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookings := make([]dto.Booking, 0)
+	for rows.Next() {
+		booking, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+	return bookings, rows.Err()
+}
+
+// bookingColumns selects the columns of a booking record in the order scanBooking reads them.
+const bookingColumns = `SELECT id, member_id, paid_by, class_name, occurrence, status, source, created_at FROM booking_records`
+
+// loadBooking reads the single booking record matched by the where clause through q.
+func loadBooking(q querier, where string, args ...any) (dto.Booking, bool, error) {
+	booking, err := scanBooking(q.QueryRow(bookingColumns+` `+where, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.Booking{}, false, nil
+	}
+	if err != nil {
+		return dto.Booking{}, false, err
+	}
+	return booking, true, nil
+}
+
+// scanBooking reads a booking record selected with bookingColumns.
+func scanBooking(row interface{ Scan(dest ...any) error }) (dto.Booking, error) {
+	var booking dto.Booking
+	var occurrence, createdAt string
+	err := row.Scan(&booking.ID, &booking.MemberID, &booking.PaidBy, &booking.ClassName, &occurrence, &booking.Status, &booking.Source, &createdAt)
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	if booking.Occurrence, err = time.Parse(sqlTimeFormat, occurrence); err != nil {
+		return dto.Booking{}, err
+	}
+	if booking.CreatedAt, err = time.Parse(sqlTimeFormat, createdAt); err != nil {
+		return dto.Booking{}, err
+	}
+	return booking, nil
+}
+
+// storeBooking upserts a booking record of studio within tx. The occurrence is
+// kept both in UTC, for lookups, and with its local offset, for display.
+func storeBooking(tx *sql.Tx, studio string, booking dto.Booking) error {
+	result, err := tx.Exec(`INSERT INTO booking_records (studio_id, id, member_id, paid_by, class_name, starts_at, occurrence, status, source, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			member_id = excluded.member_id,
+			paid_by = excluded.paid_by,
+			class_name = excluded.class_name,
+			starts_at = excluded.starts_at,
+			occurrence = excluded.occurrence,
+			status = excluded.status,
+			source = excluded.source,
+			created_at = excluded.created_at
+		WHERE booking_records.studio_id = excluded.studio_id`,
+		studio, booking.ID, booking.MemberID, booking.PaidBy, booking.ClassName, booking.Occurrence.UTC().Format(sqlTimeFormat),
+		booking.Occurrence.Format(sqlTimeFormat), booking.Status, booking.Source, booking.CreatedAt.Format(sqlTimeFormat))
+	return upserted(result, err)
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"glofox/models/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrations_UpAndDown(t *testing.T) {
+	db := openTestDB(t)
+
+	// Applying migrations twice is a no-op the second time
+	require.NoError(t, MigrateUp(db))
+	require.NoError(t, MigrateUp(db))
+	version, err := schemaVersion(db)
+	require.NoError(t, err)
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].version, version)
+
+	// Reverting every migration drops the tables again
+	require.NoError(t, MigrateDown(db, 0))
+	version, err = schemaVersion(db)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	_, err = db.Exec(`SELECT 1 FROM classes`)
+	assert.Error(t, err)
+}
+
+func TestSQLRepository_RoundTripAndReporting(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewSQLRepository(db)
+	require.NoError(t, err)
+
+	monday := time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC)
+	wednesday := time.Date(2025, 6, 4, 7, 0, 0, 0, time.UTC)
+	info := dto.ClassInfo{
+		AllowedCapacity: 1,
+		StartDate:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC),
+		Schedule:        &dto.Schedule{Weekdays: []string{"MO", "WE"}, StartTime: "07:00", DurationMinutes: 60},
+		Bookings:        map[time.Time][]string{monday: {"john_doe"}},
+		Waitlist:        map[time.Time][]string{monday: {"jane_doe", "max_doe"}},
+	}
+	require.NoError(t, repo.StoreClass("Pilates", info))
+
+	// The aggregate loads back exactly as it was stored
+	loaded, exist, err := repo.LoadClass("Pilates")
+	require.NoError(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, info, loaded)
+
+	// Every scheduled occurrence is materialised for reporting
+	var occurrences int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM occurrences WHERE class_name = 'Pilates'`).Scan(&occurrences))
+	assert.Equal(t, 2, occurrences)
+
+	var bookedOnWednesday int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM bookings b JOIN occurrences o ON o.id = b.occurrence_id
+		WHERE o.starts_at = ?`, wednesday.Format(sqlTimeFormat)).Scan(&bookedOnWednesday))
+	assert.Zero(t, bookedOnWednesday)
+
+	// Deleting the class cascades to its occurrences and bookings
+	require.NoError(t, repo.DeleteClass("Pilates"))
+	var bookings int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM bookings`).Scan(&bookings))
+	assert.Zero(t, bookings)
+
+	names, err := repo.ClassNames()
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestSQLRepository_MemberRegistryMigration(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, MigrateUp(db))
+	require.NoError(t, MigrateDown(db, 1))
+
+	// A member created on demand before the registry existed keeps its user name as id
+	_, err := db.Exec(`INSERT INTO members (user_name) VALUES ('john_doe')`)
+	require.NoError(t, err)
+
+	repo, err := NewSQLRepository(db)
+	require.NoError(t, err)
+	member, exist, err := repo.LoadMember("john_doe")
+	require.NoError(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, dto.MemberActive, member.Status)
+
+	// Registered members round-trip with every field
+	registered := dto.Member{
+		ID:        "mem_1",
+		Name:      "Jane Doe",
+		Email:     "jane@example.com",
+		Status:    dto.MemberSuspended,
+		CreatedAt: time.Date(2025, 6, 1, 9, 30, 0, 0, time.UTC),
+	}
+	require.NoError(t, repo.StoreMember(registered))
+	loaded, exist, err := repo.LoadMember("mem_1")
+	require.NoError(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, registered, loaded)
+}
+
+func TestSQLRepository_BookingRecords(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewSQLRepository(db)
+	require.NoError(t, err)
+
+	brisbane, err := time.LoadLocation("Australia/Brisbane")
+	require.NoError(t, err)
+	booking := dto.Booking{
+		ID:         "bkg_1",
+		MemberID:   "john_doe",
+		ClassName:  "Pilates",
+		Occurrence: time.Date(2025, 6, 2, 7, 0, 0, 0, brisbane),
+		Status:     dto.BookingConfirmed,
+		Source:     dto.SourceWeb,
+		CreatedAt:  time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, repo.StoreBooking(booking))
+
+	// The record keeps the local offset of its occurrence and is found by its UTC start
+	loaded, exist, err := repo.LoadBooking("bkg_1")
+	require.NoError(t, err)
+	require.True(t, exist)
+	assert.True(t, booking.Occurrence.Equal(loaded.Occurrence))
+	_, offset := loaded.Occurrence.Zone()
+	assert.Equal(t, 10*60*60, offset)
+
+	found, exist, err := repo.FindBooking("Pilates", booking.Occurrence.UTC(), "john_doe")
+	require.NoError(t, err)
+	require.True(t, exist)
+	assert.Equal(t, "bkg_1", found.ID)
+
+	// A cancelled booking is kept but no longer holds the place
+	_, err = repo.UpdateBooking("bkg_1", func(current dto.Booking, exists bool) (dto.Booking, error) {
+		current.Status = dto.BookingCancelled
+		return current, nil
+	})
+	require.NoError(t, err)
+	_, exist, err = repo.FindBooking("Pilates", booking.Occurrence, "john_doe")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	loaded, _, err = repo.LoadBooking("bkg_1")
+	require.NoError(t, err)
+	assert.Equal(t, dto.BookingCancelled, loaded.Status)
+}
+
+func TestSQLRepository_Studios(t *testing.T) {
+	db := openTestDB(t)
+	downtown, err := NewSQLRepository(db)
+	require.NoError(t, err)
+	downtown = downtown.Studio("downtown")
+	harbour := downtown.Studio("harbour")
+
+	start := time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC)
+	class := func(capacity int, member string) dto.ClassInfo {
+		return dto.ClassInfo{
+			AllowedCapacity: capacity,
+			StartDate:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:         time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC),
+			Schedule:        &dto.Schedule{Weekdays: []string{"MO"}, StartTime: "07:00", DurationMinutes: 60},
+			Bookings:        map[time.Time][]string{start: {member}},
+			Waitlist:        map[time.Time][]string{},
+		}
+	}
+
+	// Both studios can run a class of the same name
+	require.NoError(t, downtown.StoreMember(dto.Member{ID: "mem_1", Name: "Jane", Status: dto.MemberActive}))
+	require.NoError(t, harbour.StoreMember(dto.Member{ID: "mem_2", Name: "John", Status: dto.MemberActive}))
+	require.NoError(t, downtown.StoreClass("Yoga", class(10, "mem_1")))
+	require.NoError(t, harbour.StoreClass("Yoga", class(5, "mem_2")))
+	require.NoError(t, downtown.StoreBooking(dto.Booking{ID: "bkg_1", MemberID: "mem_1", ClassName: "Yoga", Occurrence: start,
+		Status: dto.BookingConfirmed, Source: dto.SourceAPI, CreatedAt: start}))
+
+	yoga, exist, err := harbour.LoadClass("Yoga")
+	require.NoError(t, err)
+	require.True(t, exist)
+	assert.Equal(t, 5, yoga.AllowedCapacity)
+	assert.Equal(t, []string{"mem_2"}, yoga.Bookings[start])
+
+	// Neither sees the members, bookings or plans of the other
+	_, exist, err = harbour.LoadMember("mem_1")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	_, exist, err = harbour.LoadBooking("bkg_1")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	_, exist, err = harbour.FindBooking("Yoga", start, "mem_1")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	bookings, err := harbour.Bookings(BookingFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, bookings)
+	require.NoError(t, downtown.StorePlan(dto.Plan{ID: "plan_1", Name: "Ten", Type: dto.PlanPack, Credits: 10, ValidDays: 30}))
+	plans, err := harbour.Plans()
+	require.NoError(t, err)
+	assert.Empty(t, plans)
+
+	// Nor can it take over their ids
+	assert.Error(t, harbour.StoreMember(dto.Member{ID: "mem_1", Name: "Mallory", Status: dto.MemberActive}))
+	assert.Error(t, harbour.StorePlan(dto.Plan{ID: "plan_1", Name: "Free", Type: dto.PlanUnlimited, ValidDays: 365}))
+	_, err = harbour.UpdateCredits("mem_1", func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+		return account, nil
+	})
+	assert.Error(t, err)
+	member, _, err := downtown.LoadMember("mem_1")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", member.Name)
+
+	// Deleting a class leaves the class of the same name elsewhere
+	require.NoError(t, harbour.DeleteClass("Yoga"))
+	names, err := downtown.ClassNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Yoga"}, names)
+}
+
+func TestSQLRepository_StudiosMigration(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, MigrateUp(db))
+	require.NoError(t, MigrateDown(db, 7))
+
+	start := time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC)
+	_, err := db.Exec(`INSERT INTO classes (name, capacity, start_date, end_date) VALUES ('Yoga', 10, '2025-06-01', '2025-06-07')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO members (member_id, name) VALUES ('mem_1', 'Jane')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO occurrences (class_name, starts_at) VALUES ('Yoga', ?)`, start.Format(sqlTimeFormat))
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO bookings (occurrence_id, member_id, status, position) VALUES (1, 1, 'confirmed', 0)`)
+	require.NoError(t, err)
+
+	// Classes and bookings stored before there were studios belong to the default studio
+	repo, err := NewSQLRepository(db)
+	require.NoError(t, err)
+	yoga, exist, err := repo.LoadClass("Yoga")
+	require.NoError(t, err)
+	require.True(t, exist)
+	assert.Equal(t, []string{"mem_1"}, yoga.Bookings[start])
+	_, exist, err = repo.Studio("downtown").LoadClass("Yoga")
+	require.NoError(t, err)
+	assert.False(t, exist)
+
+	// Reverting keeps the default studio and drops the others
+	require.NoError(t, repo.Studio("downtown").StoreClass("Spin", dto.ClassInfo{AllowedCapacity: 5,
+		StartDate: start, EndDate: start}))
+	require.NoError(t, MigrateDown(db, 7))
+	var names []string
+	rows, err := db.Query(`SELECT name FROM classes ORDER BY name`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"Yoga"}, names)
+	var bookings int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM bookings`).Scan(&bookings))
+	assert.Equal(t, 1, bookings)
+}
+
+func TestOpenSQLite_ConnectionSettings(t *testing.T) {
+	repo, err := OpenSQLite(filepath.Join(t.TempDir(), "glofox.db"))
+	require.NoError(t, err)
+	db := repo.(*sqlRepository).db
+	t.Cleanup(func() { db.Close() })
+
+	var foreignKeys, busyTimeout int
+	require.NoError(t, db.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys))
+	require.NoError(t, db.QueryRow(`PRAGMA busy_timeout`).Scan(&busyTimeout))
+	assert.Equal(t, 1, foreignKeys)
+	assert.Equal(t, 5000, busyTimeout)
+	assert.Equal(t, 1, db.Stats().MaxOpenConnections)
+}
@@ -0,0 +1,306 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embeds the IANA zone database so class time zones resolve on any host
+
+	newError "glofox/errors"
+	"glofox/models/dto"
+)
+
+// TimeFormat is the layout used for class start times and booking times.
+const TimeFormat = "15:04"
+
+const (
+	freqDaily  = "DAILY"
+	freqWeekly = "WEEKLY"
+)
+
+// weekdayCodes maps the accepted weekday spellings to time.Weekday.
+// Both RFC 5545 two-letter codes and English names are accepted.
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "SUN": time.Sunday, "SUNDAY": time.Sunday,
+	"MO": time.Monday, "MON": time.Monday, "MONDAY": time.Monday,
+	"TU": time.Tuesday, "TUE": time.Tuesday, "TUESDAY": time.Tuesday,
+	"WE": time.Wednesday, "WED": time.Wednesday, "WEDNESDAY": time.Wednesday,
+	"TH": time.Thursday, "THU": time.Thursday, "THURSDAY": time.Thursday,
+	"FR": time.Friday, "FRI": time.Friday, "FRIDAY": time.Friday,
+	"SA": time.Saturday, "SAT": time.Saturday, "SATURDAY": time.Saturday,
+}
+
+// Occurrence is a single run of a class. Start is always in UTC.
+type Occurrence struct {
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Recurrence expands a class date range and its optional schedule into the
+// concrete occurrences of the class.
+type Recurrence struct {
+	start     time.Time
+	end       time.Time
+	freq      string
+	interval  int
+	weekdays  map[time.Weekday]bool
+	count     int
+	until     time.Time
+	startTime time.Duration
+	duration  time.Duration
+	timed     bool
+	loc       *time.Location
+}
+
+// LoadLocation returns the IANA time zone with the given name. An empty name is UTC,
+// which is how classes created before time zones existed are interpreted.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", newError.ErrInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
+// ForClass builds the recurrence of a stored class in the class time zone.
+func ForClass(info dto.ClassInfo) (*Recurrence, error) {
+	loc, err := LoadLocation(info.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return New(info.Schedule, info.StartDate, info.EndDate, loc)
+}
+
+// New builds the recurrence of a class running from start to end (inclusive dates).
+// Only the calendar dates of start and end are used; start times are wall clock
+// times in loc, so a class keeps its local start time across DST changes.
+// A nil schedule yields one untimed occurrence per day starting at local
+// midnight, which is how classes without a schedule have always behaved.
+func New(def *dto.Schedule, start, end time.Time, loc *time.Location) (*Recurrence, error) {
+	recurrence := &Recurrence{
+		start:    civilDate(start),
+		end:      civilDate(end),
+		freq:     freqDaily,
+		interval: 1,
+		loc:      loc,
+	}
+	if def == nil {
+		return recurrence, nil
+	}
+
+	if def.RRule != "" {
+		if err := recurrence.parseRRule(def.RRule); err != nil {
+			return nil, err
+		}
+	}
+	if len(def.Weekdays) > 0 && recurrence.weekdays == nil {
+		weekdays, err := parseWeekdays(def.Weekdays)
+		if err != nil {
+			return nil, err
+		}
+		recurrence.weekdays = weekdays
+		if def.RRule == "" {
+			recurrence.freq = freqWeekly
+		}
+	}
+
+	startTime, err := time.Parse(TimeFormat, def.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("%w: startTime must use the %s layout", newError.ErrInvalidSchedule, TimeFormat)
+	}
+	if def.DurationMinutes <= 0 {
+		return nil, fmt.Errorf("%w: durationMinutes must be positive", newError.ErrInvalidSchedule)
+	}
+	recurrence.startTime = time.Duration(startTime.Hour())*time.Hour + time.Duration(startTime.Minute())*time.Minute
+	recurrence.duration = time.Duration(def.DurationMinutes) * time.Minute
+	recurrence.timed = true
+
+	return recurrence, nil
+}
+
+// Timed reports whether occurrences have a real start time and duration.
+func (r *Recurrence) Timed() bool {
+	return r.timed
+}
+
+// Location returns the time zone the occurrences are scheduled in.
+func (r *Recurrence) Location() *time.Location {
+	return r.loc
+}
+
+// Day returns the calendar date of t in the time zone of the recurrence, as
+// midnight UTC like the class start and end dates.
+func (r *Recurrence) Day(t time.Time) time.Time {
+	return civilDate(t.In(r.loc))
+}
+
+// Occurrences returns every occurrence of the class in chronological order.
+func (r *Recurrence) Occurrences() []Occurrence {
+	occurrences := make([]Occurrence, 0)
+	for day := r.start; !day.After(r.end); day = day.AddDate(0, 0, 1) {
+		if !r.until.IsZero() && day.After(r.until) {
+			break
+		}
+		if !r.matches(day) {
+			continue
+		}
+		occurrences = append(occurrences, Occurrence{Start: r.startOn(day), Duration: r.duration})
+		if r.count > 0 && len(occurrences) == r.count {
+			break
+		}
+	}
+	return occurrences
+}
+
+// End returns when the occurrence starting at start is over. Untimed
+// occurrences last until the following local midnight.
+func (r *Recurrence) End(start time.Time) time.Time {
+	if r.timed {
+		return start.Add(r.duration)
+	}
+	local := start.In(r.loc)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, r.loc).UTC()
+}
+
+// Resolve returns the start of the occurrence on the given date. bookingTime is
+// optional; when set it must match the local class start time.
+func (r *Recurrence) Resolve(date time.Time, bookingTime string) (time.Time, error) {
+	if bookingTime != "" {
+		clock, err := time.Parse(TimeFormat, bookingTime)
+		if err != nil {
+			return time.Time{}, err
+		}
+		offset := time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute
+		if offset != r.startTime {
+			return time.Time{}, newError.ErrNoClassOccurrence
+		}
+	}
+
+	start := r.startOn(civilDate(date))
+	for _, occurrence := range r.Occurrences() {
+		if occurrence.Start.Equal(start) {
+			return start, nil
+		}
+		if occurrence.Start.After(start) {
+			break
+		}
+	}
+	return time.Time{}, newError.ErrNoClassOccurrence
+}
+
+// startOn returns the UTC start of the occurrence on the given calendar day.
+// The wall clock time is set directly, rather than added to midnight, so that
+// it holds on days where DST starts or ends.
+func (r *Recurrence) startOn(day time.Time) time.Time {
+	hour, minute := int(r.startTime/time.Hour), int(r.startTime%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, r.loc).UTC()
+}
+
+// civilDate returns the calendar date of t as midnight UTC.
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// matches reports whether the recurrence rule selects the given day,
+// ignoring COUNT and UNTIL which are applied while enumerating.
+func (r *Recurrence) matches(day time.Time) bool {
+	days := int(day.Sub(r.start).Hours() / 24)
+	switch r.freq {
+	case freqWeekly:
+		// Weeks start on Monday (RFC 5545 default WKST=MO)
+		week := (days + (int(r.start.Weekday())+6)%7) / 7
+		if week%r.interval != 0 {
+			return false
+		}
+		if r.weekdays == nil {
+			return day.Weekday() == r.start.Weekday()
+		}
+	default:
+		if days%r.interval != 0 {
+			return false
+		}
+		if r.weekdays == nil {
+			return true
+		}
+	}
+	return r.weekdays[day.Weekday()]
+}
+
+// parseRRule applies the supported subset of an RFC 5545 RRULE to the recurrence.
+func (r *Recurrence) parseRRule(rule string) error {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		name, value, found := strings.Cut(part, "=")
+		if !found {
+			return fmt.Errorf("%w: malformed rrule part %q", newError.ErrInvalidSchedule, part)
+		}
+		switch name {
+		case "FREQ":
+			if value != freqDaily && value != freqWeekly {
+				return fmt.Errorf("%w: unsupported rrule FREQ %q", newError.ErrInvalidSchedule, value)
+			}
+			r.freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval <= 0 {
+				return fmt.Errorf("%w: invalid rrule INTERVAL %q", newError.ErrInvalidSchedule, value)
+			}
+			r.interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count <= 0 {
+				return fmt.Errorf("%w: invalid rrule COUNT %q", newError.ErrInvalidSchedule, value)
+			}
+			r.count = count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return err
+			}
+			r.until = until
+		case "BYDAY":
+			weekdays, err := parseWeekdays(strings.Split(value, ","))
+			if err != nil {
+				return err
+			}
+			r.weekdays = weekdays
+		case "WKST":
+			if value != "MO" {
+				return fmt.Errorf("%w: only WKST=MO is supported", newError.ErrInvalidSchedule)
+			}
+		default:
+			return fmt.Errorf("%w: unsupported rrule part %q", newError.ErrInvalidSchedule, name)
+		}
+	}
+	if r.count > 0 && !r.until.IsZero() {
+		return fmt.Errorf("%w: rrule COUNT and UNTIL are mutually exclusive", newError.ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// parseUntil accepts the DATE and UTC DATE-TIME forms of an RRULE UNTIL value.
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "20060102T150405Z"} {
+		if until, err := time.Parse(layout, value); err == nil {
+			return until.Truncate(24 * time.Hour), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid rrule UNTIL %q", newError.ErrInvalidSchedule, value)
+}
+
+// parseWeekdays converts weekday names or codes into a weekday set.
+func parseWeekdays(names []string) (map[time.Weekday]bool, error) {
+	weekdays := make(map[time.Weekday]bool, len(names))
+	for _, name := range names {
+		weekday, ok := weekdayCodes[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown weekday %q", newError.ErrInvalidSchedule, name)
+		}
+		weekdays[weekday] = true
+	}
+	return weekdays, nil
+}
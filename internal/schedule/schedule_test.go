@@ -0,0 +1,145 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	newError "glofox/errors"
+	"glofox/models/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse("2006-01-02", value)
+	return parsed
+}
+
+func starts(occurrences []Occurrence) []string {
+	formatted := make([]string, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		formatted = append(formatted, occurrence.Start.Format("Mon 2006-01-02 15:04"))
+	}
+	return formatted
+}
+
+func TestNew_WithoutScheduleRunsDaily(t *testing.T) {
+	recurrence, err := New(nil, date("2025-06-01"), date("2025-06-03"), time.UTC)
+
+	// Assert that one untimed occurrence is produced per day
+	assert.NoError(t, err)
+	assert.False(t, recurrence.Timed())
+	assert.Equal(t, []string{"Sun 2025-06-01 00:00", "Mon 2025-06-02 00:00", "Tue 2025-06-03 00:00"}, starts(recurrence.Occurrences()))
+}
+
+func TestNew_Weekdays(t *testing.T) {
+	def := &dto.Schedule{Weekdays: []string{"Mon", "wednesday", "FR"}, StartTime: "07:00", DurationMinutes: 60}
+	recurrence, err := New(def, date("2025-06-01"), date("2025-06-08"), time.UTC)
+
+	// Assert that only Mon/Wed/Fri at 07:00 are occurrences
+	assert.NoError(t, err)
+	assert.True(t, recurrence.Timed())
+	occurrences := recurrence.Occurrences()
+	assert.Equal(t, []string{"Mon 2025-06-02 07:00", "Wed 2025-06-04 07:00", "Fri 2025-06-06 07:00"}, starts(occurrences))
+	assert.Equal(t, time.Hour, occurrences[0].Duration)
+}
+
+func TestNew_RRuleIntervalAndCount(t *testing.T) {
+	// Every other week on Tuesday and Thursday, four runs in total
+	def := &dto.Schedule{StartTime: "18:30", DurationMinutes: 45, RRule: "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=4"}
+	recurrence, err := New(def, date("2025-06-02"), date("2025-07-31"), time.UTC)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Tue 2025-06-03 18:30", "Thu 2025-06-05 18:30",
+		"Tue 2025-06-17 18:30", "Thu 2025-06-19 18:30",
+	}, starts(recurrence.Occurrences()))
+}
+
+func TestNew_RRuleDailyUntil(t *testing.T) {
+	def := &dto.Schedule{StartTime: "06:00", DurationMinutes: 30, RRule: "FREQ=DAILY;INTERVAL=3;UNTIL=20250607"}
+	recurrence, err := New(def, date("2025-06-01"), date("2025-06-30"), time.UTC)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Sun 2025-06-01 06:00", "Wed 2025-06-04 06:00", "Sat 2025-06-07 06:00"}, starts(recurrence.Occurrences()))
+}
+
+func TestNew_InvalidSchedules(t *testing.T) {
+	invalid := []*dto.Schedule{
+		{Weekdays: []string{"Funday"}, StartTime: "07:00", DurationMinutes: 60},
+		{Weekdays: []string{"MO"}, StartTime: "7am", DurationMinutes: 60},
+		{Weekdays: []string{"MO"}, StartTime: "07:00"},
+		{StartTime: "07:00", DurationMinutes: 60, RRule: "FREQ=MONTHLY"},
+		{StartTime: "07:00", DurationMinutes: 60, RRule: "FREQ=WEEKLY;BYSETPOS=1"},
+		{StartTime: "07:00", DurationMinutes: 60, RRule: "FREQ=DAILY;COUNT=2;UNTIL=20250607"},
+	}
+
+	for _, def := range invalid {
+		_, err := New(def, date("2025-06-01"), date("2025-06-30"), time.UTC)
+		assert.True(t, errors.Is(err, newError.ErrInvalidSchedule), "schedule %+v", def)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	def := &dto.Schedule{Weekdays: []string{"MO"}, StartTime: "07:00", DurationMinutes: 60}
+	recurrence, _ := New(def, date("2025-06-01"), date("2025-06-30"), time.UTC)
+
+	// Booking a Monday without or with the matching time resolves to the occurrence start
+	occurrence, err := recurrence.Resolve(date("2025-06-02"), "")
+	assert.NoError(t, err)
+	assert.Equal(t, date("2025-06-02").Add(7*time.Hour), occurrence)
+
+	_, err = recurrence.Resolve(date("2025-06-02"), "07:00")
+	assert.NoError(t, err)
+
+	// A Tuesday, or a Monday at the wrong time, is not an occurrence
+	_, err = recurrence.Resolve(date("2025-06-03"), "")
+	assert.Equal(t, newError.ErrNoClassOccurrence, err)
+
+	_, err = recurrence.Resolve(date("2025-06-02"), "08:00")
+	assert.Equal(t, newError.ErrNoClassOccurrence, err)
+}
+
+func TestNew_TimezoneAndDST(t *testing.T) {
+	dublin, err := LoadLocation("Europe/Dublin")
+	assert.NoError(t, err)
+
+	// Irish summer time starts on Sunday 30 March 2025; the class stays at 07:00 local
+	def := &dto.Schedule{StartTime: "07:00", DurationMinutes: 60}
+	recurrence, err := New(def, date("2025-03-29"), date("2025-03-30"), dublin)
+	assert.NoError(t, err)
+	occurrences := recurrence.Occurrences()
+	assert.Equal(t, time.Date(2025, 3, 29, 7, 0, 0, 0, time.UTC), occurrences[0].Start)
+	assert.Equal(t, time.Date(2025, 3, 30, 6, 0, 0, 0, time.UTC), occurrences[1].Start)
+	assert.Equal(t, "07:00", occurrences[1].Start.In(recurrence.Location()).Format(TimeFormat))
+
+	// Without a schedule a class in UTC+10 starts at local midnight, the evening before in UTC
+	sydney, _ := LoadLocation("Australia/Sydney")
+	recurrence, _ = New(nil, date("2025-06-02"), date("2025-06-02"), sydney)
+	assert.Equal(t, time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC), recurrence.Occurrences()[0].Start)
+	assert.Equal(t, date("2025-06-02"), recurrence.Day(time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)))
+}
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	_, err = LoadLocation("Mars/Olympus_Mons")
+	assert.True(t, errors.Is(err, newError.ErrInvalidTimezone))
+}
+
+func TestRecurrence_End(t *testing.T) {
+	def := &dto.Schedule{StartTime: "18:30", DurationMinutes: 45}
+	recurrence, err := New(def, date("2025-06-02"), date("2025-06-02"), time.UTC)
+	assert.NoError(t, err)
+	start := recurrence.Occurrences()[0].Start
+	assert.Equal(t, time.Date(2025, 6, 2, 19, 15, 0, 0, time.UTC), recurrence.End(start))
+
+	// An untimed class in UTC+10 lasts until the next local midnight
+	sydney, _ := LoadLocation("Australia/Sydney")
+	recurrence, _ = New(nil, date("2025-06-02"), date("2025-06-02"), sydney)
+	start = recurrence.Occurrences()[0].Start
+	assert.Equal(t, time.Date(2025, 6, 2, 14, 0, 0, 0, time.UTC), recurrence.End(start))
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"errors"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/schedule"
+	"glofox/models/dto"
+	"log"
+	"time"
+)
+
+// checkInOpensBefore is how long before the start of a class members can check in.
+const checkInOpensBefore = time.Hour
+
+// CheckIn marks a confirmed booking as attended. Check-in opens an hour before
+// the class starts; a booking already marked as a no-show can still be checked
+// in late. Checking in an attended booking again changes nothing.
+func (service *service) CheckIn(id string) (dto.Booking, error) {
+	return service.repo.UpdateBooking(id, func(booking dto.Booking, exists bool) (dto.Booking, error) {
+		if !exists {
+			return booking, newError.ErrBookingNotExist
+		}
+		switch booking.Status {
+		case dto.BookingAttended:
+			return booking, nil
+		case dto.BookingConfirmed, dto.BookingNoShow:
+		default:
+			return booking, newError.ErrCheckInNotAllowed
+		}
+		if service.clock.Now().Before(booking.Occurrence.Add(-checkInOpensBefore)) {
+			return booking, newError.ErrCheckInNotOpen
+		}
+		booking.Status = dto.BookingAttended
+		return booking, nil
+	})
+}
+
+// CheckInRoster checks in every listed member attending a class occurrence, as
+// an instructor does from the class roster. Members that can not be checked in
+// are reported one by one and do not keep the others from being checked in.
+func (service *service) CheckInRoster(className string, roster dto.RosterCheckIn) (dto.RosterCheckInResult, error) {
+	bookingDate, err := service.parseBookingDate(roster.BookingDate)
+	if err != nil {
+		return dto.RosterCheckInResult{}, err
+	}
+	classInfo, exist, err := service.repo.LoadClass(className)
+	if err != nil {
+		return dto.RosterCheckInResult{}, err
+	}
+	if !exist {
+		return dto.RosterCheckInResult{}, newError.ErrClassNotExist
+	}
+	occurrence, err := occurrenceOf(classInfo, bookingDate, roster.BookingTime)
+	if err != nil {
+		return dto.RosterCheckInResult{}, err
+	}
+
+	result := dto.RosterCheckInResult{
+		CheckedIn: make([]dto.Booking, 0, len(roster.MemberIDs)),
+		Failed:    make([]dto.CheckInFailure, 0),
+	}
+	for _, memberID := range roster.MemberIDs {
+		booking, err := service.occurrenceBooking(className, occurrence, memberID)
+		if err == nil {
+			booking, err = service.CheckIn(booking.ID)
+		}
+		if err != nil {
+			if newError.CodeOf(err) == newError.CodeInternal {
+				log.Println("Error: failed to check in member", memberID, err)
+			}
+			result.Failed = append(result.Failed, dto.CheckInFailure{
+				MemberID: memberID,
+				Code:     string(newError.CodeOf(err)),
+				Message:  newError.MessageOf(err),
+			})
+			continue
+		}
+		result.CheckedIn = append(result.CheckedIn, booking)
+	}
+	return result, nil
+}
+
+// occurrenceBooking returns the booking a member holds for a class occurrence,
+// whether still open, attended or missed, looked up through the member index.
+func (service *service) occurrenceBooking(className string, occurrence time.Time, memberID string) (dto.Booking, error) {
+	bookings, err := service.repo.MemberBookings(memberID, repository.BookingFilter{
+		From:     occurrence,
+		To:       occurrence.Add(time.Second),
+		Statuses: []string{dto.BookingConfirmed, dto.BookingWaitlisted, dto.BookingAttended, dto.BookingNoShow},
+	})
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	for _, booking := range bookings {
+		if booking.ClassName == className {
+			return booking, nil
+		}
+	}
+	return dto.Booking{}, newError.ErrBookingNotExist
+}
+
+// MarkNoShows marks the confirmed bookings of every class occurrence that has
+// ended without the member checking in as no-shows, and returns how many were
+// marked. It walks the bookings held by the classes and is meant to run periodically.
+func (service *service) MarkNoShows() (int, error) {
+	names, err := service.repo.ClassNames()
+	if err != nil {
+		return 0, err
+	}
+
+	now := service.clock.Now()
+	marked := 0
+	for _, name := range names {
+		classInfo, exist, err := service.repo.LoadClass(name)
+		if err != nil {
+			return marked, err
+		}
+		if !exist {
+			continue
+		}
+		recurrence, err := schedule.ForClass(classInfo)
+		if err != nil {
+			log.Println("Error: failed to expand schedule of class", name, err)
+			continue
+		}
+		for occurrence, memberIDs := range classInfo.Bookings {
+			if now.Before(recurrence.End(occurrence)) {
+				continue
+			}
+			for _, memberID := range memberIDs {
+				if service.markNoShow(name, occurrence, memberID) {
+					marked++
+				}
+			}
+		}
+	}
+	return marked, nil
+}
+
+// markNoShow marks the booking of a member for an occurrence as a no-show,
+// unless it is no longer confirmed, and reports whether it did.
+func (service *service) markNoShow(className string, occurrence time.Time, memberID string) bool {
+	record, exist, err := service.repo.FindBooking(className, occurrence, memberID)
+	if err != nil || !exist || record.Status != dto.BookingConfirmed {
+		return false
+	}
+	_, err = service.repo.UpdateBooking(record.ID, func(booking dto.Booking, exists bool) (dto.Booking, error) {
+		if !exists || booking.Status != dto.BookingConfirmed {
+			return booking, errNotConfirmed
+		}
+		booking.Status = dto.BookingNoShow
+		return booking, nil
+	})
+	if err != nil && !errors.Is(err, errNotConfirmed) {
+		log.Println("Error: failed to mark no-show of member", memberID, err)
+	}
+	return err == nil
+}
+
+// errNotConfirmed aborts marking a no-show for a booking checked in or cancelled in the meantime.
+var errNotConfirmed = errors.New("booking is no longer confirmed")
+
+// GetAttendance returns how often a member attended and missed booked classes,
+// and until when repeated no-shows keep the member from booking.
+func (service *service) GetAttendance(memberID string) (dto.Attendance, error) {
+	if _, err := service.GetMember(memberID); err != nil {
+		return dto.Attendance{}, err
+	}
+	bookings, err := service.repo.MemberBookings(memberID, repository.BookingFilter{
+		Statuses: []string{dto.BookingAttended, dto.BookingNoShow},
+	})
+	if err != nil {
+		return dto.Attendance{}, err
+	}
+
+	attendance := dto.Attendance{MemberID: memberID}
+	for _, booking := range bookings {
+		if booking.Status == dto.BookingAttended {
+			attendance.Attended++
+		} else {
+			attendance.NoShows++
+		}
+	}
+	until, err := service.noShowBlock(memberID)
+	if err != nil {
+		return dto.Attendance{}, err
+	}
+	if !until.IsZero() {
+		attendance.BlockedUntil = &until
+	}
+	return attendance, nil
+}
+
+// checkNoShowBlock fails while repeated no-shows keep a member from booking.
+func (service *service) checkNoShowBlock(memberID string) error {
+	until, err := service.noShowBlock(memberID)
+	if err != nil {
+		return err
+	}
+	if !until.IsZero() {
+		return newError.ErrMemberBlocked
+	}
+	return nil
+}
+
+// noShowBlock returns until when the no-show policy blocks a member from
+// booking, or the zero time when the member may book.
+func (service *service) noShowBlock(memberID string) (time.Time, error) {
+	policy := service.cfg.NoShowPolicy
+	if policy.Limit <= 0 {
+		return time.Time{}, nil
+	}
+
+	now := service.clock.Now()
+	filter := repository.BookingFilter{To: now, Statuses: []string{dto.BookingNoShow}}
+	if policy.WindowDays > 0 {
+		filter.From = now.AddDate(0, 0, -policy.WindowDays)
+	}
+	noShows, err := service.repo.MemberBookings(memberID, filter)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(noShows) < policy.Limit {
+		return time.Time{}, nil
+	}
+	until := noShows[len(noShows)-1].Occurrence.AddDate(0, 0, policy.BlockDays).UTC()
+	if !now.Before(until) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
@@ -0,0 +1,169 @@
+package service_test
+
+import (
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serviceAt returns a service over repo that sees the given time in June 2030.
+func serviceAt(repo repository.Repository, cfg config.Config, day, hour, minute int) service.BusinessService {
+	cfg.DateFormat = "2006-01-02"
+	return service.InitializeServiceWithClock(repo, cfg, fixedClock(time.Date(2030, 6, day, hour, minute, 0, 0, time.UTC)))
+}
+
+func TestCheckIn(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		policyClass(t, repo, nil)
+
+		svc := serviceAt(repo, config.Config{}, 1, 9, 0)
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-10"})
+		require.NoError(t, err)
+		cancelled, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-10"})
+		require.NoError(t, err)
+		require.NoError(t, svc.CancelBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-10"}))
+
+		// Check-in opens an hour before the 18:00 start
+		_, err = serviceAt(repo, config.Config{}, 10, 16, 30).CheckIn(booked.BookingID)
+		assert.Equal(t, newError.ErrCheckInNotOpen, err)
+
+		svc = serviceAt(repo, config.Config{}, 10, 17, 10)
+		booking, err := svc.CheckIn(booked.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingAttended, booking.Status)
+
+		// Checking in twice changes nothing
+		booking, err = svc.CheckIn(booked.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingAttended, booking.Status)
+
+		_, err = svc.CheckIn(cancelled.BookingID)
+		assert.Equal(t, newError.ErrCheckInNotAllowed, err)
+		_, err = svc.CheckIn("bkg_unknown")
+		assert.Equal(t, newError.ErrBookingNotExist, err)
+	})
+}
+
+func TestCheckInRoster_ReportsEachMember(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe", "max_doe")
+		policyClass(t, repo, nil)
+
+		svc := serviceAt(repo, config.Config{}, 1, 9, 0)
+		for _, memberID := range []string{"john_doe", "jane_doe"} {
+			_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: memberID, BookingDate: "2030-06-10"})
+			require.NoError(t, err)
+		}
+		// Max only booked another evening
+		_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "max_doe", BookingDate: "2030-06-11"})
+		require.NoError(t, err)
+
+		svc = serviceAt(repo, config.Config{}, 10, 17, 30)
+		result, err := svc.CheckInRoster("Spin", dto.RosterCheckIn{BookingDate: "2030-06-10", MemberIDs: []string{"john_doe", "max_doe", "jane_doe"}})
+		require.NoError(t, err)
+		require.Len(t, result.CheckedIn, 2)
+		assert.Equal(t, "john_doe", result.CheckedIn[0].MemberID)
+		assert.Equal(t, "jane_doe", result.CheckedIn[1].MemberID)
+		assert.Equal(t, dto.BookingAttended, result.CheckedIn[1].Status)
+		require.Len(t, result.Failed, 1)
+		assert.Equal(t, "max_doe", result.Failed[0].MemberID)
+		assert.Equal(t, string(newError.CodeOf(newError.ErrBookingNotExist)), result.Failed[0].Code)
+
+		// The whole roster fails for a date the class does not run
+		_, err = svc.CheckInRoster("Spin", dto.RosterCheckIn{BookingDate: "2030-07-10", MemberIDs: []string{"john_doe"}})
+		assert.Equal(t, newError.ErrNoClassOccurrence, err)
+		_, err = svc.CheckInRoster("Boxing", dto.RosterCheckIn{BookingDate: "2030-06-10", MemberIDs: []string{"john_doe"}})
+		assert.Equal(t, newError.ErrClassNotExist, err)
+	})
+}
+
+func TestMarkNoShows(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		policyClass(t, repo, nil)
+
+		svc := serviceAt(repo, config.Config{}, 1, 9, 0)
+		for _, memberID := range []string{"john_doe", "jane_doe"} {
+			_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: memberID, BookingDate: "2030-06-10"})
+			require.NoError(t, err)
+		}
+		upcoming, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-12"})
+		require.NoError(t, err)
+
+		// Nothing is marked while the 45 minute class is still running
+		svc = serviceAt(repo, config.Config{}, 10, 18, 30)
+		marked, err := svc.MarkNoShows()
+		require.NoError(t, err)
+		assert.Zero(t, marked)
+		_, err = svc.CheckInRoster("Spin", dto.RosterCheckIn{BookingDate: "2030-06-10", MemberIDs: []string{"john_doe"}})
+		require.NoError(t, err)
+
+		// Once it has ended, only Jane who never checked in is marked, and only once
+		svc = serviceAt(repo, config.Config{}, 10, 19, 0)
+		marked, err = svc.MarkNoShows()
+		require.NoError(t, err)
+		assert.Equal(t, 1, marked)
+		marked, err = svc.MarkNoShows()
+		require.NoError(t, err)
+		assert.Zero(t, marked)
+
+		booking, err := svc.GetBooking(upcoming.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingConfirmed, booking.Status)
+
+		attendance, err := svc.GetAttendance("john_doe")
+		require.NoError(t, err)
+		assert.Equal(t, dto.Attendance{MemberID: "john_doe", Attended: 1}, attendance)
+		attendance, err = svc.GetAttendance("jane_doe")
+		require.NoError(t, err)
+		assert.Equal(t, dto.Attendance{MemberID: "jane_doe", NoShows: 1}, attendance)
+
+		_, err = svc.GetAttendance("mem_unknown")
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+	})
+}
+
+func TestNoShowPolicy_BlocksBooking(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		policyClass(t, repo, nil)
+		cfg := config.Config{NoShowPolicy: config.NoShowPolicy{Limit: 2, WindowDays: 30, BlockDays: 7}}
+
+		svc := serviceAt(repo, cfg, 1, 9, 0)
+		for _, date := range []string{"2030-06-10", "2030-06-11"} {
+			_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: date})
+			require.NoError(t, err)
+		}
+		gift, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15"})
+		require.NoError(t, err)
+
+		// Jane misses both evenings and is blocked for a week after the last one
+		svc = serviceAt(repo, cfg, 12, 9, 0)
+		marked, err := svc.MarkNoShows()
+		require.NoError(t, err)
+		assert.Equal(t, 2, marked)
+
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-20"})
+		assert.Equal(t, newError.ErrMemberBlocked, err)
+		_, err = svc.RescheduleBooking(gift.BookingID, dto.RescheduleInfo{MemberID: "jane_doe"})
+		assert.Equal(t, newError.ErrMemberBlocked, err)
+
+		attendance, err := svc.GetAttendance("jane_doe")
+		require.NoError(t, err)
+		require.NotNil(t, attendance.BlockedUntil)
+		assert.Equal(t, time.Date(2030, 6, 18, 18, 0, 0, 0, time.UTC), *attendance.BlockedUntil)
+
+		// The block is lifted once the week has passed
+		svc = serviceAt(repo, cfg, 18, 18, 0)
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-20"})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	mapstore "glofox/core"
+	"glofox/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is a minimal, non-singleton MapStore so that every test starts from an empty map.
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: make(map[string]interface{})}
+}
+
+func (m *memoryStore) Load(key string) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *memoryStore) Store(key string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryStore) Update(key string, fn mapstore.UpdateFunc) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, exists := m.values[key]
+	value, err := fn(old, exists)
+	if errors.Is(err, mapstore.ErrDeleteKey) {
+		delete(m.values, key)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.values[key] = value
+	return value, nil
+}
+
+func (m *memoryStore) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.values))
+	for key := range m.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// backend opens a fresh, empty repository of one storage kind.
+type backend struct {
+	name string
+	open func(t *testing.T) repository.Repository
+}
+
+// backends lists every repository implementation the service tests run against.
+var backends = []backend{
+	{
+		name: "map",
+		open: func(t *testing.T) repository.Repository {
+			return repository.NewMapRepository(newMemoryStore())
+		},
+	},
+	{
+		name: "sqlite",
+		open: func(t *testing.T) repository.Repository {
+			repo, err := repository.OpenSQLite(":memory:")
+			require.NoError(t, err)
+			t.Cleanup(func() { repo.(io.Closer).Close() })
+			return repo
+		},
+	},
+}
+
+// forEachBackend runs test once per repository implementation.
+func forEachBackend(t *testing.T, test func(t *testing.T, repo repository.Repository)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			test(t, b.open(t))
+		})
+	}
+}
+
+// fixedClock is a service.Clock that always reports the same time.
+type fixedClock time.Time
+
+func (clock fixedClock) Now() time.Time {
+	return time.Time(clock)
+}
@@ -2,44 +2,422 @@ package service
 
 import (
 	newError "glofox/errors"
+	"glofox/internal/schedule"
 	"glofox/models/dto"
+	"log"
+	"slices"
 	"time"
 )
 
-// CreateBooking handles booking a user into a class on a specific date.
-// It performs several checks including class existence, booking window validity,
-// capacity limits, and then stores the booking in the system.
-func (service *service) CreateBooking(bookingInfo dto.BookingInfo) error {
+// CreateBooking handles booking a member into a class on a specific date.
+// It performs several checks including member status and no-show blocks, class existence, the
+// booking window of the class policy, credits, duplicate bookings and capacity limits, and then
+// stores the booking in the system. When the date is full and the member asked
+// for it, the member is put on the waitlist instead.
+// A credit is consumed first and the booking is then written in a single atomic
+// update of the class; if that update fails the credit is put back.
+// Every booking is also kept as a record with its own id, status and source channel.
+func (service *service) CreateBooking(bookingInfo dto.BookingInfo) (dto.BookingResult, error) {
 
-	bookingDate, err := time.Parse(service.cfg.DateFormat, bookingInfo.BookingDate)
+	bookingDate, err := service.parseBookingDate(bookingInfo.BookingDate)
 	if err != nil {
-		return err
+		return dto.BookingResult{}, err
+	}
+	source, err := bookingSource(bookingInfo.Source)
+	if err != nil {
+		return dto.BookingResult{}, err
+	}
+
+	// Only registered, active members can book, unless they keep not showing up
+	if _, err = service.activeMember(bookingInfo.MemberID); err != nil {
+		return dto.BookingResult{}, err
+	}
+	if err = service.checkNoShowBlock(bookingInfo.MemberID); err != nil {
+		return dto.BookingResult{}, err
 	}
-	service.lock.Lock()
-	defer service.lock.Unlock()
 
-	classDate, exist := service.syMap.Load(bookingInfo.ClassName)
+	// Resolve the occurrence up front so that the credit is charged for the right class run
+	classInfo, exist, err := service.repo.LoadClass(bookingInfo.ClassName)
+	if err != nil {
+		return dto.BookingResult{}, err
+	}
 	if !exist {
-		err = newError.ErrClassNotExist
+		return dto.BookingResult{}, newError.ErrClassNotExist
+	}
+	occurrence, err := service.bookableOccurrence(classInfo, bookingDate, bookingInfo.BookingTime)
+	if err != nil {
+		return dto.BookingResult{}, err
+	}
+
+	credit, err := service.consumeCredit(bookingInfo.MemberID, bookingInfo.ClassName, occurrence)
+	if err != nil {
+		return dto.BookingResult{}, err
+	}
+
+	var result dto.BookingResult
+	_, err = service.repo.UpdateClass(bookingInfo.ClassName, func(typeCastData dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if !exist {
+			return typeCastData, newError.ErrClassNotExist
+		}
+
+		// The class may have changed since it was read, so check it again
+		current, err := service.bookableOccurrence(typeCastData, bookingDate, bookingInfo.BookingTime)
+		if err != nil {
+			return typeCastData, err
+		}
+		if !current.Equal(occurrence) {
+			return typeCastData, newError.ErrNoClassOccurrence
+		}
+		if slices.Contains(typeCastData.Bookings[occurrence], bookingInfo.MemberID) {
+			return typeCastData, newError.ErrDuplicateBooking
+		}
+		if len(typeCastData.Bookings[occurrence]) >= typeCastData.AllowedCapacity {
+			if !bookingInfo.JoinWaitlist {
+				return typeCastData, newError.ErrSlotsFullForTheDate
+			}
+			result, err = joinWaitlist(bookingInfo, occurrence, typeCastData)
+			return typeCastData, err
+		}
+
+		typeCastData.Bookings[occurrence] = append(typeCastData.Bookings[occurrence], bookingInfo.MemberID)
+		result = dto.BookingResult{Status: dto.BookingConfirmed}
+		return typeCastData, nil
+	})
+	if err != nil {
+		service.revertCredit(bookingInfo.MemberID, credit)
+		return dto.BookingResult{}, err
+	}
+
+	record, err := service.storeBookingRecord(bookingInfo, source, localTime(classInfo, occurrence), result.Status)
+	if err != nil {
+		// Without a record the booking can not be looked up, so take it back
+		service.dropBooking(bookingInfo.ClassName, occurrence, bookingInfo.MemberID)
+		service.revertCredit(bookingInfo.MemberID, credit)
+		return dto.BookingResult{}, newError.ErrCreatingBooking
+	}
+
+	result.BookingID = record.ID
+	result.Occurrence = record.Occurrence
+	return result, nil
+}
+
+// GetBooking returns the booking record stored under id.
+func (service *service) GetBooking(id string) (dto.Booking, error) {
+	booking, exist, err := service.repo.LoadBooking(id)
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	if !exist {
+		return dto.Booking{}, newError.ErrBookingNotExist
+	}
+	return booking, nil
+}
+
+// CancelBooking removes a member's booking from a class on a specific date.
+// It checks that the class exists, that the class date and the cancellation
+// deadline of the class policy have not already passed and that the member
+// actually holds a booking or a waitlist place, then frees it.
+// A freed slot is handed to the first member on the waitlist. The credit of the
+// booking is refunded when a waitlist place is given up, or when the booking is
+// cancelled at least RefundWindowHours before the class starts.
+func (service *service) CancelBooking(bookingInfo dto.BookingInfo) error {
+
+	bookingDate, err := service.parseBookingDate(bookingInfo.BookingDate)
+	if err != nil {
+		return err
+	}
+
+	var occurrence time.Time
+	var waitlisted bool
+	var promoted []string
+	_, err = service.repo.UpdateClass(bookingInfo.ClassName, func(typeCastData dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if !exist {
+			return typeCastData, newError.ErrClassNotExist
+		}
+
+		recurrence, err := schedule.ForClass(typeCastData)
+		if err != nil {
+			return typeCastData, err
+		}
+		if bookingDate.day(recurrence).Before(recurrence.Day(service.clock.Now())) {
+			return typeCastData, newError.ErrCancellationDatePassed
+		}
+
+		occurrence, err = occurrenceOf(typeCastData, bookingDate, bookingInfo.BookingTime)
+		if err != nil {
+			return typeCastData, newError.ErrBookingNotExist
+		}
+
+		waitlisted = false
+		if !removeUser(typeCastData.Bookings, occurrence, bookingInfo.MemberID) {
+			if !removeUser(typeCastData.Waitlist, occurrence, bookingInfo.MemberID) {
+				return typeCastData, newError.ErrBookingNotExist
+			}
+			waitlisted = true
+		}
+		if err = service.checkCancellationDeadline(typeCastData, occurrence); err != nil {
+			return typeCastData, err
+		}
+
+		promoted = promoteWaitlist(typeCastData, occurrence)
+		return typeCastData, nil
+	})
+	if err != nil {
 		return err
 	}
 
-	// Type assert the loaded value to ClassInfo type
-	typeCastData := classDate.(dto.ClassInfo)
+	// The payer is read from the record while it is still active
+	payer := service.bookingPayer(bookingInfo.ClassName, occurrence, bookingInfo.MemberID)
+	service.syncBookingStatus(bookingInfo.ClassName, appendAffected(nil, occurrence, []string{bookingInfo.MemberID}), dto.BookingCancelled)
+	service.syncBookingStatus(bookingInfo.ClassName, appendAffected(nil, occurrence, promoted), dto.BookingConfirmed)
+
+	if waitlisted || service.refundable(occurrence) {
+		service.refundCredit(payer, bookingInfo.ClassName, occurrence)
+	}
+	return nil
+}
 
-	if bookingDate.Before(typeCastData.StartDate) {
-		return newError.ErrBookingDatePassed
+// CancelBookingByID cancels the booking or waitlist place recorded under id in
+// the same way as CancelBooking, and returns the cancelled booking record.
+func (service *service) CancelBookingByID(id string) (dto.Booking, error) {
+	record, err := service.GetBooking(id)
+	if err != nil {
+		return dto.Booking{}, err
 	}
-	if bookingDate.After(typeCastData.EndDate) {
-		return newError.ErrBookingDatePassed
+	if record.Status != dto.BookingConfirmed && record.Status != dto.BookingWaitlisted {
+		return dto.Booking{}, newError.ErrBookingNotExist
 	}
-	if len(typeCastData.Bookings[bookingDate]) >= typeCastData.AllowedCapacity {
-		return newError.ErrSlotsFullForTheDate
+
+	err = service.CancelBooking(dto.BookingInfo{
+		ClassName:   record.ClassName,
+		MemberID:    record.MemberID,
+		BookingDate: record.Occurrence.Format(time.RFC3339),
+	})
+	if err != nil {
+		return dto.Booking{}, err
 	}
+	return service.GetBooking(id)
+}
+
+// GetWaitlistPosition returns the 1-based position of a member on the waitlist
+// of a class for a specific date.
+func (service *service) GetWaitlistPosition(bookingInfo dto.BookingInfo) (int, error) {
 
-	typeCastData.Bookings[bookingDate] = append(typeCastData.Bookings[bookingDate], bookingInfo.UserName)
+	bookingDate, err := service.parseBookingDate(bookingInfo.BookingDate)
+	if err != nil {
+		return 0, err
+	}
 
-	service.syMap.Store(bookingInfo.ClassName, typeCastData)
+	typeCastData, exist, err := service.repo.LoadClass(bookingInfo.ClassName)
+	if err != nil {
+		return 0, err
+	}
+	if !exist {
+		return 0, newError.ErrClassNotExist
+	}
 
-	return err
+	occurrence, err := occurrenceOf(typeCastData, bookingDate, bookingInfo.BookingTime)
+	if err != nil {
+		return 0, newError.ErrNotOnWaitlist
+	}
+
+	index := slices.Index(typeCastData.Waitlist[occurrence], bookingInfo.MemberID)
+	if index < 0 {
+		return 0, newError.ErrNotOnWaitlist
+	}
+	return index + 1, nil
+}
+
+// joinWaitlist appends the member to the waitlist of a full class occurrence and
+// reports the resulting waitlist position. classInfo must be the copy being updated.
+func joinWaitlist(bookingInfo dto.BookingInfo, occurrence time.Time, classInfo dto.ClassInfo) (dto.BookingResult, error) {
+	if slices.Contains(classInfo.Waitlist[occurrence], bookingInfo.MemberID) {
+		return dto.BookingResult{}, newError.ErrAlreadyOnWaitlist
+	}
+	classInfo.Waitlist[occurrence] = append(classInfo.Waitlist[occurrence], bookingInfo.MemberID)
+
+	return dto.BookingResult{
+		Status:           dto.BookingWaitlisted,
+		WaitlistPosition: len(classInfo.Waitlist[occurrence]),
+	}, nil
+}
+
+// bookingDate is the date of a booking request. It is either a calendar date,
+// which is taken in the time zone of the class, or an exact instant given as
+// an RFC 3339 timestamp.
+type bookingDate struct {
+	value   time.Time
+	instant bool
+}
+
+// parseBookingDate accepts a date in the configured layout or an RFC 3339 timestamp.
+func (service *service) parseBookingDate(value string) (bookingDate, error) {
+	if date, err := time.Parse(service.cfg.DateFormat, value); err == nil {
+		return bookingDate{value: date}, nil
+	}
+	if instant, err := time.Parse(time.RFC3339, value); err == nil {
+		return bookingDate{value: instant, instant: true}, nil
+	}
+	return bookingDate{}, newError.ErrInvalidBookingDate
+}
+
+// day returns the calendar date the booking falls on in the time zone of the class.
+func (date bookingDate) day(recurrence *schedule.Recurrence) time.Time {
+	if date.instant {
+		return recurrence.Day(date.value)
+	}
+	return date.value
+}
+
+// resolve returns the occurrence the booking refers to. A timestamp names the
+// local start time of a scheduled class itself, unless bookingTime is given.
+func (date bookingDate) resolve(recurrence *schedule.Recurrence, bookingTime string) (time.Time, error) {
+	if date.instant && bookingTime == "" && recurrence.Timed() {
+		bookingTime = date.value.In(recurrence.Location()).Format(schedule.TimeFormat)
+	}
+	return recurrence.Resolve(date.day(recurrence), bookingTime)
+}
+
+// bookableOccurrence checks that the booking date lies within the class date
+// range, resolves the occurrence being booked and checks that it can be booked now.
+func (service *service) bookableOccurrence(classInfo dto.ClassInfo, date bookingDate, bookingTime string) (time.Time, error) {
+	recurrence, err := schedule.ForClass(classInfo)
+	if err != nil {
+		return time.Time{}, err
+	}
+	day := date.day(recurrence)
+	if day.Before(classInfo.StartDate) || day.After(classInfo.EndDate) {
+		return time.Time{}, newError.ErrBookingDatePassed
+	}
+	// Capacity is tracked per occurrence, so resolve which run of the class is booked
+	occurrence, err := date.resolve(recurrence, bookingTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return occurrence, service.checkBookingWindow(classInfo, occurrence)
+}
+
+// occurrenceOf resolves the class occurrence a booking request refers to.
+// Bookings and waitlists are keyed by the UTC start of that occurrence.
+func occurrenceOf(classInfo dto.ClassInfo, date bookingDate, bookingTime string) (time.Time, error) {
+	recurrence, err := schedule.ForClass(classInfo)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.resolve(recurrence, bookingTime)
+}
+
+// localTime renders t in the time zone of the class.
+func localTime(classInfo dto.ClassInfo, t time.Time) time.Time {
+	loc, err := schedule.LoadLocation(classInfo.Timezone)
+	if err != nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+// promoteWaitlist moves members from the head of the waitlist into the class
+// occurrence for as long as there is free capacity, and returns who was moved.
+func promoteWaitlist(classInfo dto.ClassInfo, date time.Time) []string {
+	var promoted []string
+	for len(classInfo.Waitlist[date]) > 0 && len(classInfo.Bookings[date]) < classInfo.AllowedCapacity {
+		memberID := classInfo.Waitlist[date][0]
+		classInfo.Bookings[date] = append(classInfo.Bookings[date], memberID)
+		removeUser(classInfo.Waitlist, date, memberID)
+		promoted = append(promoted, memberID)
+	}
+	return promoted
+}
+
+// bookingSource validates the channel a booking is made through; none means the API.
+func bookingSource(source string) (string, error) {
+	switch source {
+	case "":
+		return dto.SourceAPI, nil
+	case dto.SourceAPI, dto.SourceWeb, dto.SourceMobile, dto.SourceFrontDesk:
+		return source, nil
+	}
+	return "", newError.ErrInvalidBookingSource
+}
+
+// storeBookingRecord keeps a new booking as a record with its own id.
+func (service *service) storeBookingRecord(bookingInfo dto.BookingInfo, source string, occurrence time.Time, status string) (dto.Booking, error) {
+	id, err := newID("bkg_")
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	record := dto.Booking{
+		ID:         id,
+		MemberID:   bookingInfo.MemberID,
+		PaidBy:     bookingInfo.MemberID,
+		ClassName:  bookingInfo.ClassName,
+		Occurrence: occurrence,
+		Status:     status,
+		Source:     source,
+		CreatedAt:  service.clock.Now().UTC().Truncate(time.Second),
+	}
+	if err = service.repo.StoreBooking(record); err != nil {
+		log.Println("Error: failed to store booking of member", bookingInfo.MemberID, err)
+		return dto.Booking{}, err
+	}
+	return record, nil
+}
+
+// dropBooking takes a member back out of a class occurrence, handing a freed
+// slot to the waitlist. It is used to undo a booking that could not be recorded.
+func (service *service) dropBooking(className string, occurrence time.Time, memberID string) {
+	var promoted []string
+	_, err := service.repo.UpdateClass(className, func(classInfo dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if !exist {
+			return classInfo, newError.ErrClassNotExist
+		}
+		if !removeUser(classInfo.Bookings, occurrence, memberID) {
+			removeUser(classInfo.Waitlist, occurrence, memberID)
+		}
+		promoted = promoteWaitlist(classInfo, occurrence)
+		return classInfo, nil
+	})
+	if err != nil {
+		log.Println("Error: failed to drop booking of member", memberID, err)
+		return
+	}
+	service.syncBookingStatus(className, appendAffected(nil, occurrence, promoted), dto.BookingConfirmed)
+}
+
+// syncBookingStatus sets the status of the active booking records of the
+// affected members. Occurrences must still be in UTC, as the records are looked
+// up by them. The class itself is the source of truth, so failures are only logged.
+func (service *service) syncBookingStatus(className string, affected []dto.AffectedBooking, status string) {
+	for _, booking := range affected {
+		record, exist, err := service.repo.FindBooking(className, booking.Occurrence, booking.MemberID)
+		if err == nil && exist {
+			_, err = service.repo.UpdateBooking(record.ID, func(current dto.Booking, exists bool) (dto.Booking, error) {
+				if !exists {
+					return current, newError.ErrBookingNotExist
+				}
+				current.Status = status
+				return current, nil
+			})
+		}
+		if err != nil {
+			log.Println("Error: failed to update booking of member", booking.MemberID, err)
+		}
+	}
+}
+
+// removeUser deletes the first occurrence of userName from the entries of the
+// given date, dropping the date entirely once it is empty. It reports whether
+// the user was found.
+func removeUser(entries map[time.Time][]string, date time.Time, userName string) bool {
+	index := slices.Index(entries[date], userName)
+	if index < 0 {
+		return false
+	}
+	remaining := slices.Delete(entries[date], index, index+1)
+	if len(remaining) == 0 {
+		delete(entries, date)
+	} else {
+		entries[date] = remaining
+	}
+	return true
 }
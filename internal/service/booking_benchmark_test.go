@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"glofox/config"
+	mapstore "glofox/core"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+)
+
+// benchmarkClasses is the number of classes bookings are spread across, and
+// benchmarkMembers the number of members booking them.
+const (
+	benchmarkClasses = 256
+	benchmarkMembers = 8192
+)
+
+// globalLockService reproduces the former concurrency model, where every
+// booking in the server was serialised behind one shared mutex.
+type globalLockService struct {
+	service.BusinessService
+	lock sync.Mutex
+}
+
+func (s *globalLockService) CreateBooking(bookingInfo dto.BookingInfo) (dto.BookingResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.BusinessService.CreateBooking(bookingInfo)
+}
+
+func (s *globalLockService) CancelBooking(bookingInfo dto.BookingInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.BusinessService.CancelBooking(bookingInfo)
+}
+
+// newBenchmarkService seeds a store with benchmarkClasses scheduled classes and
+// benchmarkMembers active members on unlimited plans, and returns a service on top of it.
+func newBenchmarkService(b *testing.B, store mapstore.MapStore, cfg config.Config) service.BusinessService {
+	repo := repository.NewMapRepository(store)
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	for i := 0; i < benchmarkClasses; i++ {
+		err := repo.StoreClass(fmt.Sprintf("class_%d", i), dto.ClassInfo{
+			AllowedCapacity: 1000,
+			StartDate:       start,
+			EndDate:         start.AddDate(0, 3, 0),
+			Schedule:        &dto.Schedule{Weekdays: []string{"MO", "WE", "FR"}, StartTime: "07:00", DurationMinutes: 60},
+			Bookings:        make(map[time.Time][]string),
+			Waitlist:        make(map[time.Time][]string),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	for i := 0; i < benchmarkMembers; i++ {
+		id := fmt.Sprintf("member_%d", i)
+		if err := repo.StoreMember(dto.Member{ID: id, Name: id, Status: dto.MemberActive}); err != nil {
+			b.Fatal(err)
+		}
+		_, err := repo.UpdateCredits(id, func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+			account.Grants = append(account.Grants, dto.CreditGrant{
+				ID:        "grant_" + id,
+				Unlimited: true,
+				ValidFrom: start,
+				ExpiresAt: start.AddDate(1, 0, 0),
+			})
+			return account, nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return service.InitializeService(repo, cfg)
+}
+
+// BenchmarkCreateBooking_ParallelClasses books and cancels members in parallel
+// across many classes, comparing the global lock with per-shard locking.
+// Run it with: go test ./internal/service -run '^$' -bench ParallelClasses -cpu 1,4,8
+func BenchmarkCreateBooking_ParallelClasses(b *testing.B) {
+	cfg := config.Config{
+		DateFormat: "2006-01-02",
+	}
+	variants := []struct {
+		name string
+		open func(b *testing.B) service.BusinessService
+	}{
+		{
+			name: "global-lock",
+			open: func(b *testing.B) service.BusinessService {
+				return &globalLockService{BusinessService: newBenchmarkService(b, mapstore.NewShardedMapStore(1), cfg)}
+			},
+		},
+		{
+			name: "single-shard",
+			open: func(b *testing.B) service.BusinessService {
+				return newBenchmarkService(b, mapstore.NewShardedMapStore(1), cfg)
+			},
+		},
+		{
+			name: "sharded",
+			open: func(b *testing.B) service.BusinessService {
+				return newBenchmarkService(b, mapstore.NewShardedMapStore(mapstore.DefaultShards), cfg)
+			},
+		},
+	}
+
+	// Every class runs on Mondays, so book the first Monday of the range
+	date := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	for date.Weekday() != time.Monday {
+		date = date.AddDate(0, 0, 1)
+	}
+
+	for _, variant := range variants {
+		b.Run(variant.name, func(b *testing.B) {
+			svc := variant.open(b)
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := next.Add(1)
+					bookingInfo := dto.BookingInfo{
+						ClassName:   fmt.Sprintf("class_%d", n%benchmarkClasses),
+						MemberID:    fmt.Sprintf("member_%d", n%benchmarkMembers),
+						BookingDate: date.Format(cfg.DateFormat),
+					}
+					if _, err := svc.CreateBooking(bookingInfo); err != nil {
+						b.Error(err)
+						return
+					}
+					if err := svc.CancelBooking(bookingInfo); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -1,240 +1,793 @@
 package service_test
 
 import (
+	"fmt"
 	"glofox/config"
 	newError "glofox/errors"
+	"glofox/internal/repository"
 	"glofox/internal/service"
 	"glofox/models/dto"
-
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-type MockMapStore struct {
-	mock.Mock
-}
-
-func (m *MockMapStore) Load(key string) (value interface{}, ok bool) {
-	args := m.Called(key)
-	return args.Get(0), args.Bool(1)
+// registerMember adds active members with the given ids to the repository,
+// each holding an unlimited plan so that credits never get in the way.
+func registerMember(t *testing.T, repo repository.Repository, ids ...string) {
+	for _, id := range ids {
+		require.NoError(t, repo.StoreMember(dto.Member{ID: id, Name: id, Status: dto.MemberActive}))
+		_, err := repo.UpdateCredits(id, func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+			account.Grants = append(account.Grants, dto.CreditGrant{
+				ID:        "grant_" + id,
+				PlanID:    "plan_unlimited",
+				PlanName:  "Unlimited",
+				Unlimited: true,
+				ValidFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+				ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+			})
+			return account, nil
+		})
+		require.NoError(t, err)
+	}
 }
 
-func (m *MockMapStore) Store(key string, value interface{}) {
-	m.Called(key, value)
-}
-func (m *MockMapStore) Delete(key string) {
-	m.Delete(key)
+// loadClass reads a class back from the repository, failing the test if it is missing.
+func loadClass(t *testing.T, repo repository.Repository, name string) dto.ClassInfo {
+	info, exist, err := repo.LoadClass(name)
+	require.NoError(t, err)
+	require.True(t, exist)
+	return info
 }
 
 func TestInitializeService(t *testing.T) {
-	mockMapStore := new(MockMapStore)
-
-	mockMutex := &sync.Mutex{}
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	svc := service.InitializeService(mockMapStore, mockMutex, cfg)
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
 
-	// Ensure the service is initialized correctly
-	assert.NotNil(t, svc)
+		// Ensure the service is initialized correctly
+		assert.NotNil(t, svc)
+	})
 }
 
 func TestCreateBooking_ValidBooking(t *testing.T) {
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	// Prepare valid booking info
-	bookingInfo := dto.BookingInfo{
-		UserName:    "john_doe",
-		BookingDate: time.Now().Format(cfg.DateFormat),
-		ClassName:   "YogaClass",
-	}
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
 
-	bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Prepare valid booking info
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Add(24 * time.Hour).Format(cfg.DateFormat),
+			ClassName:   "YogaClass",
+		}
 
-	// Mock ClassInfo data for a valid class
-	classInfo := dto.ClassInfo{
-		StartDate:       time.Now().Add(-24 * time.Hour), // Class starts 1 day ago
-		EndDate:         time.Now().Add(24 * time.Hour),  // Class ends in 1 day
-		AllowedCapacity: 5,
-		Bookings:        make(map[time.Time][]string), // Booked slots will be stored in a map of time -> slice of bookings
-	}
+		bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
 
-	// Set up mock MapStore to return classInfo when YogaClass is loaded
-	mockMapStore := new(MockMapStore)
-	mockMapStore.On("Load", "YogaClass").Return(classInfo, true).Once() // Expect Load to be called once
-	mockMapStore.On("Store", "YogaClass", mock.MatchedBy(func(v interface{}) bool {
-		// Check that the value being passed to Store is correct
-		if bookings, ok := v.(dto.ClassInfo); ok {
-			return len(bookings.Bookings[bookingDate]) == 1 // Ensure the booking is correctly added
+		// ClassInfo data for a valid class
+		classInfo := dto.ClassInfo{
+			StartDate:       time.Now().Add(-24 * time.Hour), // Class starts 1 day ago
+			EndDate:         time.Now().Add(24 * time.Hour),  // Class ends in 1 day
+			AllowedCapacity: 5,
+			Bookings:        make(map[time.Time][]string), // Booked slots will be stored in a map of time -> slice of bookings
 		}
-		return false
-	})).Once() // Expect Store to be called once
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
 
-	mockMutex := &sync.Mutex{}
-	svc := service.InitializeService(mockMapStore, mockMutex, cfg)
+		svc := service.InitializeService(repo, cfg)
 
-	err := svc.CreateBooking(bookingInfo)
+		result, err := svc.CreateBooking(bookingInfo)
 
-	// Assert that there is no error for valid booking
-	assert.NoError(t, err)
+		// Assert that there is no error for valid booking
+		assert.NoError(t, err)
+		assert.Equal(t, dto.BookingConfirmed, result.Status)
 
-	// Assert Store method was called with correct arguments
-	mockMapStore.AssertExpectations(t)
+		// Assert that the booking was stored against the date
+		assert.Equal(t, []string{"john_doe"}, loadClass(t, repo, "YogaClass").Bookings[bookingDate])
+	})
 }
 
 func TestCreateBooking_InvalidDateFormat(t *testing.T) {
-	// Invalid booking date format
-	bookingInfo := dto.BookingInfo{
-		UserName:    "john_doe",
-		BookingDate: "invalid_date", // Invalid date format
-		ClassName:   "YogaClass",
-	}
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	mockMapStore := new(MockMapStore)
-	mockMutex := &sync.Mutex{}
-	svc := service.InitializeService(mockMapStore, mockMutex, cfg)
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		// Invalid booking date format
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: "invalid_date", // Invalid date format
+			ClassName:   "YogaClass",
+		}
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
 
-	err := svc.CreateBooking(bookingInfo)
+		_, err := svc.CreateBooking(bookingInfo)
 
-	// Assert that the error returned is due to the invalid date format
-	assert.Error(t, err)
+		// Assert that the error returned is due to the invalid date format
+		assert.Error(t, err)
+	})
 }
 
 func TestCreateBooking_ClassNotExist(t *testing.T) {
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	// Booking info for a class that doesn't exist
-	bookingInfo := dto.BookingInfo{
-		UserName:    "john_doe",
-		BookingDate: time.Now().Format(cfg.DateFormat),
-		ClassName:   "NonExistentClass", // Class that doesn't exist
-	}
-
-	mockMapStore := new(MockMapStore)
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
 
-	mockMapStore.On("Load", "NonExistentClass").Return(nil, false).Once() // Simulate class not found
-	mockMutex := &sync.Mutex{}
-	svc := service.InitializeService(mockMapStore, mockMutex, cfg)
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Booking info for a class that doesn't exist
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Format(cfg.DateFormat),
+			ClassName:   "NonExistentClass", // Class that doesn't exist
+		}
 
-	err := svc.CreateBooking(bookingInfo)
+		svc := service.InitializeService(repo, cfg)
 
-	// Assert that the error returned is ErrClassNotExist
-	assert.Equal(t, err, newError.ErrClassNotExist)
+		_, err := svc.CreateBooking(bookingInfo)
 
-	// Assert Load was called with the correct class name
-	mockMapStore.AssertExpectations(t)
+		// Assert that the error returned is ErrClassNotExist
+		assert.Equal(t, err, newError.ErrClassNotExist)
+	})
 }
 
 func TestCreateBooking_BookingDateBeforeClassStart(t *testing.T) {
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	// Booking date is before the class start date
-	bookingInfo := dto.BookingInfo{
-		UserName:    "john_doe",
-		BookingDate: time.Now().Format(cfg.DateFormat), // Booking date before class start date
-		ClassName:   "YogaClass",
-	}
-
-	// Class starts in 2 days, ends in 3 days
-	classInfo := dto.ClassInfo{
-		StartDate:       time.Now().Add(48 * time.Hour), // Class starts in 2 days
-		EndDate:         time.Now().Add(72 * time.Hour), // Class ends in 3 days
-		AllowedCapacity: 5,
-		Bookings:        make(map[time.Time][]string),
-	}
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
 
-	// Set up mock map store
-	mockMapStore := new(MockMapStore)
-	mockMapStore.On("Load", "YogaClass").Return(classInfo, true).Once() // Expect the class to be loaded
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Booking date is before the class start date
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Format(cfg.DateFormat), // Booking date before class start date
+			ClassName:   "YogaClass",
+		}
 
-	// Mutex and service setup
-	mockMutex := &sync.Mutex{}
-	svc := service.InitializeService(mockMapStore, mockMutex, cfg)
+		// Class starts in 2 days, ends in 3 days
+		classInfo := dto.ClassInfo{
+			StartDate:       time.Now().Add(48 * time.Hour), // Class starts in 2 days
+			EndDate:         time.Now().Add(72 * time.Hour), // Class ends in 3 days
+			AllowedCapacity: 5,
+			Bookings:        make(map[time.Time][]string),
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
 
-	// Run the service method
-	err := svc.CreateBooking(bookingInfo)
+		// Mutex and service setup
+		svc := service.InitializeService(repo, cfg)
 
-	// Assert that the error returned is ErrBookingDatePassed (booking before class starts)
-	assert.Equal(t, err, newError.ErrBookingDatePassed)
+		// Run the service method
+		_, err := svc.CreateBooking(bookingInfo)
 
-	// Assert expectations on mock MapStore
-	mockMapStore.AssertExpectations(t)
+		// Assert that the error returned is ErrBookingDatePassed (booking before class starts)
+		assert.Equal(t, err, newError.ErrBookingDatePassed)
+	})
 }
 
 func TestCreateBooking_BookingDateAfterClassEnd(t *testing.T) {
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	// Booking date is after the class end date
-	bookingInfo := dto.BookingInfo{
-		UserName:    "john_doe",
-		BookingDate: time.Now().Add(48 * time.Hour).Format(cfg.DateFormat), // Booking date after class end date
-		ClassName:   "YogaClass",
-	}
-
-	classInfo := dto.ClassInfo{
-		StartDate:       time.Now().Add(-48 * time.Hour), // Class starts 2 days ago
-		EndDate:         time.Now().Add(24 * time.Hour),  // Class ends in 1 day
-		AllowedCapacity: 5,
-		Bookings:        make(map[time.Time][]string),
-	}
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
 
-	mockMapStore := new(MockMapStore)
-	mockMapStore.On("Load", "YogaClass").Return(classInfo, true).Once()
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Booking date is after the class end date
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Add(48 * time.Hour).Format(cfg.DateFormat), // Booking date after class end date
+			ClassName:   "YogaClass",
+		}
 
-	mockMutex := &sync.Mutex{}
-	svc := service.InitializeService(mockMapStore, mockMutex, cfg)
+		classInfo := dto.ClassInfo{
+			StartDate:       time.Now().Add(-48 * time.Hour), // Class starts 2 days ago
+			EndDate:         time.Now().Add(24 * time.Hour),  // Class ends in 1 day
+			AllowedCapacity: 5,
+			Bookings:        make(map[time.Time][]string),
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
 
-	err := svc.CreateBooking(bookingInfo)
+		svc := service.InitializeService(repo, cfg)
 
-	// Assert that the error returned is ErrBookingDatePassed (booking after class ends)
-	assert.Equal(t, err, newError.ErrBookingDatePassed)
+		_, err := svc.CreateBooking(bookingInfo)
 
-	mockMapStore.AssertExpectations(t)
+		// Assert that the error returned is ErrBookingDatePassed (booking after class ends)
+		assert.Equal(t, err, newError.ErrBookingDatePassed)
+	})
 }
 
 func TestCreateBooking_SlotsFullForTheDate(t *testing.T) {
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	// Test when slots are full for the given date
-	bookingInfo := dto.BookingInfo{
-		UserName:    "john_doe",
-		BookingDate: time.Now().Format(cfg.DateFormat), // Valid date format
-		ClassName:   "YogaClass",
-	}
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
 
-	bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
-	timeNow := time.Now()
-	date := time.Date(timeNow.Year(), timeNow.Month(), timeNow.Day(), 0, 0, 0, 0, time.UTC)
-	// Class with one slot, and already full for the day
-	classInfo := dto.ClassInfo{
-		StartDate:       date.Add(-24 * time.Hour),
-		EndDate:         time.Now().Add(24 * time.Hour),
-		AllowedCapacity: 1,
-		Bookings:        make(map[time.Time][]string),
-	}
-	// Pre-add a booking on the date
-	classInfo.Bookings[bookingDate] = append(classInfo.Bookings[bookingDate], "existing_user")
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Test when slots are full for the given date
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Add(24 * time.Hour).Format(cfg.DateFormat), // Tomorrow, while bookings are open
+			ClassName:   "YogaClass",
+		}
+
+		bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
+		timeNow := time.Now()
+		date := time.Date(timeNow.Year(), timeNow.Month(), timeNow.Day(), 0, 0, 0, 0, time.UTC)
+		// Class with one slot, and already full for the day
+		classInfo := dto.ClassInfo{
+			StartDate:       date.Add(-24 * time.Hour),
+			EndDate:         time.Now().Add(24 * time.Hour),
+			AllowedCapacity: 1,
+			Bookings:        make(map[time.Time][]string),
+		}
+		// Pre-add a booking on the date
+		classInfo.Bookings[bookingDate] = append(classInfo.Bookings[bookingDate], "existing_user")
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+		_, err := svc.CreateBooking(bookingInfo)
+
+		// Assert that the error returned is ErrSlotsFullForTheDate
+		assert.Equal(t, err, newError.ErrSlotsFullForTheDate)
+	})
+}
+
+func TestCancelBooking_ValidCancellation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Booking info for an existing booking tomorrow
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Add(24 * time.Hour).Format(cfg.DateFormat),
+			ClassName:   "YogaClass",
+		}
+
+		bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
+
+		classInfo := dto.ClassInfo{
+			StartDate:       time.Now().Add(-24 * time.Hour),
+			EndDate:         time.Now().Add(48 * time.Hour),
+			AllowedCapacity: 5,
+			Bookings:        map[time.Time][]string{bookingDate: {"jane_doe", "john_doe"}},
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+
+		err := svc.CancelBooking(bookingInfo)
+
+		// Assert that there is no error for a valid cancellation
+		assert.NoError(t, err)
+
+		// Check that only the cancelling user was removed from the date
+		assert.Equal(t, []string{"jane_doe"}, loadClass(t, repo, "YogaClass").Bookings[bookingDate])
+	})
+}
+
+func TestCancelBooking_ClassNotExist(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Format(cfg.DateFormat),
+			ClassName:   "NonExistentClass",
+		}
+
+		svc := service.InitializeService(repo, cfg)
+
+		err := svc.CancelBooking(bookingInfo)
+
+		// Assert that the error returned is ErrClassNotExist
+		assert.Equal(t, newError.ErrClassNotExist, err)
+	})
+}
+
+func TestCancelBooking_BookingNotExist(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// The user holds no booking on the requested date
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Format(cfg.DateFormat),
+			ClassName:   "YogaClass",
+		}
+
+		classInfo := dto.ClassInfo{
+			StartDate:       time.Now().Add(-24 * time.Hour),
+			EndDate:         time.Now().Add(24 * time.Hour),
+			AllowedCapacity: 5,
+			Bookings:        make(map[time.Time][]string),
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+
+		err := svc.CancelBooking(bookingInfo)
+
+		// Assert that the error returned is ErrBookingNotExist
+		assert.Equal(t, newError.ErrBookingNotExist, err)
+	})
+}
+
+func TestCancelBooking_ClassDatePassed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Booking made for a date that is already over
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Add(-48 * time.Hour).Format(cfg.DateFormat),
+			ClassName:   "YogaClass",
+		}
+
+		bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
 
-	mockMapStore := new(MockMapStore)
-	mockMapStore.On("Load", "YogaClass").Return(classInfo, true).Once()
+		classInfo := dto.ClassInfo{
+			StartDate:       time.Now().Add(-72 * time.Hour),
+			EndDate:         time.Now().Add(24 * time.Hour),
+			AllowedCapacity: 5,
+			Bookings:        map[time.Time][]string{bookingDate: {"john_doe"}},
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+
+		err := svc.CancelBooking(bookingInfo)
+
+		// Assert that the error returned is ErrCancellationDatePassed
+		assert.Equal(t, newError.ErrCancellationDatePassed, err)
+	})
+}
+
+func TestCreateBooking_JoinWaitlistWhenFull(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
+
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Full class date, but the user asks to be waitlisted
+		bookingInfo := dto.BookingInfo{
+			MemberID:     "john_doe",
+			BookingDate:  time.Now().Add(24 * time.Hour).Format(cfg.DateFormat),
+			ClassName:    "YogaClass",
+			JoinWaitlist: true,
+		}
+
+		bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
+		classInfo := dto.ClassInfo{
+			StartDate:       bookingDate.Add(-24 * time.Hour),
+			EndDate:         bookingDate.Add(24 * time.Hour),
+			AllowedCapacity: 1,
+			Bookings:        map[time.Time][]string{bookingDate: {"existing_user"}},
+			Waitlist:        map[time.Time][]string{bookingDate: {"first_waiting"}},
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+		result, err := svc.CreateBooking(bookingInfo)
+
+		// Assert that the user is waitlisted at position 2
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.BookingID)
+		result.BookingID = ""
+		assert.Equal(t, dto.BookingResult{Status: dto.BookingWaitlisted, Occurrence: bookingDate, WaitlistPosition: 2}, result)
+
+		// Check that the user was appended to the waitlist, not the bookings
+		stored := loadClass(t, repo, "YogaClass")
+		assert.Len(t, stored.Bookings[bookingDate], 1)
+		assert.Equal(t, []string{"first_waiting", "john_doe"}, stored.Waitlist[bookingDate])
+	})
+}
+
+func TestCreateBooking_AlreadyOnWaitlist(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
+
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		bookingInfo := dto.BookingInfo{
+			MemberID:     "john_doe",
+			BookingDate:  time.Now().Add(24 * time.Hour).Format(cfg.DateFormat),
+			ClassName:    "YogaClass",
+			JoinWaitlist: true,
+		}
+
+		bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
+		classInfo := dto.ClassInfo{
+			StartDate:       bookingDate,
+			EndDate:         bookingDate,
+			AllowedCapacity: 1,
+			Bookings:        map[time.Time][]string{bookingDate: {"existing_user"}},
+			Waitlist:        map[time.Time][]string{bookingDate: {"john_doe"}},
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+		_, err := svc.CreateBooking(bookingInfo)
+
+		// Assert that the error returned is ErrAlreadyOnWaitlist
+		assert.Equal(t, newError.ErrAlreadyOnWaitlist, err)
+	})
+}
+
+func TestCancelBooking_PromotesFirstWaitlisted(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		bookingInfo := dto.BookingInfo{
+			MemberID:    "john_doe",
+			BookingDate: time.Now().Add(24 * time.Hour).Format(cfg.DateFormat),
+			ClassName:   "YogaClass",
+		}
+
+		bookingDate, _ := time.Parse(cfg.DateFormat, bookingInfo.BookingDate)
+		classInfo := dto.ClassInfo{
+			StartDate:       bookingDate,
+			EndDate:         bookingDate,
+			AllowedCapacity: 1,
+			Bookings:        map[time.Time][]string{bookingDate: {"john_doe"}},
+			Waitlist:        map[time.Time][]string{bookingDate: {"jane_doe", "max_doe"}},
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+
+		err := svc.CancelBooking(bookingInfo)
+		assert.NoError(t, err)
+
+		// Check that the head of the waitlist took the freed slot
+		stored := loadClass(t, repo, "YogaClass")
+		assert.Equal(t, []string{"jane_doe"}, stored.Bookings[bookingDate])
+		assert.Equal(t, []string{"max_doe"}, stored.Waitlist[bookingDate])
+	})
+}
+
+func TestGetWaitlistPosition(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		bookingDate, _ := time.Parse(cfg.DateFormat, "2025-06-01")
+		classInfo := dto.ClassInfo{
+			StartDate:       bookingDate,
+			EndDate:         bookingDate,
+			AllowedCapacity: 1,
+			Bookings:        map[time.Time][]string{bookingDate: {"existing_user"}},
+			Waitlist:        map[time.Time][]string{bookingDate: {"jane_doe", "john_doe"}},
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+
+		position, err := svc.GetWaitlistPosition(dto.BookingInfo{ClassName: "YogaClass", MemberID: "john_doe", BookingDate: "2025-06-01"})
+
+		// Assert that the second waitlisted user is reported at position 2
+		assert.NoError(t, err)
+		assert.Equal(t, 2, position)
+
+		_, err = svc.GetWaitlistPosition(dto.BookingInfo{ClassName: "YogaClass", MemberID: "unknown", BookingDate: "2025-06-01"})
+
+		// Assert that a user who is not waitlisted gets ErrNotOnWaitlist
+		assert.Equal(t, newError.ErrNotOnWaitlist, err)
+	})
+}
 
-	mockMutex := &sync.Mutex{}
-	svc := service.InitializeService(mockMapStore, mockMutex, cfg)
-	err := svc.CreateBooking(bookingInfo)
+func TestCreateBooking_ScheduledOccurrence(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
 
-	// Assert that the error returned is ErrSlotsFullForTheDate
-	assert.Equal(t, err, newError.ErrSlotsFullForTheDate)
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		// Class running Mondays at 07:00 during June 2025
+		startDate, _ := time.Parse(cfg.DateFormat, "2025-06-01")
+		endDate, _ := time.Parse(cfg.DateFormat, "2025-06-30")
+		classInfo := dto.ClassInfo{
+			StartDate:       startDate,
+			EndDate:         endDate,
+			AllowedCapacity: 5,
+			Schedule:        &dto.Schedule{Weekdays: []string{"MO"}, StartTime: "07:00", DurationMinutes: 60},
+			Bookings:        make(map[time.Time][]string),
+		}
+		require.NoError(t, repo.StoreClass("Pilates", classInfo))
+		occurrence := time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC)
+
+		svc := service.InitializeServiceWithClock(repo, cfg, fixedClock(startDate))
+
+		_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Pilates", MemberID: "john_doe", BookingDate: "2025-06-02", BookingTime: "07:00"})
+		assert.NoError(t, err)
+
+		// Check that the booking is tracked against the occurrence start
+		assert.Len(t, loadClass(t, repo, "Pilates").Bookings[occurrence], 1)
+
+		// A Tuesday falls inside the date range but is not an occurrence
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Pilates", MemberID: "john_doe", BookingDate: "2025-06-03"})
+		assert.Equal(t, newError.ErrNoClassOccurrence, err)
+	})
+}
+
+func TestCreateBooking_StudioTimezone(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+
+		// The studio runs in UTC+10, so a 07:00 class starts at 21:00 UTC the day before
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+			Timezone:   "Australia/Brisbane",
+		}
+		svc := service.InitializeService(repo, cfg)
+		require.NoError(t, svc.CreateClass(dto.Class{
+			Name:      "Sunrise",
+			Capacity:  5,
+			StartDate: "2030-06-03",
+			EndDate:   "2030-06-03",
+			Schedule:  &dto.Schedule{StartTime: "07:00", DurationMinutes: 60},
+		}))
+		occurrence := time.Date(2030, 6, 2, 21, 0, 0, 0, time.UTC)
+
+		// A date-only booking is taken in the studio time zone
+		result, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Sunrise", MemberID: "john_doe", BookingDate: "2030-06-03"})
+		assert.NoError(t, err)
+		assert.Equal(t, "2030-06-03T07:00:00+10:00", result.Occurrence.Format(time.RFC3339))
+
+		// An RFC 3339 timestamp in any offset names the same occurrence
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Sunrise", MemberID: "jane_doe", BookingDate: "2030-06-02T21:00:00Z"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"john_doe", "jane_doe"}, loadClass(t, repo, "Sunrise").Bookings[occurrence])
+
+		// A timestamp that is not the start of an occurrence is refused
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Sunrise", MemberID: "jane_doe", BookingDate: "2030-06-03T08:00:00+10:00"})
+		assert.Equal(t, newError.ErrNoClassOccurrence, err)
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Sunrise", MemberID: "jane_doe", BookingDate: "03/06/2030"})
+		assert.Equal(t, newError.ErrInvalidBookingDate, err)
+
+		// Details are rendered in local time
+		details, err := svc.GetClass("Sunrise")
+		assert.NoError(t, err)
+		assert.Equal(t, "Australia/Brisbane", details.Timezone)
+		assert.Equal(t, "2030-06-03", details.Availability[0].Date)
+		assert.Equal(t, "07:00", details.Availability[0].StartTime)
+		assert.Equal(t, 2, details.Availability[0].Booked)
+	})
+}
+
+func TestCreateClass_InvalidTimezone(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		err := svc.CreateClass(dto.Class{Name: "Yoga", Capacity: 5, StartDate: "2030-06-03", EndDate: "2030-06-03", Timezone: "Mars/Olympus_Mons"})
+		assert.ErrorIs(t, err, newError.ErrInvalidTimezone)
+	})
+}
+
+func TestCreateBooking_ConcurrentBookingsNeverExceedCapacity(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		date := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+		classInfo := dto.ClassInfo{
+			StartDate:       date,
+			EndDate:         date,
+			AllowedCapacity: 3,
+			Bookings:        make(map[time.Time][]string),
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+		for i := 0; i < 10; i++ {
+			registerMember(t, repo, fmt.Sprintf("member_%d", i))
+		}
+
+		// Race more members than there are spots for the same date
+		var wg sync.WaitGroup
+		results := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := svc.CreateBooking(dto.BookingInfo{
+					MemberID:    fmt.Sprintf("member_%d", i),
+					BookingDate: date.Format(cfg.DateFormat),
+					ClassName:   "YogaClass",
+				})
+				results <- err
+			}(i)
+		}
+		wg.Wait()
+		close(results)
+
+		// Assert that exactly the capacity was confirmed and the rest were turned away
+		confirmed := 0
+		for err := range results {
+			if err == nil {
+				confirmed++
+			} else {
+				assert.Equal(t, newError.ErrSlotsFullForTheDate, err)
+			}
+		}
+		assert.Equal(t, 3, confirmed)
+		assert.Len(t, loadClass(t, repo, "YogaClass").Bookings[date], 3)
+	})
+}
+
+func TestCreateBooking_MemberRules(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		date := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+		classInfo := dto.ClassInfo{
+			StartDate:       date,
+			EndDate:         date,
+			AllowedCapacity: 5,
+			Bookings:        make(map[time.Time][]string),
+		}
+		require.NoError(t, repo.StoreClass("YogaClass", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+		bookingInfo := dto.BookingInfo{
+			ClassName:   "YogaClass",
+			BookingDate: date.Format(cfg.DateFormat),
+		}
+
+		// Unknown members can not book
+		bookingInfo.MemberID = "mem_unknown"
+		_, err := svc.CreateBooking(bookingInfo)
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+
+		// Suspended members can not book
+		suspended, err := svc.CreateMember(dto.MemberInfo{Name: "Jane Doe", Status: dto.MemberSuspended})
+		require.NoError(t, err)
+		bookingInfo.MemberID = suspended.ID
+		_, err = svc.CreateBooking(bookingInfo)
+		assert.Equal(t, newError.ErrMemberNotActive, err)
+
+		// An active member can book once, but not twice for the same occurrence
+		active, err := svc.CreateMember(dto.MemberInfo{Name: "John Doe"})
+		require.NoError(t, err)
+		plan, err := svc.CreatePlan(dto.PlanInfo{Name: "Unlimited", Type: dto.PlanUnlimited, ValidDays: 30})
+		require.NoError(t, err)
+		_, err = svc.PurchasePlan(active.ID, dto.PurchaseInfo{PlanID: plan.ID})
+		require.NoError(t, err)
+		bookingInfo.MemberID = active.ID
+		_, err = svc.CreateBooking(bookingInfo)
+		assert.NoError(t, err)
+		_, err = svc.CreateBooking(bookingInfo)
+		assert.Equal(t, newError.ErrDuplicateBooking, err)
+
+		assert.Equal(t, []string{active.ID}, loadClass(t, repo, "YogaClass").Bookings[date])
+	})
+}
+
+func TestCreateBooking_RecordsBooking(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		policyClass(t, repo, nil)
+
+		now := time.Date(2030, 6, 15, 9, 0, 0, 0, time.UTC)
+		svc := service.InitializeServiceWithClock(repo, config.Config{DateFormat: "2006-01-02"}, fixedClock(now))
+
+		// The booking gets an id under which it can be fetched
+		result, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15", Source: dto.SourceMobile})
+		require.NoError(t, err)
+		require.NotEmpty(t, result.BookingID)
+
+		booking, err := svc.GetBooking(result.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.Booking{
+			ID:         result.BookingID,
+			MemberID:   "john_doe",
+			PaidBy:     "john_doe",
+			ClassName:  "Spin",
+			Occurrence: time.Date(2030, 6, 15, 18, 0, 0, 0, time.UTC),
+			Status:     dto.BookingConfirmed,
+			Source:     dto.SourceMobile,
+			CreatedAt:  now,
+		}, booking)
+
+		// Bookings that do not name a channel come from the API
+		result, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-15"})
+		require.NoError(t, err)
+		booking, err = svc.GetBooking(result.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.SourceAPI, booking.Source)
+
+		_, err = svc.GetBooking("bkg_unknown")
+		assert.Equal(t, newError.ErrBookingNotExist, err)
+	})
+}
+
+func TestCreateBooking_InvalidSource(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
+		policyClass(t, repo, nil)
+
+		svc := service.InitializeServiceWithClock(repo, config.Config{DateFormat: "2006-01-02"}, fixedClock(time.Date(2030, 6, 15, 9, 0, 0, 0, time.UTC)))
+		_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15", Source: "fax"})
+		assert.Equal(t, newError.ErrInvalidBookingSource, err)
+
+		// Nothing was booked
+		assert.Empty(t, loadClass(t, repo, "Spin").Bookings)
+	})
+}
+
+func TestCancelBookingByID(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		svc := service.InitializeServiceWithClock(repo, config.Config{DateFormat: "2006-01-02"}, fixedClock(time.Date(2030, 6, 15, 9, 0, 0, 0, time.UTC)))
+		require.NoError(t, svc.CreateClass(dto.Class{Name: "Spin", Capacity: 1, StartDate: "2030-06-01", EndDate: "2030-06-30",
+			Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}}))
+
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15"})
+		require.NoError(t, err)
+		waiting, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-15", JoinWaitlist: true})
+		require.NoError(t, err)
+
+		// Cancelling by id frees the place for the waitlisted member
+		cancelled, err := svc.CancelBookingByID(booked.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingCancelled, cancelled.Status)
+		assert.Equal(t, booked.BookingID, cancelled.ID)
+		promoted, err := svc.GetBooking(waiting.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingConfirmed, promoted.Status)
+		assert.Equal(t, []string{"jane_doe"}, loadClass(t, repo, "Spin").Bookings[booked.Occurrence.UTC()])
+
+		// A booking can only be cancelled once, and unknown ids are not found
+		_, err = svc.CancelBookingByID(booked.BookingID)
+		assert.Equal(t, newError.ErrBookingNotExist, err)
+		_, err = svc.CancelBookingByID("bkg_unknown")
+		assert.Equal(t, newError.ErrBookingNotExist, err)
+	})
+}
 
-	mockMapStore.AssertExpectations(t)
+func TestCancelBooking_UpdatesBookingStatus(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		svc := service.InitializeServiceWithClock(repo, config.Config{DateFormat: "2006-01-02"}, fixedClock(time.Date(2030, 6, 15, 9, 0, 0, 0, time.UTC)))
+		require.NoError(t, svc.CreateClass(dto.Class{Name: "Spin", Capacity: 1, StartDate: "2030-06-01", EndDate: "2030-06-30",
+			Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}}))
+
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15"})
+		require.NoError(t, err)
+		waiting, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-15", JoinWaitlist: true})
+		require.NoError(t, err)
+
+		booking, err := svc.GetBooking(waiting.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingWaitlisted, booking.Status)
+
+		// Cancelling frees the place for the waitlisted member
+		require.NoError(t, svc.CancelBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15"}))
+
+		booking, err = svc.GetBooking(booked.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingCancelled, booking.Status)
+		booking, err = svc.GetBooking(waiting.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingConfirmed, booking.Status)
+
+		// Deleting the class cancels the booking, whose record outlives the class
+		_, err = svc.DeleteClass("Spin", true)
+		require.NoError(t, err)
+		booking, err = svc.GetBooking(waiting.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingCancelled, booking.Status)
+	})
 }
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"glofox/internal/ical"
+	"glofox/internal/repository"
+	"glofox/internal/schedule"
+	"glofox/models/dto"
+	"log"
+	"net/url"
+	"time"
+)
+
+// calendarDomain ends the UIDs of calendar events, making them globally unique.
+// Studios other than the default one have a domain of their own, as two studios
+// may run classes of the same name at the same time.
+func (service *service) calendarDomain() string {
+	if service.studio == "" {
+		return "@glofox"
+	}
+	return "@" + service.studio + ".glofox"
+}
+
+// ClassCalendar returns the schedule of every class as a published calendar
+// with one event per occurrence. Occurrences are written in the time zone of
+// their class and keep their UID across requests, so subscribed calendars
+// update them in place.
+func (service *service) ClassCalendar() (ical.Calendar, error) {
+	names, err := service.repo.ClassNames()
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+
+	now := service.clock.Now()
+	domain := service.calendarDomain()
+	calendar := ical.Calendar{Name: "Classes", Method: ical.MethodPublish, Events: make([]ical.Event, 0)}
+	for _, name := range names {
+		classInfo, exist, err := service.repo.LoadClass(name)
+		if err != nil {
+			return ical.Calendar{}, err
+		}
+		if !exist {
+			continue
+		}
+		recurrence, err := schedule.ForClass(classInfo)
+		if err != nil {
+			log.Println("Error: failed to expand schedule of class", name, err)
+			continue
+		}
+		for _, occurrence := range recurrence.Occurrences() {
+			event := occurrenceEvent(recurrence, name, occurrence.Start, now)
+			event.UID = "class-" + url.PathEscape(name) + "-" + occurrence.Start.UTC().Format("20060102T150405Z") + domain
+			event.Status = ical.StatusConfirmed
+			calendar.Events = append(calendar.Events, event)
+		}
+	}
+	return calendar, nil
+}
+
+// MemberCalendar returns the bookings of a member as a published calendar.
+// Bookings holding a spot are confirmed events, and cancelled bookings stay in
+// the feed as cancelled events with a higher sequence, so that subscribed
+// calendars take them out. Events use the booking id as UID, so a rescheduled
+// booking moves in place.
+func (service *service) MemberCalendar(memberID string) (ical.Calendar, error) {
+	member, err := service.GetMember(memberID)
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+	bookings, err := service.repo.MemberBookings(memberID, repository.BookingFilter{
+		Statuses: []string{dto.BookingConfirmed, dto.BookingAttended, dto.BookingNoShow, dto.BookingCancelled},
+	})
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+
+	now := service.clock.Now()
+	domain := service.calendarDomain()
+	calendar := ical.Calendar{Name: "Bookings of " + member.Name, Method: ical.MethodPublish, Events: make([]ical.Event, 0)}
+	recurrences := make(map[string]*schedule.Recurrence)
+	for _, booking := range bookings {
+		recurrence, err := service.classRecurrence(recurrences, booking.ClassName)
+		if err != nil {
+			return ical.Calendar{}, err
+		}
+		var event ical.Event
+		if recurrence != nil {
+			event = occurrenceEvent(recurrence, booking.ClassName, booking.Occurrence, now)
+		} else {
+			// The class is gone, so only the start of the booking is known
+			event = ical.Event{Stamp: now, Start: booking.Occurrence.UTC(), Summary: booking.ClassName}
+		}
+		event.UID = booking.ID + domain
+		event.Status = ical.StatusConfirmed
+		if booking.Status == dto.BookingCancelled {
+			event.Status = ical.StatusCancelled
+			event.Sequence = 1
+		}
+		calendar.Events = append(calendar.Events, event)
+	}
+	return calendar, nil
+}
+
+// classRecurrence returns the schedule of a class, remembering it in known, or
+// nil when the class no longer exists or its schedule can not be expanded.
+func (service *service) classRecurrence(known map[string]*schedule.Recurrence, className string) (*schedule.Recurrence, error) {
+	if recurrence, ok := known[className]; ok {
+		return recurrence, nil
+	}
+	classInfo, exist, err := service.repo.LoadClass(className)
+	if err != nil {
+		return nil, err
+	}
+	var recurrence *schedule.Recurrence
+	if exist {
+		if recurrence, err = schedule.ForClass(classInfo); err != nil {
+			log.Println("Error: failed to expand schedule of class", className, err)
+			recurrence = nil
+		}
+	}
+	known[className] = recurrence
+	return recurrence, nil
+}
+
+// occurrenceEvent returns the event of a class occurrence in the time zone of
+// the class. Occurrences of classes without a start time are all-day events.
+func occurrenceEvent(recurrence *schedule.Recurrence, className string, start, stamp time.Time) ical.Event {
+	loc := recurrence.Location()
+	return ical.Event{
+		Stamp:   stamp,
+		Start:   start.In(loc),
+		End:     recurrence.End(start).In(loc),
+		AllDay:  !recurrence.Timed(),
+		Summary: className,
+	}
+}
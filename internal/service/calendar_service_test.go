@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"bytes"
+	"flag"
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/ical"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares the encoded calendars with testdata/name, or rewrites it when run with -update.
+func assertGolden(t *testing.T, name string, calendars ...ical.Calendar) {
+	var encoded bytes.Buffer
+	require.NoError(t, ical.Encode(&encoded, calendars...))
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, encoded.Bytes(), 0o644))
+	}
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), encoded.String())
+}
+
+// calendarService creates a UTC evening class, a Dublin class over the start
+// of Irish summer time and a class without start time, seen on March 1st 2030.
+func calendarService(t *testing.T, repo repository.Repository) service.BusinessService {
+	svc := service.InitializeServiceWithClock(repo, config.Config{DateFormat: "2006-01-02"},
+		fixedClock(time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)))
+	require.NoError(t, svc.CreateClass(dto.Class{Name: "Spin", Capacity: 10, StartDate: "2030-06-10", EndDate: "2030-06-12",
+		Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}}))
+	require.NoError(t, svc.CreateClass(dto.Class{Name: "Sunrise Yoga, Dublin", Capacity: 10, StartDate: "2030-03-30", EndDate: "2030-04-06",
+		Timezone: "Europe/Dublin", Schedule: &dto.Schedule{Weekdays: []string{"Sat", "Sun"}, StartTime: "07:00", DurationMinutes: 60}}))
+	require.NoError(t, svc.CreateClass(dto.Class{Name: "Open Gym", Capacity: 10, StartDate: "2030-06-10", EndDate: "2030-06-11"}))
+	return svc
+}
+
+func TestClassCalendar_Golden(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := calendarService(t, repo)
+
+		calendar, err := svc.ClassCalendar()
+		require.NoError(t, err)
+		assertGolden(t, "class_calendar.ics", calendar)
+	})
+}
+
+func TestClassCalendar_UIDsPerStudio(t *testing.T) {
+	cfg := config.Config{DateFormat: "2006-01-02", Studios: []config.StudioConfig{{ID: "downtown"}, {ID: "harbour"}}}
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		studios, err := service.InitializeStudiosWithClock(repo, cfg, fixedClock(time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)))
+		require.NoError(t, err)
+
+		// Both studios run Spin at the same time; their events must stay apart
+		uids := make(map[string]string)
+		for _, id := range studios.IDs() {
+			svc, err := studios.Studio(id)
+			require.NoError(t, err)
+			require.NoError(t, svc.CreateClass(dto.Class{Name: "Spin", Capacity: 10, StartDate: "2030-06-10", EndDate: "2030-06-10",
+				Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}}))
+			calendar, err := svc.ClassCalendar()
+			require.NoError(t, err)
+			require.Len(t, calendar.Events, 1)
+			uids[id] = calendar.Events[0].UID
+		}
+		assert.Equal(t, map[string]string{
+			"":         "class-Spin-20300610T180000Z@glofox",
+			"downtown": "class-Spin-20300610T180000Z@downtown.glofox",
+			"harbour":  "class-Spin-20300610T180000Z@harbour.glofox",
+		}, uids)
+	})
+}
+
+func TestMemberCalendar_Golden(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "jane_doe")
+		svc := calendarService(t, repo)
+
+		created := time.Date(2030, 3, 1, 8, 0, 0, 0, time.UTC)
+		dublin, err := time.LoadLocation("Europe/Dublin")
+		require.NoError(t, err)
+		for _, booking := range []dto.Booking{
+			{ID: "bkg_1", ClassName: "Spin", Occurrence: time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC), Status: dto.BookingConfirmed},
+			{ID: "bkg_2", ClassName: "Spin", Occurrence: time.Date(2030, 6, 11, 18, 0, 0, 0, time.UTC), Status: dto.BookingCancelled},
+			{ID: "bkg_3", ClassName: "Sunrise Yoga, Dublin", Occurrence: time.Date(2030, 3, 31, 7, 0, 0, 0, dublin), Status: dto.BookingAttended},
+			{ID: "bkg_4", ClassName: "Open Gym", Occurrence: time.Date(2030, 6, 10, 0, 0, 0, 0, time.UTC), Status: dto.BookingWaitlisted},
+			{ID: "bkg_5", ClassName: "Boxing", Occurrence: time.Date(2030, 5, 1, 19, 0, 0, 0, time.UTC), Status: dto.BookingCancelled},
+		} {
+			booking.MemberID = "jane_doe"
+			booking.Source = dto.SourceAPI
+			booking.CreatedAt = created
+			require.NoError(t, repo.StoreBooking(booking))
+		}
+
+		// Waitlisted bookings hold no spot and are left out; cancelled ones are marked so
+		calendar, err := svc.MemberCalendar("jane_doe")
+		require.NoError(t, err)
+		assertGolden(t, "member_calendar.ics", calendar)
+
+		_, err = svc.MemberCalendar("mem_unknown")
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+	})
+}
@@ -2,41 +2,393 @@ package service
 
 import (
 	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/schedule"
 	"glofox/models/dto"
+	"slices"
+	"strings"
 	"time"
 )
 
 // CreateClass processes the creation of a new class based on the provided input data.
 // It validates date formats, ensures logical consistency of start and end dates,
-// initializes the class information structure, and stores it in the shared map.
+// initializes the class information structure, and stores it in the repository.
+// A class without a time zone runs in the time zone of the studio.
 func (service *service) CreateClass(info dto.Class) error {
+	classInfo, err := service.newClassInfo(info)
+	if err != nil {
+		return err
+	}
+	return service.storeNewClass(info.Name, classInfo)
+}
+
+// newClassInfo validates a new class and initializes the class information structure.
+// It holds the rules shared by CreateClass and ImportClasses.
+func (service *service) newClassInfo(info dto.Class) (dto.ClassInfo, error) {
+	if strings.TrimSpace(info.Name) == "" {
+		return dto.ClassInfo{}, newError.ErrClassNameRequired
+	}
+	if info.Capacity <= 0 {
+		return dto.ClassInfo{}, newError.ErrInvalidCapacity
+	}
+
 	//Time object
 	startDate, err := time.Parse(service.cfg.DateFormat, info.StartDate)
 	if err != nil {
-		return err
+		return dto.ClassInfo{}, err
 	}
 
 	endDate, err := time.Parse(service.cfg.DateFormat, info.EndDate)
 	if err != nil {
-		return err
+		return dto.ClassInfo{}, err
 	}
 
 	hrs := endDate.Sub(startDate).Hours()
 	if hrs < 0 {
-		return newError.ErrEndTimeLessThanStartTime
+		return dto.ClassInfo{}, newError.ErrEndTimeLessThanStartTime
 	}
 
-	classInfo := dto.ClassInfo{
+	timezone := info.Timezone
+	if timezone == "" {
+		timezone = service.cfg.Timezone
+	}
+	loc, err := schedule.LoadLocation(timezone)
+	if err != nil {
+		return dto.ClassInfo{}, err
+	}
+
+	// Reject schedules that can not be expanded into occurrences
+	_, err = schedule.New(info.Schedule, startDate, endDate, loc)
+	if err != nil {
+		return dto.ClassInfo{}, err
+	}
+	if err = validatePolicy(info.Policy); err != nil {
+		return dto.ClassInfo{}, err
+	}
+
+	return dto.ClassInfo{
 		AllowedCapacity: info.Capacity,
+		Timezone:        timezone,
+		Schedule:        info.Schedule,
+		Policy:          info.Policy,
+
+		StartDate: startDate.Truncate(24 * time.Hour),
+		EndDate:   endDate.Truncate(24 * time.Hour),
+		Bookings:  make(map[time.Time][]string),
+		Waitlist:  make(map[time.Time][]string),
+	}, nil
+}
+
+// storeNewClass stores a class under name. Never overwrite an existing class,
+// as that would throw away its bookings.
+func (service *service) storeNewClass(name string, classInfo dto.ClassInfo) error {
+	_, err := service.repo.UpdateClass(name, func(existing dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if exist {
+			return existing, newError.ErrClassAlreadyExist
+		}
+		return classInfo, nil
+	})
+	return err
+}
+
+// UpdateClass changes the capacity, date range or schedule of a class.
+// Bookings that no longer fit are handled according to update.OnConflict:
+// by default the update is rejected, otherwise bookings over the new capacity
+// are moved to the waitlist or cancelled, and bookings on occurrences that no
+// longer exist are cancelled. A higher capacity promotes waitlisted members.
+// Cancelled bookings get their credit back.
+func (service *service) UpdateClass(name string, update dto.ClassUpdate) (dto.ClassChange, error) {
+	policy := update.OnConflict
+	if policy == "" {
+		policy = dto.ConflictReject
+	}
+	if policy != dto.ConflictReject && policy != dto.ConflictWaitlist && policy != dto.ConflictCancel {
+		return dto.ClassChange{}, newError.ErrInvalidConflictPolicy
+	}
 
-		EndDate:  endDate.Truncate(24 * time.Hour),
-		Bookings: make(map[time.Time][]string),
+	var change dto.ClassChange
+	updated, err := service.repo.UpdateClass(name, func(classInfo dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if !exist {
+			return classInfo, newError.ErrClassNotExist
+		}
+		if err := service.applyClassUpdate(&classInfo, update); err != nil {
+			return classInfo, err
+		}
+		var err error
+		change, err = rebalance(classInfo, policy)
+		return classInfo, err
+	})
+	if err != nil {
+		return dto.ClassChange{}, err
+	}
+
+	for _, cancelled := range change.Cancelled {
+		service.refundCredit(service.bookingPayer(name, cancelled.Occurrence, cancelled.MemberID), name, cancelled.Occurrence)
+	}
+	service.syncBookingStatus(name, change.Cancelled, dto.BookingCancelled)
+	service.syncBookingStatus(name, change.Waitlisted, dto.BookingWaitlisted)
+	service.syncBookingStatus(name, change.Promoted, dto.BookingConfirmed)
+	localizeAffected(updated, change.Cancelled)
+	localizeAffected(updated, change.Waitlisted)
+	localizeAffected(updated, change.Promoted)
+	details := service.classDetails(name, updated)
+	change.Class = &details
+	return change, nil
+}
+
+// DeleteClass removes a class. While it still has bookings or waitlist places
+// on occurrences from today on, it is only removed when cascade is set, in
+// which case those bookings are cancelled and their credits refunded.
+func (service *service) DeleteClass(name string, cascade bool) (dto.ClassChange, error) {
+	change := dto.ClassChange{
+		Cancelled:  make([]dto.AffectedBooking, 0),
+		Waitlisted: make([]dto.AffectedBooking, 0),
+	}
+	var deleted dto.ClassInfo
+	_, err := service.repo.UpdateClass(name, func(classInfo dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if !exist {
+			return classInfo, newError.ErrClassNotExist
+		}
+		recurrence, err := schedule.ForClass(classInfo)
+		if err != nil {
+			return classInfo, err
+		}
+		deleted = classInfo
+		today := recurrence.Day(service.clock.Now())
+		for _, occurrence := range bookedOccurrences(classInfo) {
+			if recurrence.Day(occurrence).Before(today) {
+				continue
+			}
+			change.Cancelled = appendAffected(change.Cancelled, occurrence, classInfo.Bookings[occurrence])
+			change.Cancelled = appendAffected(change.Cancelled, occurrence, classInfo.Waitlist[occurrence])
+		}
+		if len(change.Cancelled) > 0 && !cascade {
+			return classInfo, newError.ErrClassHasBookings
+		}
+		return classInfo, repository.ErrDeleteClass
+	})
+	if err != nil {
+		return dto.ClassChange{}, err
 	}
 
-	service.lock.Lock()
-	defer service.lock.Unlock()
+	for _, cancelled := range change.Cancelled {
+		service.refundCredit(service.bookingPayer(name, cancelled.Occurrence, cancelled.MemberID), name, cancelled.Occurrence)
+	}
+	service.syncBookingStatus(name, change.Cancelled, dto.BookingCancelled)
+	localizeAffected(deleted, change.Cancelled)
+	return change, nil
+}
 
-	service.syMap.Store(info.Name, classInfo)
+// applyClassUpdate writes the fields of update onto classInfo and validates the result.
+func (service *service) applyClassUpdate(classInfo *dto.ClassInfo, update dto.ClassUpdate) error {
+	if update.Replace {
+		if update.Capacity == nil || update.StartDate == nil || update.EndDate == nil {
+			return newError.ErrIncompleteClass
+		}
+		classInfo.Schedule = update.Schedule
+		classInfo.Policy = update.Policy
+	} else {
+		if update.Schedule != nil {
+			classInfo.Schedule = update.Schedule
+		}
+		if update.Policy != nil {
+			classInfo.Policy = update.Policy
+		}
+	}
+	if err := validatePolicy(classInfo.Policy); err != nil {
+		return err
+	}
+	if update.Capacity != nil {
+		if *update.Capacity <= 0 {
+			return newError.ErrInvalidCapacity
+		}
+		classInfo.AllowedCapacity = *update.Capacity
+	}
+	if update.Timezone != nil {
+		if _, err := schedule.LoadLocation(*update.Timezone); err != nil {
+			return err
+		}
+		classInfo.Timezone = *update.Timezone
+	}
+	if update.StartDate != nil {
+		startDate, err := time.Parse(service.cfg.DateFormat, *update.StartDate)
+		if err != nil {
+			return err
+		}
+		classInfo.StartDate = startDate.Truncate(24 * time.Hour)
+	}
+	if update.EndDate != nil {
+		endDate, err := time.Parse(service.cfg.DateFormat, *update.EndDate)
+		if err != nil {
+			return err
+		}
+		classInfo.EndDate = endDate.Truncate(24 * time.Hour)
+	}
 
-	return nil
+	if classInfo.EndDate.Before(classInfo.StartDate) {
+		return newError.ErrEndTimeLessThanStartTime
+	}
+	_, err := schedule.ForClass(*classInfo)
+	return err
+}
+
+// rebalance fits the bookings and waitlist of an updated class to its new
+// occurrences and capacity according to policy, and reports who was affected.
+func rebalance(classInfo dto.ClassInfo, policy string) (dto.ClassChange, error) {
+	change := dto.ClassChange{
+		Cancelled:  make([]dto.AffectedBooking, 0),
+		Waitlisted: make([]dto.AffectedBooking, 0),
+		Promoted:   make([]dto.AffectedBooking, 0),
+	}
+
+	recurrence, err := schedule.ForClass(classInfo)
+	if err != nil {
+		return change, err
+	}
+	scheduled := make(map[time.Time]bool)
+	for _, occurrence := range recurrence.Occurrences() {
+		scheduled[occurrence.Start] = true
+	}
+
+	for _, occurrence := range bookedOccurrences(classInfo) {
+		booked := classInfo.Bookings[occurrence]
+
+		// The occurrence is gone, so nobody can keep or wait for a place in it
+		if !scheduled[occurrence] {
+			change.Cancelled = appendAffected(change.Cancelled, occurrence, booked)
+			change.Cancelled = appendAffected(change.Cancelled, occurrence, classInfo.Waitlist[occurrence])
+			delete(classInfo.Bookings, occurrence)
+			delete(classInfo.Waitlist, occurrence)
+			continue
+		}
+
+		// The most recent bookings are the ones that no longer fit
+		if len(booked) > classInfo.AllowedCapacity {
+			overflow := booked[max(classInfo.AllowedCapacity, 0):]
+			classInfo.Bookings[occurrence] = booked[:max(classInfo.AllowedCapacity, 0)]
+			if policy == dto.ConflictWaitlist {
+				change.Waitlisted = appendAffected(change.Waitlisted, occurrence, overflow)
+				classInfo.Waitlist[occurrence] = append(append([]string(nil), overflow...), classInfo.Waitlist[occurrence]...)
+			} else {
+				change.Cancelled = appendAffected(change.Cancelled, occurrence, overflow)
+			}
+			if len(classInfo.Bookings[occurrence]) == 0 {
+				delete(classInfo.Bookings, occurrence)
+			}
+			continue
+		}
+
+		change.Promoted = appendAffected(change.Promoted, occurrence, promoteWaitlist(classInfo, occurrence))
+	}
+
+	if policy == dto.ConflictReject && (len(change.Cancelled) > 0 || len(change.Waitlisted) > 0) {
+		return change, newError.ErrClassUpdateConflict
+	}
+	return change, nil
+}
+
+// bookedOccurrences returns every occurrence holding bookings or waitlist places, in time order.
+func bookedOccurrences(classInfo dto.ClassInfo) []time.Time {
+	seen := make(map[time.Time]bool)
+	occurrences := make([]time.Time, 0, len(classInfo.Bookings)+len(classInfo.Waitlist))
+	for _, entries := range []map[time.Time][]string{classInfo.Bookings, classInfo.Waitlist} {
+		for occurrence := range entries {
+			if !seen[occurrence] {
+				seen[occurrence] = true
+				occurrences = append(occurrences, occurrence)
+			}
+		}
+	}
+	slices.SortFunc(occurrences, func(a, b time.Time) int { return a.Compare(b) })
+	return occurrences
+}
+
+// appendAffected records every member of an occurrence as affected by a class change.
+func appendAffected(affected []dto.AffectedBooking, occurrence time.Time, memberIDs []string) []dto.AffectedBooking {
+	for _, memberID := range memberIDs {
+		affected = append(affected, dto.AffectedBooking{MemberID: memberID, Occurrence: occurrence})
+	}
+	return affected
+}
+
+// localizeAffected renders the occurrences of affected bookings in the time zone of the class.
+func localizeAffected(classInfo dto.ClassInfo, affected []dto.AffectedBooking) {
+	for i := range affected {
+		affected[i].Occurrence = localTime(classInfo, affected[i].Occurrence)
+	}
+}
+
+// GetClasses returns the details of every class held in the repository,
+// ordered by class name.
+func (service *service) GetClasses() ([]dto.ClassDetails, error) {
+	names, err := service.repo.ClassNames()
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]dto.ClassDetails, 0, len(names))
+	for _, name := range names {
+		classInfo, exist, err := service.repo.LoadClass(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			continue
+		}
+		classes = append(classes, service.classDetails(name, classInfo))
+	}
+	return classes, nil
+}
+
+// GetClass returns the details of a single class, including the booked and
+// remaining spots for every occurrence of the class.
+func (service *service) GetClass(name string) (dto.ClassDetails, error) {
+	classInfo, exist, err := service.repo.LoadClass(name)
+	if err != nil {
+		return dto.ClassDetails{}, err
+	}
+	if !exist {
+		return dto.ClassDetails{}, newError.ErrClassNotExist
+	}
+	return service.classDetails(name, classInfo), nil
+}
+
+// classDetails builds the read model of a class from its stored information,
+// rendering its occurrences in the time zone of the class.
+func (service *service) classDetails(name string, info dto.ClassInfo) dto.ClassDetails {
+	details := dto.ClassDetails{
+		Name:         name,
+		Capacity:     info.AllowedCapacity,
+		StartDate:    info.StartDate.Format(service.cfg.DateFormat),
+		EndDate:      info.EndDate.Format(service.cfg.DateFormat),
+		Timezone:     info.Timezone,
+		Schedule:     info.Schedule,
+		Policy:       service.bookingPolicy(info),
+		Availability: make([]dto.ClassAvailability, 0),
+	}
+
+	recurrence, err := schedule.ForClass(info)
+	if err != nil {
+		return details
+	}
+	loc := recurrence.Location()
+	details.Timezone = loc.String()
+
+	for _, occurrence := range recurrence.Occurrences() {
+		booked := len(info.Bookings[occurrence.Start])
+		start := occurrence.Start.In(loc)
+		availability := dto.ClassAvailability{
+			Date:       start.Format(service.cfg.DateFormat),
+			Start:      start,
+			Booked:     booked,
+			Remaining:  max(info.AllowedCapacity-booked, 0),
+			Waitlisted: len(info.Waitlist[occurrence.Start]),
+		}
+		if recurrence.Timed() {
+			availability.StartTime = start.Format(schedule.TimeFormat)
+			availability.EndTime = start.Add(occurrence.Duration).Format(schedule.TimeFormat)
+		}
+		details.Availability = append(details.Availability, availability)
+	}
+	return details
 }
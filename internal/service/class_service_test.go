@@ -1,135 +1,440 @@
-package service
+package service_test
 
 import (
 	"glofox/config"
 	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
 	"glofox/models/dto"
-	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-// Mocking the MapStore interface
-type MockMapStore struct {
-	mock.Mock
+func TestCreateClass_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		// Create a valid class info
+		classInfo := dto.Class{
+			Name:      "Yoga Class",
+			Capacity:  30,
+			StartDate: "2025-06-01",
+			EndDate:   "2025-06-10",
+		}
+
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
+
+		// Call the CreateClass method
+		err := svc.CreateClass(classInfo)
+
+		// Assert that there is no error
+		assert.NoError(t, err)
+
+		// Assert that the class was stored with its capacity and date range
+		stored := loadClass(t, repo, "Yoga Class")
+		assert.Equal(t, 30, stored.AllowedCapacity)
+		assert.Equal(t, "2025-06-01", stored.StartDate.Format(cfg.DateFormat))
+		assert.Equal(t, "2025-06-10", stored.EndDate.Format(cfg.DateFormat))
+	})
 }
 
-func (m *MockMapStore) Store(key string, value interface{}) {
-	m.Called(key, value)
+func TestCreateClass_InvalidStartDate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		// Create a class info with an invalid start date format
+		classInfo := dto.Class{
+			Name:      "Yoga Class",
+			Capacity:  30,
+			StartDate: "2025-06-01T00:00:00", // Invalid format
+			EndDate:   "2025-06-10",
+		}
+
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
+
+		// Call the CreateClass method
+		err := svc.CreateClass(classInfo)
+
+		// Assert that an error is returned (invalid date format)
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "parsing time \"2025-06-01T00:00:00\": extra text: \"T00:00:00\"")
+	})
 }
 
-func (m *MockMapStore) Load(key string) (interface{}, bool) {
-	args := m.Called(key)
-	return args.Get(0), args.Bool(1)
+func TestCreateClass_InvalidEndDateBeforeStartDate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		// Create a class info where the end date is before the start date
+		classInfo := dto.Class{
+			Name:      "Yoga Class",
+			Capacity:  30,
+			StartDate: "2025-06-10",
+			EndDate:   "2025-06-01", // End date before start date
+		}
+
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
+
+		// Call the CreateClass method
+		err := svc.CreateClass(classInfo)
+
+		// Assert that the correct error is returned (end date before start date)
+		assert.Error(t, err)
+		assert.Equal(t, err, newError.ErrEndTimeLessThanStartTime)
+	})
 }
 
-func (m *MockMapStore) Delete(key string) {
-	m.Delete(key)
+func TestCreateClass_InvalidStartDateAfterEndDate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		// Create a class info where the end date is before the start date
+		classInfo := dto.Class{
+			Name:      "Yoga Class",
+			Capacity:  30,
+			StartDate: "2025-06-01",
+			EndDate:   "2025-06-10",
+		}
+
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
+
+		// Call the CreateClass method
+		err := svc.CreateClass(classInfo)
+
+		// Assert that there is no error
+		assert.NoError(t, err)
+
+		// Assert that the class was stored
+		loadClass(t, repo, "Yoga Class")
+	})
 }
-func (m *MockMapStore) PrintMap() {
-	m.Called()
+
+func TestGetClass_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		startDate, _ := time.Parse(cfg.DateFormat, "2025-06-01")
+		endDate, _ := time.Parse(cfg.DateFormat, "2025-06-03")
+
+		// Class with capacity 2 and one booking on the second day
+		classInfo := dto.ClassInfo{
+			AllowedCapacity: 2,
+			StartDate:       startDate,
+			EndDate:         endDate,
+			Bookings:        map[time.Time][]string{startDate.AddDate(0, 0, 1): {"john_doe"}},
+		}
+		require.NoError(t, repo.StoreClass("Yoga Class", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
+
+		details, err := svc.GetClass("Yoga Class")
+
+		// Assert that every date of the class is reported with its availability
+		assert.NoError(t, err)
+		assert.Equal(t, "Yoga Class", details.Name)
+		assert.Equal(t, 2, details.Capacity)
+		assert.Equal(t, "2025-06-01", details.StartDate)
+		assert.Equal(t, "2025-06-03", details.EndDate)
+		assert.Equal(t, []dto.ClassAvailability{
+			{Date: "2025-06-01", Start: startDate, Booked: 0, Remaining: 2},
+			{Date: "2025-06-02", Start: startDate.AddDate(0, 0, 1), Booked: 1, Remaining: 1},
+			{Date: "2025-06-03", Start: endDate, Booked: 0, Remaining: 2},
+		}, details.Availability)
+	})
 }
 
-func TestCreateClass_Success(t *testing.T) {
-	// Create a valid class info
-	classInfo := dto.Class{
-		Name:      "Yoga Class",
-		Capacity:  30,
-		StartDate: "2025-06-01",
-		EndDate:   "2025-06-10",
-	}
+func TestGetClass_ClassNotExist(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
 
-	mockMapStore := new(MockMapStore)
-	mockMapStore.On("Store", "Yoga Class", mock.Anything).Once()
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	mockMutex := &sync.Mutex{}
-	svc := InitializeService(mockMapStore, mockMutex, cfg)
+		_, err := svc.GetClass("Unknown")
 
-	// Call the CreateClass method
-	err := svc.CreateClass(classInfo)
+		// Assert that the error returned is ErrClassNotExist
+		assert.Equal(t, newError.ErrClassNotExist, err)
+	})
+}
+
+func TestGetClasses_ListsEveryClass(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		date, _ := time.Parse(cfg.DateFormat, "2025-06-01")
+		classInfo := dto.ClassInfo{
+			AllowedCapacity: 10,
+			StartDate:       date,
+			EndDate:         date,
+			Bookings:        make(map[time.Time][]string),
+		}
+		require.NoError(t, repo.StoreClass("Yoga Class", classInfo))
+		require.NoError(t, repo.StoreClass("Pilates", classInfo))
 
-	// Assert that there is no error
-	assert.NoError(t, err)
+		svc := service.InitializeService(repo, cfg)
 
-	// Assert that the Store method was called with the correct arguments
-	mockMapStore.AssertExpectations(t)
+		classes, err := svc.GetClasses()
+
+		// Assert that both classes are returned in name order
+		assert.NoError(t, err)
+		assert.Len(t, classes, 2)
+		assert.Equal(t, "Pilates", classes[0].Name)
+		assert.Equal(t, "Yoga Class", classes[1].Name)
+		assert.Equal(t, 10, classes[1].Availability[0].Remaining)
+	})
 }
 
-func TestCreateClass_InvalidStartDate(t *testing.T) {
-	// Create a class info with an invalid start date format
-	classInfo := dto.Class{
-		Name:      "Yoga Class",
-		Capacity:  30,
-		StartDate: "2025-06-01T00:00:00", // Invalid format
-		EndDate:   "2025-06-10",
-	}
+func TestCreateClass_InvalidSchedule(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		// Create a class whose schedule names an unknown weekday
+		classInfo := dto.Class{
+			Name:      "Pilates",
+			Capacity:  10,
+			StartDate: "2025-06-01",
+			EndDate:   "2025-06-30",
+			Schedule:  &dto.Schedule{Weekdays: []string{"Funday"}, StartTime: "07:00", DurationMinutes: 60},
+		}
 
-	mockMapStore := new(MockMapStore)
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	mockMutex := &sync.Mutex{}
-	svc := InitializeService(mockMapStore, mockMutex, cfg)
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		svc := service.InitializeService(repo, cfg)
 
-	// Call the CreateClass method
-	err := svc.CreateClass(classInfo)
+		err := svc.CreateClass(classInfo)
 
-	// Assert that an error is returned (invalid date format)
-	assert.Error(t, err)
-	assert.Equal(t, err.Error(), "parsing time \"2025-06-01T00:00:00\": extra text: \"T00:00:00\"")
+		// Assert that the schedule is rejected and nothing is stored
+		assert.ErrorIs(t, err, newError.ErrInvalidSchedule)
+		_, exist, _ := repo.LoadClass("Pilates")
+		assert.False(t, exist)
+	})
 }
 
-func TestCreateClass_InvalidEndDateBeforeStartDate(t *testing.T) {
-	// Create a class info where the end date is before the start date
-	classInfo := dto.Class{
-		Name:      "Yoga Class",
-		Capacity:  30,
-		StartDate: "2025-06-10",
-		EndDate:   "2025-06-01", // End date before start date
-	}
+func TestCreateClass_RequiresNameAndCapacity(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
 
-	mockMapStore := new(MockMapStore)
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
-	}
-	mockMutex := &sync.Mutex{}
-	svc := InitializeService(mockMapStore, mockMutex, cfg)
+		err := svc.CreateClass(dto.Class{Name: " ", Capacity: 10, StartDate: "2025-06-01", EndDate: "2025-06-30"})
+		assert.Equal(t, newError.ErrClassNameRequired, err)
+		for _, capacity := range []int{0, -5} {
+			err = svc.CreateClass(dto.Class{Name: "Pilates", Capacity: capacity, StartDate: "2025-06-01", EndDate: "2025-06-30"})
+			assert.Equal(t, newError.ErrInvalidCapacity, err)
+		}
+
+		names, err := repo.ClassNames()
+		require.NoError(t, err)
+		assert.Empty(t, names)
+	})
+}
+
+func TestGetClass_ScheduledOccurrences(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{
+			DateFormat: "2006-01-02",
+		}
+		startDate, _ := time.Parse(cfg.DateFormat, "2025-06-01")
+		endDate, _ := time.Parse(cfg.DateFormat, "2025-06-07")
+		classInfo := dto.ClassInfo{
+			AllowedCapacity: 5,
+			StartDate:       startDate,
+			EndDate:         endDate,
+			Schedule:        &dto.Schedule{Weekdays: []string{"MO", "WE"}, StartTime: "07:00", DurationMinutes: 60},
+			Bookings:        map[time.Time][]string{time.Date(2025, 6, 4, 7, 0, 0, 0, time.UTC): {"john_doe"}},
+		}
+		require.NoError(t, repo.StoreClass("Pilates", classInfo))
+
+		svc := service.InitializeService(repo, cfg)
 
-	// Call the CreateClass method
-	err := svc.CreateClass(classInfo)
+		details, err := svc.GetClass("Pilates")
 
-	// Assert that the correct error is returned (end date before start date)
-	assert.Error(t, err)
-	assert.Equal(t, err, newError.ErrEndTimeLessThanStartTime)
+		// Assert that availability is reported per occurrence with its time slot
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.ClassAvailability{
+			{Date: "2025-06-02", Start: time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), StartTime: "07:00", EndTime: "08:00", Booked: 0, Remaining: 5},
+			{Date: "2025-06-04", Start: time.Date(2025, 6, 4, 7, 0, 0, 0, time.UTC), StartTime: "07:00", EndTime: "08:00", Booked: 1, Remaining: 4},
+		}, details.Availability)
+	})
 }
 
-func TestCreateClass_InvalidStartDateAfterEndDate(t *testing.T) {
-	// Create a class info where the end date is before the start date
-	classInfo := dto.Class{
-		Name:      "Yoga Class",
-		Capacity:  30,
-		StartDate: "2025-06-01",
-		EndDate:   "2025-06-10",
-	}
+// bookedClass stores a daily class over the next three days whose first day holds the given bookings and waitlist.
+func bookedClass(t *testing.T, repo repository.Repository, capacity int, booked []string, waitlisted []string) time.Time {
+	tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	registerMember(t, repo, append(append([]string(nil), booked...), waitlisted...)...)
+	require.NoError(t, repo.StoreClass("Yoga Class", dto.ClassInfo{
+		AllowedCapacity: capacity,
+		StartDate:       tomorrow,
+		EndDate:         tomorrow.AddDate(0, 0, 2),
+		Bookings:        map[time.Time][]string{tomorrow: booked},
+		Waitlist:        map[time.Time][]string{tomorrow: waitlisted},
+	}))
+	return tomorrow
+}
+
+func TestCreateClass_DuplicateName(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		tomorrow := bookedClass(t, repo, 2, []string{"john_doe"}, nil)
+		svc := service.InitializeService(repo, cfg)
 
-	mockMapStore := new(MockMapStore)
-	mockMapStore.On("Store", "Yoga Class", mock.Anything).Once()
-	cfg := config.Config{
-		DateFormat: "2006-01-02",
+		// Creating the class again must not wipe its bookings
+		err := svc.CreateClass(dto.Class{Name: "Yoga Class", Capacity: 10, StartDate: "2025-06-01", EndDate: "2025-06-10"})
+		assert.Equal(t, newError.ErrClassAlreadyExist, err)
+		assert.Equal(t, []string{"john_doe"}, loadClass(t, repo, "Yoga Class").Bookings[tomorrow])
+	})
+}
+
+func TestUpdateClass_CapacityShrink(t *testing.T) {
+	capacity := 1
+	for _, tc := range []struct {
+		policy     string
+		err        error
+		booked     []string
+		waitlisted []string
+		cancelled  int
+	}{
+		{policy: dto.ConflictReject, err: newError.ErrClassUpdateConflict, booked: []string{"john_doe", "jane_doe"}, waitlisted: []string{"max_doe"}},
+		{policy: dto.ConflictWaitlist, booked: []string{"john_doe"}, waitlisted: []string{"jane_doe", "max_doe"}},
+		{policy: dto.ConflictCancel, booked: []string{"john_doe"}, waitlisted: []string{"max_doe"}, cancelled: 1},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+				cfg := config.Config{DateFormat: "2006-01-02"}
+				tomorrow := bookedClass(t, repo, 2, []string{"john_doe", "jane_doe"}, []string{"max_doe"})
+				svc := service.InitializeService(repo, cfg)
+
+				// Shrink the class below its bookings; the latest booking is the one that no longer fits
+				change, err := svc.UpdateClass("Yoga Class", dto.ClassUpdate{Capacity: &capacity, OnConflict: tc.policy})
+				assert.Equal(t, tc.err, err)
+				assert.Len(t, change.Cancelled, tc.cancelled)
+
+				stored := loadClass(t, repo, "Yoga Class")
+				assert.Equal(t, tc.booked, stored.Bookings[tomorrow])
+				assert.Equal(t, tc.waitlisted, stored.Waitlist[tomorrow])
+			})
+		})
 	}
-	mockMutex := &sync.Mutex{}
-	svc := InitializeService(mockMapStore, mockMutex, cfg)
+}
+
+func TestUpdateClass_DateRangeShrink(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		tomorrow := bookedClass(t, repo, 2, []string{"john_doe"}, nil)
+		svc := service.InitializeService(repo, cfg)
+
+		// Moving the start past a booked date is rejected by default
+		start := tomorrow.AddDate(0, 0, 1).Format(cfg.DateFormat)
+		_, err := svc.UpdateClass("Yoga Class", dto.ClassUpdate{StartDate: &start})
+		assert.Equal(t, newError.ErrClassUpdateConflict, err)
+
+		// With cancel the booking on the dropped date is cancelled
+		change, err := svc.UpdateClass("Yoga Class", dto.ClassUpdate{StartDate: &start, OnConflict: dto.ConflictCancel})
+		require.NoError(t, err)
+		assert.Equal(t, []dto.AffectedBooking{{MemberID: "john_doe", Occurrence: tomorrow}}, change.Cancelled)
+		assert.Equal(t, start, change.Class.StartDate)
+		assert.Empty(t, loadClass(t, repo, "Yoga Class").Bookings)
+	})
+}
+
+func TestUpdateClass_CapacityRaisePromotesWaitlist(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		tomorrow := bookedClass(t, repo, 1, []string{"john_doe"}, []string{"jane_doe", "max_doe"})
+		svc := service.InitializeService(repo, cfg)
+
+		capacity := 2
+		change, err := svc.UpdateClass("Yoga Class", dto.ClassUpdate{Capacity: &capacity})
+		require.NoError(t, err)
+		assert.Equal(t, 2, change.Class.Capacity)
+
+		// The head of the waitlist takes the new spot
+		stored := loadClass(t, repo, "Yoga Class")
+		assert.Equal(t, []string{"john_doe", "jane_doe"}, stored.Bookings[tomorrow])
+		assert.Equal(t, []string{"max_doe"}, stored.Waitlist[tomorrow])
+	})
+}
+
+func TestUpdateClass_Validation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		bookedClass(t, repo, 1, nil, nil)
+		svc := service.InitializeService(repo, cfg)
+
+		capacity := 5
+		_, err := svc.UpdateClass("Unknown", dto.ClassUpdate{Capacity: &capacity})
+		assert.Equal(t, newError.ErrClassNotExist, err)
+
+		_, err = svc.UpdateClass("Yoga Class", dto.ClassUpdate{Capacity: &capacity, OnConflict: "ignore"})
+		assert.Equal(t, newError.ErrInvalidConflictPolicy, err)
+
+		// A replacement needs the whole class
+		_, err = svc.UpdateClass("Yoga Class", dto.ClassUpdate{Capacity: &capacity, Replace: true})
+		assert.Equal(t, newError.ErrIncompleteClass, err)
+	})
+}
+
+func TestUpdateClass_RejectsNonPositiveCapacity(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		tomorrow := bookedClass(t, repo, 2, []string{"john_doe"}, []string{"jane_doe"})
+		svc := service.InitializeService(repo, cfg)
+		date := tomorrow.Format(cfg.DateFormat)
+
+		for _, capacity := range []int{0, -3} {
+			// Neither a patch nor a replacement may empty the class
+			_, err := svc.UpdateClass("Yoga Class", dto.ClassUpdate{Capacity: &capacity, OnConflict: dto.ConflictCancel})
+			assert.Equal(t, newError.ErrInvalidCapacity, err)
+			_, err = svc.UpdateClass("Yoga Class", dto.ClassUpdate{Capacity: &capacity, StartDate: &date, EndDate: &date, Replace: true, OnConflict: dto.ConflictCancel})
+			assert.Equal(t, newError.ErrInvalidCapacity, err)
+		}
+
+		stored := loadClass(t, repo, "Yoga Class")
+		assert.Equal(t, 2, stored.AllowedCapacity)
+		assert.Equal(t, []string{"john_doe"}, stored.Bookings[tomorrow])
+		assert.Equal(t, []string{"jane_doe"}, stored.Waitlist[tomorrow])
+	})
+}
+
+func TestDeleteClass(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		svc := service.InitializeService(repo, cfg)
+		tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+		require.NoError(t, repo.StoreClass("Yoga Class", dto.ClassInfo{
+			AllowedCapacity: 5,
+			StartDate:       tomorrow,
+			EndDate:         tomorrow,
+			Bookings:        make(map[time.Time][]string),
+		}))
+		memberID := newPackMember(t, svc, 1, 30)
+		_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Yoga Class", MemberID: memberID, BookingDate: tomorrow.Format(cfg.DateFormat)})
+		require.NoError(t, err)
+
+		// A class with active bookings is only deleted on request
+		_, err = svc.DeleteClass("Yoga Class", false)
+		assert.Equal(t, newError.ErrClassHasBookings, err)
+		loadClass(t, repo, "Yoga Class")
 
-	// Call the CreateClass method
-	err := svc.CreateClass(classInfo)
+		change, err := svc.DeleteClass("Yoga Class", true)
+		require.NoError(t, err)
+		assert.Equal(t, []dto.AffectedBooking{{MemberID: memberID, Occurrence: tomorrow}}, change.Cancelled)
+		_, exist, err := repo.LoadClass("Yoga Class")
+		require.NoError(t, err)
+		assert.False(t, exist)
 
-	// Assert that there is no error
-	assert.NoError(t, err)
+		// The cancelled booking's credit is back
+		balance, err := svc.GetCreditBalance(memberID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, balance.Credits)
 
-	// Assert that the Store method was called with the correct arguments
-	mockMapStore.AssertExpectations(t)
+		_, err = svc.DeleteClass("Yoga Class", true)
+		assert.Equal(t, newError.ErrClassNotExist, err)
+	})
 }
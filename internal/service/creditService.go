@@ -0,0 +1,319 @@
+package service
+
+import (
+	"cmp"
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"log"
+	"strings"
+	"time"
+)
+
+// CreatePlan validates and stores a new membership plan.
+func (service *service) CreatePlan(info dto.PlanInfo) (dto.Plan, error) {
+	if strings.TrimSpace(info.Name) == "" || info.ValidDays <= 0 {
+		return dto.Plan{}, newError.ErrInvalidPlan
+	}
+	switch info.Type {
+	case dto.PlanPack:
+		if info.Credits <= 0 {
+			return dto.Plan{}, newError.ErrInvalidPlan
+		}
+	case dto.PlanUnlimited:
+		info.Credits = 0
+	default:
+		return dto.Plan{}, newError.ErrInvalidPlan
+	}
+
+	id, err := newID("plan_")
+	if err != nil {
+		return dto.Plan{}, err
+	}
+	plan := dto.Plan{
+		ID:        id,
+		Name:      strings.TrimSpace(info.Name),
+		Type:      info.Type,
+		Credits:   info.Credits,
+		ValidDays: info.ValidDays,
+	}
+	if err = service.repo.StorePlan(plan); err != nil {
+		return dto.Plan{}, err
+	}
+	return plan, nil
+}
+
+// GetPlans returns every plan on sale.
+func (service *service) GetPlans() ([]dto.Plan, error) {
+	return service.repo.Plans()
+}
+
+// PurchasePlan grants a plan to a member. The grant is valid from the start of
+// today for the number of days of the plan.
+func (service *service) PurchasePlan(memberID string, info dto.PurchaseInfo) (dto.CreditBalance, error) {
+	if _, err := service.GetMember(memberID); err != nil {
+		return dto.CreditBalance{}, err
+	}
+	plan, exist, err := service.repo.LoadPlan(info.PlanID)
+	if err != nil {
+		return dto.CreditBalance{}, err
+	}
+	if !exist {
+		return dto.CreditBalance{}, newError.ErrPlanNotExist
+	}
+
+	grantID, err := newID("grant_")
+	if err != nil {
+		return dto.CreditBalance{}, err
+	}
+	now := service.clock.Now().UTC()
+	validFrom := now.Truncate(24 * time.Hour)
+	grant := dto.CreditGrant{
+		ID:        grantID,
+		PlanID:    plan.ID,
+		PlanName:  plan.Name,
+		Unlimited: plan.Type == dto.PlanUnlimited,
+		Remaining: plan.Credits,
+		ValidFrom: validFrom,
+		ExpiresAt: validFrom.AddDate(0, 0, plan.ValidDays),
+	}
+
+	account, err := service.repo.UpdateCredits(memberID, func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+		account.Grants = append(account.Grants, grant)
+		account.Ledger = append(account.Ledger, dto.CreditEntry{
+			Seq:     nextSeq(account),
+			At:      now.Truncate(time.Second),
+			Type:    dto.CreditPurchase,
+			GrantID: grant.ID,
+			Credits: grant.Remaining,
+		})
+		return account, nil
+	})
+	if err != nil {
+		return dto.CreditBalance{}, err
+	}
+	return creditBalance(memberID, account, now), nil
+}
+
+// GetCreditBalance returns the credits a member can still use and the grants behind them.
+func (service *service) GetCreditBalance(memberID string) (dto.CreditBalance, error) {
+	if _, err := service.GetMember(memberID); err != nil {
+		return dto.CreditBalance{}, err
+	}
+	account, _, err := service.repo.LoadCredits(memberID)
+	if err != nil {
+		return dto.CreditBalance{}, err
+	}
+	return creditBalance(memberID, account, service.clock.Now().UTC()), nil
+}
+
+// GetCreditLedger returns every purchase, consumption and refund of a member, oldest first.
+func (service *service) GetCreditLedger(memberID string) ([]dto.CreditEntry, error) {
+	if _, err := service.GetMember(memberID); err != nil {
+		return nil, err
+	}
+	account, _, err := service.repo.LoadCredits(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Ledger == nil {
+		return make([]dto.CreditEntry, 0), nil
+	}
+	return account.Ledger, nil
+}
+
+// consumeCredit takes one credit covering the occurrence from the member's account.
+// A valid unlimited grant is used first, then the pack that expires soonest.
+func (service *service) consumeCredit(memberID string, className string, occurrence time.Time) (dto.CreditEntry, error) {
+	var entry dto.CreditEntry
+	_, err := service.repo.UpdateCredits(memberID, func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+		var err error
+		account, entry, err = service.chargeAccount(account, className, occurrence)
+		return account, err
+	})
+	return entry, err
+}
+
+// chargeAccount records the consumption of one credit covering the occurrence on account.
+func (service *service) chargeAccount(account dto.CreditAccount, className string, occurrence time.Time) (dto.CreditAccount, dto.CreditEntry, error) {
+	index := usableGrant(account.Grants, occurrence)
+	if index < 0 {
+		return account, dto.CreditEntry{}, newError.ErrNoValidCredit
+	}
+
+	credits := 0
+	if !account.Grants[index].Unlimited {
+		credits = -1
+		account.Grants[index].Remaining--
+	}
+	entry := dto.CreditEntry{
+		Seq:        nextSeq(account),
+		At:         service.clock.Now().UTC().Truncate(time.Second),
+		Type:       dto.CreditConsume,
+		GrantID:    account.Grants[index].ID,
+		Credits:    credits,
+		ClassName:  className,
+		Occurrence: &occurrence,
+	}
+	account.Ledger = append(account.Ledger, entry)
+	return account, entry, nil
+}
+
+// revertCredit undoes a consumption whose booking could not be stored, as if it never happened.
+func (service *service) revertCredit(memberID string, consumed dto.CreditEntry) {
+	_, err := service.repo.UpdateCredits(memberID, func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+		for i, entry := range account.Ledger {
+			if entry.Seq == consumed.Seq {
+				account.Ledger = append(account.Ledger[:i], account.Ledger[i+1:]...)
+				restoreGrant(account.Grants, entry)
+				break
+			}
+		}
+		return account, nil
+	})
+	if err != nil {
+		log.Println("Error: failed to revert credit of member", memberID, err)
+	}
+}
+
+// refundCredit gives back the credit consumed by the member's booking of the
+// occurrence. Bookings made without a credit have nothing to refund.
+func (service *service) refundCredit(memberID string, className string, occurrence time.Time) {
+	_, err := service.repo.UpdateCredits(memberID, func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+		account, _ = service.refundAccount(account, className, occurrence)
+		return account, nil
+	})
+	if err != nil {
+		log.Println("Error: failed to refund credit of member", memberID, err)
+	}
+}
+
+// payerOf returns the member whose credit paid for a booking record. Records
+// kept before payers were tracked were paid for by the member holding them.
+func payerOf(record dto.Booking) string {
+	return cmp.Or(record.PaidBy, record.MemberID)
+}
+
+// bookingPayer returns the member whose credit paid for the active booking of
+// memberID in a class occurrence, which is memberID unless it was given the booking.
+func (service *service) bookingPayer(className string, occurrence time.Time, memberID string) string {
+	record, exist, err := service.repo.FindBooking(className, occurrence, memberID)
+	if err != nil {
+		log.Println("Error: failed to read booking of member", memberID, err)
+	}
+	if err != nil || !exist {
+		return memberID
+	}
+	return payerOf(record)
+}
+
+// moveCredit refunds the credit of a member's booking of one occurrence and
+// charges one for another in a single update of the account, so that a pack
+// down to its last credit can still pay for the new occurrence. A booking made
+// without a credit moves for free. Nothing changes when no credit covers the new occurrence.
+func (service *service) moveCredit(memberID string, fromClass string, from time.Time, toClass string, to time.Time) error {
+	_, err := service.repo.UpdateCredits(memberID, func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+		account, refunded := service.refundAccount(account, fromClass, from)
+		if !refunded {
+			return account, nil
+		}
+		account, _, err := service.chargeAccount(account, toClass, to)
+		return account, err
+	})
+	return err
+}
+
+// refundAccount records the refund of the outstanding consumption of the
+// occurrence on account, and reports whether there was one.
+func (service *service) refundAccount(account dto.CreditAccount, className string, occurrence time.Time) (dto.CreditAccount, bool) {
+	// A consumption is still outstanding when it outnumbers the refunds for the same occurrence
+	outstanding := 0
+	var consumed dto.CreditEntry
+	for _, entry := range account.Ledger {
+		if entry.ClassName != className || entry.Occurrence == nil || !entry.Occurrence.Equal(occurrence) {
+			continue
+		}
+		switch entry.Type {
+		case dto.CreditConsume:
+			outstanding++
+			consumed = entry
+		case dto.CreditRefund:
+			outstanding--
+		}
+	}
+	if outstanding <= 0 {
+		return account, false
+	}
+
+	refund := consumed
+	refund.Seq = nextSeq(account)
+	refund.At = service.clock.Now().UTC().Truncate(time.Second)
+	refund.Type = dto.CreditRefund
+	refund.Credits = -consumed.Credits
+	account.Ledger = append(account.Ledger, refund)
+	restoreGrant(account.Grants, consumed)
+	return account, true
+}
+
+// refundable reports whether cancelling a booking of the occurrence now still returns its credit.
+func (service *service) refundable(occurrence time.Time) bool {
+	window := time.Duration(service.cfg.RefundWindowHours) * time.Hour
+	return !service.clock.Now().UTC().Add(window).After(occurrence)
+}
+
+// usableGrant returns the index of the grant to charge for the occurrence, or -1 if none covers it.
+func usableGrant(grants []dto.CreditGrant, occurrence time.Time) int {
+	best := -1
+	for i, grant := range grants {
+		if occurrence.Before(grant.ValidFrom) || !occurrence.Before(grant.ExpiresAt) {
+			continue
+		}
+		if grant.Unlimited {
+			return i
+		}
+		if grant.Remaining > 0 && (best < 0 || grant.ExpiresAt.Before(grants[best].ExpiresAt)) {
+			best = i
+		}
+	}
+	return best
+}
+
+// restoreGrant reverses the effect of a ledger entry on the grant it charged.
+func restoreGrant(grants []dto.CreditGrant, entry dto.CreditEntry) {
+	for i := range grants {
+		if grants[i].ID == entry.GrantID {
+			grants[i].Remaining -= entry.Credits
+			return
+		}
+	}
+}
+
+// nextSeq returns the sequence number of the next ledger entry of account.
+func nextSeq(account dto.CreditAccount) int {
+	if len(account.Ledger) == 0 {
+		return 1
+	}
+	return account.Ledger[len(account.Ledger)-1].Seq + 1
+}
+
+// creditBalance builds the read model of an account as seen at now.
+func creditBalance(memberID string, account dto.CreditAccount, now time.Time) dto.CreditBalance {
+	balance := dto.CreditBalance{
+		MemberID: memberID,
+		Grants:   make([]dto.CreditGrant, 0, len(account.Grants)),
+	}
+	for _, grant := range account.Grants {
+		balance.Grants = append(balance.Grants, grant)
+		if !now.Before(grant.ExpiresAt) {
+			continue
+		}
+		if !grant.Unlimited {
+			balance.Credits += grant.Remaining
+			continue
+		}
+		if !now.Before(grant.ValidFrom) && (balance.UnlimitedUntil == nil || grant.ExpiresAt.After(*balance.UnlimitedUntil)) {
+			until := grant.ExpiresAt
+			balance.UnlimitedUntil = &until
+		}
+	}
+	return balance
+}
@@ -0,0 +1,181 @@
+package service_test
+
+import (
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPackMember registers an active member holding a fresh pack of the given size.
+func newPackMember(t *testing.T, svc service.BusinessService, credits int, validDays int) string {
+	member, err := svc.CreateMember(dto.MemberInfo{Name: "John Doe"})
+	require.NoError(t, err)
+	plan, err := svc.CreatePlan(dto.PlanInfo{Name: "Class pack", Type: dto.PlanPack, Credits: credits, ValidDays: validDays})
+	require.NoError(t, err)
+	_, err = svc.PurchasePlan(member.ID, dto.PurchaseInfo{PlanID: plan.ID})
+	require.NoError(t, err)
+	return member.ID
+}
+
+// storeDailyClass stores a class running every day from today for the given number of days.
+func storeDailyClass(t *testing.T, repo repository.Repository, name string, capacity int, days int) time.Time {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	require.NoError(t, repo.StoreClass(name, dto.ClassInfo{
+		AllowedCapacity: capacity,
+		StartDate:       today,
+		EndDate:         today.AddDate(0, 0, days),
+		Bookings:        make(map[time.Time][]string),
+		Waitlist:        make(map[time.Time][]string),
+	}))
+	return today
+}
+
+func TestCreatePlan_Validation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		// Packs need credits, every plan needs a type and a validity
+		for _, info := range []dto.PlanInfo{
+			{Name: "Empty pack", Type: dto.PlanPack, ValidDays: 90},
+			{Name: "Forever", Type: dto.PlanUnlimited},
+			{Name: "Mystery", Type: "trial", ValidDays: 7},
+			{Type: dto.PlanUnlimited, ValidDays: 30},
+		} {
+			_, err := svc.CreatePlan(info)
+			assert.Equal(t, newError.ErrInvalidPlan, err, info.Name)
+		}
+
+		plan, err := svc.CreatePlan(dto.PlanInfo{Name: "10 classes", Type: dto.PlanPack, Credits: 10, ValidDays: 90})
+		require.NoError(t, err)
+		plans, err := svc.GetPlans()
+		require.NoError(t, err)
+		assert.Equal(t, []dto.Plan{plan}, plans)
+
+		// Buying an unknown plan is rejected
+		member, err := svc.CreateMember(dto.MemberInfo{Name: "John Doe"})
+		require.NoError(t, err)
+		_, err = svc.PurchasePlan(member.ID, dto.PurchaseInfo{PlanID: "plan_unknown"})
+		assert.Equal(t, newError.ErrPlanNotExist, err)
+	})
+}
+
+func TestCreateBooking_ConsumesPackCredits(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		svc := service.InitializeService(repo, cfg)
+		today := storeDailyClass(t, repo, "YogaClass", 5, 10)
+		memberID := newPackMember(t, svc, 2, 90)
+
+		book := func(day int) error {
+			_, err := svc.CreateBooking(dto.BookingInfo{
+				ClassName:   "YogaClass",
+				MemberID:    memberID,
+				BookingDate: today.AddDate(0, 0, day).Format(cfg.DateFormat),
+			})
+			return err
+		}
+
+		// Each booking takes one credit until the pack runs out
+		require.NoError(t, book(1))
+		require.NoError(t, book(2))
+		assert.Equal(t, newError.ErrNoValidCredit, book(3))
+
+		balance, err := svc.GetCreditBalance(memberID)
+		require.NoError(t, err)
+		assert.Zero(t, balance.Credits)
+		assert.Nil(t, balance.UnlimitedUntil)
+
+		// The ledger explains the balance
+		ledger, err := svc.GetCreditLedger(memberID)
+		require.NoError(t, err)
+		require.Len(t, ledger, 3)
+		assert.Equal(t, dto.CreditPurchase, ledger[0].Type)
+		assert.Equal(t, 2, ledger[0].Credits)
+		assert.Equal(t, dto.CreditConsume, ledger[1].Type)
+		assert.Equal(t, -1, ledger[1].Credits)
+		assert.Equal(t, "YogaClass", ledger[1].ClassName)
+		assert.True(t, ledger[1].Occurrence.Equal(today.AddDate(0, 0, 1)))
+	})
+}
+
+func TestCreateBooking_FailedBookingKeepsCredit(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		svc := service.InitializeService(repo, cfg)
+		today := storeDailyClass(t, repo, "YogaClass", 1, 10)
+		registerMember(t, repo, "jane_doe")
+		memberID := newPackMember(t, svc, 1, 90)
+
+		// Fill the class, then try to book it without joining the waitlist
+		bookingInfo := dto.BookingInfo{ClassName: "YogaClass", MemberID: "jane_doe", BookingDate: today.AddDate(0, 0, 1).Format(cfg.DateFormat)}
+		_, err := svc.CreateBooking(bookingInfo)
+		require.NoError(t, err)
+
+		bookingInfo.MemberID = memberID
+		_, err = svc.CreateBooking(bookingInfo)
+		assert.Equal(t, newError.ErrSlotsFullForTheDate, err)
+
+		// The credit taken for the attempt was put back without a trace
+		balance, err := svc.GetCreditBalance(memberID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, balance.Credits)
+		ledger, err := svc.GetCreditLedger(memberID)
+		require.NoError(t, err)
+		assert.Len(t, ledger, 1)
+	})
+}
+
+func TestCreateBooking_ExpiredPack(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		svc := service.InitializeService(repo, cfg)
+		today := storeDailyClass(t, repo, "YogaClass", 5, 30)
+		memberID := newPackMember(t, svc, 5, 7)
+
+		// A pack valid for a week does not cover a class in three weeks
+		_, err := svc.CreateBooking(dto.BookingInfo{
+			ClassName:   "YogaClass",
+			MemberID:    memberID,
+			BookingDate: today.AddDate(0, 0, 21).Format(cfg.DateFormat),
+		})
+		assert.Equal(t, newError.ErrNoValidCredit, err)
+	})
+}
+
+func TestCancelBooking_RefundsWithinWindow(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		cfg := config.Config{DateFormat: "2006-01-02", RefundWindowHours: 48}
+		svc := service.InitializeService(repo, cfg)
+		today := storeDailyClass(t, repo, "YogaClass", 5, 10)
+		memberID := newPackMember(t, svc, 2, 90)
+
+		early := dto.BookingInfo{ClassName: "YogaClass", MemberID: memberID, BookingDate: today.AddDate(0, 0, 5).Format(cfg.DateFormat)}
+		late := dto.BookingInfo{ClassName: "YogaClass", MemberID: memberID, BookingDate: today.AddDate(0, 0, 1).Format(cfg.DateFormat)}
+		_, err := svc.CreateBooking(early)
+		require.NoError(t, err)
+		_, err = svc.CreateBooking(late)
+		require.NoError(t, err)
+
+		// Cancelling five days ahead is refunded, cancelling the next day's class is not
+		require.NoError(t, svc.CancelBooking(early))
+		require.NoError(t, svc.CancelBooking(late))
+
+		balance, err := svc.GetCreditBalance(memberID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, balance.Credits)
+
+		ledger, err := svc.GetCreditLedger(memberID)
+		require.NoError(t, err)
+		require.Len(t, ledger, 4)
+		assert.Equal(t, dto.CreditRefund, ledger[3].Type)
+		assert.Equal(t, 1, ledger[3].Credits)
+		assert.True(t, ledger[3].Occurrence.Equal(today.AddDate(0, 0, 5)))
+	})
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/schedule"
+	"glofox/models/dto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Page sizes of the booking history.
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// GetMemberBookings returns the past and upcoming bookings of a member, ordered
+// by occurrence start and narrowed down by date range and status. The bookings
+// are read through the member index of the repository rather than from the classes.
+func (service *service) GetMemberBookings(memberID string, query dto.BookingQuery) (dto.BookingPage, error) {
+	filter, err := service.bookingFilter(query)
+	if err != nil {
+		return dto.BookingPage{}, err
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if limit < 0 || limit > maxPageLimit {
+		return dto.BookingPage{}, newError.ErrInvalidPageLimit
+	}
+	if query.Offset < 0 {
+		return dto.BookingPage{}, newError.ErrInvalidPageOffset
+	}
+
+	if _, err = service.GetMember(memberID); err != nil {
+		return dto.BookingPage{}, err
+	}
+	bookings, err := service.repo.MemberBookings(memberID, filter)
+	if err != nil {
+		return dto.BookingPage{}, err
+	}
+
+	start := min(query.Offset, len(bookings))
+	end := min(start+limit, len(bookings))
+	return dto.BookingPage{
+		Bookings: bookings[start:end],
+		Total:    len(bookings),
+		Limit:    limit,
+		Offset:   query.Offset,
+	}, nil
+}
+
+// bookingFilter turns a booking query into a repository filter. Dates are
+// taken in the studio time zone and a date in To includes the whole day.
+func (service *service) bookingFilter(query dto.BookingQuery) (repository.BookingFilter, error) {
+	var filter repository.BookingFilter
+	loc, err := schedule.LoadLocation(service.cfg.Timezone)
+	if err != nil {
+		return filter, err
+	}
+	if query.From != "" {
+		if filter.From, _, err = service.parseQueryTime(query.From, loc); err != nil {
+			return filter, newError.ErrInvalidBookingFrom
+		}
+	}
+	if query.To != "" {
+		to, date, err := service.parseQueryTime(query.To, loc)
+		if err != nil {
+			return filter, newError.ErrInvalidBookingTo
+		}
+		if date {
+			to = to.AddDate(0, 0, 1)
+		} else {
+			// Occurrences start on whole seconds, so this includes one starting right at the bound
+			to = to.Truncate(time.Second).Add(time.Second)
+		}
+		if !filter.From.IsZero() && to.Before(filter.From) {
+			return filter, newError.ErrInvalidBookingTo
+		}
+		filter.To = to
+	}
+
+	for _, value := range query.Status {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			switch status {
+			case "":
+				continue
+			case dto.BookingConfirmed, dto.BookingWaitlisted, dto.BookingCancelled, dto.BookingAttended, dto.BookingNoShow:
+				if !slices.Contains(filter.Statuses, status) {
+					filter.Statuses = append(filter.Statuses, status)
+				}
+			default:
+				return filter, newError.ErrInvalidBookingStatus
+			}
+		}
+	}
+	return filter, nil
+}
+
+// parseQueryTime reads a date in the configured layout, as local midnight in
+// loc, or an RFC 3339 timestamp. It reports whether the value was a date.
+func (service *service) parseQueryTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if date, err := time.ParseInLocation(service.cfg.DateFormat, value, loc); err == nil {
+		return date, true, nil
+	}
+	instant, err := time.Parse(time.RFC3339, value)
+	return instant, false, err
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bookingIDs lists the ids of the bookings on a page, in order.
+func bookingIDs(page dto.BookingPage) []string {
+	ids := make([]string, 0, len(page.Bookings))
+	for _, booking := range page.Bookings {
+		ids = append(ids, booking.ID)
+	}
+	return ids
+}
+
+func TestGetMemberBookings_FiltersAndPages(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		policyClass(t, repo, nil)
+
+		cfg := config.Config{DateFormat: "2006-01-02"}
+		svc := service.InitializeServiceWithClock(repo, cfg, fixedClock(time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)))
+
+		// John books four evenings and cancels one; Jane books one
+		ids := make(map[string]string)
+		for _, date := range []string{"2030-06-12", "2030-06-10", "2030-06-14", "2030-06-11"} {
+			result, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: date})
+			require.NoError(t, err)
+			ids[date] = result.BookingID
+		}
+		require.NoError(t, svc.CancelBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-11"}))
+		_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-10"})
+		require.NoError(t, err)
+
+		// Every booking of the member, ordered by occurrence
+		page, err := svc.GetMemberBookings("john_doe", dto.BookingQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids["2030-06-10"], ids["2030-06-11"], ids["2030-06-12"], ids["2030-06-14"]}, bookingIDs(page))
+		assert.Equal(t, 4, page.Total)
+		assert.Equal(t, 20, page.Limit)
+
+		// Date range, with the end date included
+		page, err = svc.GetMemberBookings("john_doe", dto.BookingQuery{From: "2030-06-11", To: "2030-06-12"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids["2030-06-11"], ids["2030-06-12"]}, bookingIDs(page))
+
+		// A timestamp bound includes an occurrence starting right at it
+		page, err = svc.GetMemberBookings("john_doe", dto.BookingQuery{To: "2030-06-12T18:00:00Z"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+
+		// Status filter, as a list or repeated
+		page, err = svc.GetMemberBookings("john_doe", dto.BookingQuery{Status: []string{"cancelled"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids["2030-06-11"]}, bookingIDs(page))
+		page, err = svc.GetMemberBookings("john_doe", dto.BookingQuery{Status: []string{"confirmed,waitlisted"}})
+		require.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+
+		// Pagination keeps the total of the whole result
+		page, err = svc.GetMemberBookings("john_doe", dto.BookingQuery{Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids["2030-06-11"], ids["2030-06-12"]}, bookingIDs(page))
+		assert.Equal(t, 4, page.Total)
+		page, err = svc.GetMemberBookings("john_doe", dto.BookingQuery{Offset: 10})
+		require.NoError(t, err)
+		assert.Empty(t, page.Bookings)
+	})
+}
+
+func TestGetMemberBookings_InvalidQuery(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		for _, test := range []struct {
+			query dto.BookingQuery
+			err   error
+		}{
+			{dto.BookingQuery{From: "June"}, newError.ErrInvalidBookingFrom},
+			{dto.BookingQuery{From: "2030-06-10", To: "2030-06-01"}, newError.ErrInvalidBookingTo},
+			{dto.BookingQuery{Status: []string{"booked"}}, newError.ErrInvalidBookingStatus},
+			{dto.BookingQuery{Limit: 101}, newError.ErrInvalidPageLimit},
+			{dto.BookingQuery{Offset: -1}, newError.ErrInvalidPageOffset},
+		} {
+			_, err := svc.GetMemberBookings("john_doe", test.query)
+			assert.Equal(t, test.err, err)
+		}
+
+		_, err := svc.GetMemberBookings("mem_unknown", dto.BookingQuery{})
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+	})
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"errors"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/models/dto"
+	"log"
+)
+
+// ImportClasses creates the classes read from an import file, validating each
+// row with the rules of CreateClass. A class that already exists, or that
+// appears twice in the file, is reported as a failed row and never overwritten.
+// In all-or-nothing mode (the default) nothing is imported unless every row
+// is valid, in which case ErrImportRejected is returned along with the result;
+// in best-effort mode the valid rows are imported and the others reported.
+// A failing store ends the import with its error, after taking back the
+// classes imported so far in all-or-nothing mode.
+func (service *service) ImportClasses(rows []dto.ClassRow, mode string) (dto.ImportResult, error) {
+	if mode == "" {
+		mode = dto.ImportAllOrNothing
+	}
+	if mode != dto.ImportAllOrNothing && mode != dto.ImportBestEffort {
+		return dto.ImportResult{}, newError.ErrInvalidImportMode
+	}
+
+	result := dto.ImportResult{
+		Mode:     mode,
+		Total:    len(rows),
+		Imported: make([]string, 0),
+		Failed:   make([]dto.ImportFailure, 0),
+	}
+	type validRow struct {
+		number    int
+		name      string
+		classInfo dto.ClassInfo
+	}
+	var valid []validRow
+	seen := make(map[string]bool)
+	for i, row := range rows {
+		classInfo, err := service.validateImportRow(row, seen)
+		if err != nil && newError.CodeOf(err) == newError.CodeInternal {
+			// Rows only fail with domain errors; anything else comes from the store
+			return dto.ImportResult{}, err
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, importFailure(i+1, row.Class.Name, err))
+			continue
+		}
+		valid = append(valid, validRow{number: i + 1, name: row.Class.Name, classInfo: classInfo})
+	}
+	if mode == dto.ImportAllOrNothing && len(result.Failed) > 0 {
+		return result, newError.ErrImportRejected
+	}
+
+	for _, row := range valid {
+		err := service.storeNewClass(row.name, row.classInfo)
+		if err != nil && !errors.Is(err, newError.ErrClassAlreadyExist) {
+			if mode == dto.ImportAllOrNothing {
+				service.removeImported(result.Imported)
+			}
+			return dto.ImportResult{}, err
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, importFailure(row.number, row.name, err))
+			if mode == dto.ImportAllOrNothing {
+				// A class was created meanwhile; take back the ones imported so far
+				service.removeImported(result.Imported)
+				result.Imported = make([]string, 0)
+				return result, newError.ErrImportRejected
+			}
+			continue
+		}
+		result.Imported = append(result.Imported, row.name)
+	}
+	return result, nil
+}
+
+// validateImportRow validates a row like CreateClass would and checks that its
+// class neither exists yet nor was already seen earlier in the import.
+func (service *service) validateImportRow(row dto.ClassRow, seen map[string]bool) (dto.ClassInfo, error) {
+	if row.Err != nil {
+		return dto.ClassInfo{}, row.Err
+	}
+	classInfo, err := service.newClassInfo(row.Class)
+	if err != nil {
+		return dto.ClassInfo{}, err
+	}
+	if seen[row.Class.Name] {
+		return dto.ClassInfo{}, newError.ErrClassAlreadyExist
+	}
+	seen[row.Class.Name] = true
+	_, exist, err := service.repo.LoadClass(row.Class.Name)
+	if err != nil {
+		return dto.ClassInfo{}, err
+	}
+	if exist {
+		return dto.ClassInfo{}, newError.ErrClassAlreadyExist
+	}
+	return classInfo, nil
+}
+
+// removeImported deletes the classes of an all-or-nothing import that could
+// not be completed. A class that got booked in the meantime is kept.
+func (service *service) removeImported(names []string) {
+	for _, name := range names {
+		_, err := service.repo.UpdateClass(name, func(classInfo dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+			if !exist {
+				return classInfo, nil
+			}
+			if len(bookedOccurrences(classInfo)) > 0 {
+				return classInfo, newError.ErrClassHasBookings
+			}
+			return classInfo, repository.ErrDeleteClass
+		})
+		if err != nil {
+			log.Println("Error: failed to remove imported class", name, err)
+		}
+	}
+}
+
+// importFailure describes why a row was not imported.
+func importFailure(row int, className string, err error) dto.ImportFailure {
+	return dto.ImportFailure{
+		Row:       row,
+		ClassName: className,
+		Code:      newError.CodeOf(err),
+		Message:   newError.MessageOf(err),
+		Errors:    newError.FieldsOf(err),
+	}
+}
+
+// ExportClasses returns every class as it would be created, so that the
+// export can be imported again. Bookings are exported by ExportBookings.
+func (service *service) ExportClasses() ([]dto.Class, error) {
+	names, err := service.repo.ClassNames()
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]dto.Class, 0, len(names))
+	for _, name := range names {
+		classInfo, exist, err := service.repo.LoadClass(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			continue
+		}
+		classes = append(classes, dto.Class{
+			Name:      name,
+			Capacity:  classInfo.AllowedCapacity,
+			StartDate: classInfo.StartDate.Format(service.cfg.DateFormat),
+			EndDate:   classInfo.EndDate.Format(service.cfg.DateFormat),
+			Timezone:  classInfo.Timezone,
+			Schedule:  classInfo.Schedule,
+			Policy:    classInfo.Policy,
+		})
+	}
+	return classes, nil
+}
+
+// ExportBookings returns the booking records of every member and status, in order of occurrence.
+func (service *service) ExportBookings() ([]dto.Booking, error) {
+	return service.repo.Bookings(repository.BookingFilter{})
+}
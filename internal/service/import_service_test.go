@@ -0,0 +1,234 @@
+package service_test
+
+import (
+	"errors"
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// importRows returns rows with a valid class, one ending before it starts,
+// one that exists already, one repeated from earlier in the file and one
+// that could not be read.
+func importRows() []dto.ClassRow {
+	return []dto.ClassRow{
+		{Class: dto.Class{Name: "Pilates", Capacity: 8, StartDate: "2030-06-01", EndDate: "2030-06-30",
+			Schedule: &dto.Schedule{Weekdays: []string{"Tue"}, StartTime: "12:00", DurationMinutes: 50}}},
+		{Class: dto.Class{Name: "Boxing", Capacity: 8, StartDate: "2030-06-30", EndDate: "2030-06-01"}},
+		{Class: dto.Class{Name: "Spin", Capacity: 8, StartDate: "2030-06-01", EndDate: "2030-06-30"}},
+		{Class: dto.Class{Name: "Pilates", Capacity: 4, StartDate: "2030-07-01", EndDate: "2030-07-31"}},
+		{Class: dto.Class{Name: "Rowing"}, Err: &newError.ValidationError{Fields: []newError.FieldError{{Field: "classCapacity", Message: "must be a whole number"}}}},
+	}
+}
+
+func TestImportClasses_AllOrNothing(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := rescheduleService(t, repo)
+
+		result, err := svc.ImportClasses(importRows(), "")
+		assert.Equal(t, newError.ErrImportRejected, err)
+		assert.Equal(t, dto.ImportAllOrNothing, result.Mode)
+		assert.Equal(t, 5, result.Total)
+		assert.Empty(t, result.Imported)
+		require.Len(t, result.Failed, 4)
+		assert.Equal(t, dto.ImportFailure{Row: 2, ClassName: "Boxing", Code: newError.CodeValidationFailed,
+			Message: newError.ErrEndTimeLessThanStartTime.Error(), Errors: newError.FieldsOf(newError.ErrEndTimeLessThanStartTime)}, result.Failed[0])
+		assert.Equal(t, 3, result.Failed[1].Row)
+		assert.Equal(t, newError.CodeClassAlreadyExists, result.Failed[1].Code)
+		assert.Equal(t, 4, result.Failed[2].Row)
+		assert.Equal(t, newError.CodeClassAlreadyExists, result.Failed[2].Code)
+		assert.Equal(t, 5, result.Failed[3].Row)
+		assert.Equal(t, []newError.FieldError{{Field: "classCapacity", Message: "must be a whole number"}}, result.Failed[3].Errors)
+
+		// Nothing was imported, not even the valid class
+		_, err = svc.GetClass("Pilates")
+		assert.Equal(t, newError.ErrClassNotExist, err)
+
+		// Once every row is valid, all of them are imported
+		result, err = svc.ImportClasses(importRows()[:1], dto.ImportAllOrNothing)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Pilates"}, result.Imported)
+		assert.Empty(t, result.Failed)
+		class, err := svc.GetClass("Pilates")
+		require.NoError(t, err)
+		assert.Equal(t, 8, class.Capacity)
+		assert.Len(t, class.Availability, 4)
+	})
+}
+
+func TestImportClasses_BestEffort(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := rescheduleService(t, repo)
+
+		result, err := svc.ImportClasses(importRows(), dto.ImportBestEffort)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Pilates"}, result.Imported)
+		require.Len(t, result.Failed, 4)
+		assert.Equal(t, []int{2, 3, 4, 5}, []int{result.Failed[0].Row, result.Failed[1].Row, result.Failed[2].Row, result.Failed[3].Row})
+
+		// The first Pilates row won, and the existing Spin class was left alone
+		class, err := svc.GetClass("Pilates")
+		require.NoError(t, err)
+		assert.Equal(t, 8, class.Capacity)
+		class, err = svc.GetClass("Spin")
+		require.NoError(t, err)
+		assert.Equal(t, 1, class.Capacity)
+
+		_, err = svc.ImportClasses(importRows(), "some")
+		assert.Equal(t, newError.ErrInvalidImportMode, err)
+	})
+}
+
+func TestImportClasses_ReportsUnparsableDates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		result, err := svc.ImportClasses([]dto.ClassRow{
+			{Class: dto.Class{Name: "Spin", Capacity: 8, StartDate: "01/06/2030", EndDate: "2030-06-30"}},
+			{Class: dto.Class{Name: "Yoga", Capacity: 8, StartDate: "2030-06-01", EndDate: "2030-06-30", Timezone: "Mars/Olympus"}},
+		}, dto.ImportBestEffort)
+		require.NoError(t, err)
+		assert.Empty(t, result.Imported)
+		require.Len(t, result.Failed, 2)
+		for _, failure := range result.Failed {
+			assert.Equal(t, newError.CodeValidationFailed, failure.Code, failure.Message)
+		}
+	})
+}
+
+func TestImportClasses_RejectsBlankNamesAndCapacity(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		result, err := svc.ImportClasses([]dto.ClassRow{
+			{Class: dto.Class{Name: "", Capacity: 0, StartDate: "2030-06-01", EndDate: "2030-06-30"}},
+			{Class: dto.Class{Name: "Neg", Capacity: -5, StartDate: "2030-06-01", EndDate: "2030-06-30"}},
+			{Class: dto.Class{Name: "Empty", Capacity: 0, StartDate: "2030-06-01", EndDate: "2030-06-30"}},
+		}, dto.ImportAllOrNothing)
+		assert.Equal(t, newError.ErrImportRejected, err)
+		assert.Empty(t, result.Imported)
+		require.Len(t, result.Failed, 3)
+		assert.Equal(t, newError.FieldsOf(newError.ErrClassNameRequired), result.Failed[0].Errors)
+		assert.Equal(t, newError.FieldsOf(newError.ErrInvalidCapacity), result.Failed[1].Errors)
+		assert.Equal(t, newError.FieldsOf(newError.ErrInvalidCapacity), result.Failed[2].Errors)
+
+		// Nothing was stored, not even under an empty name
+		names, err := repo.ClassNames()
+		require.NoError(t, err)
+		assert.Empty(t, names)
+	})
+}
+
+// errStoreDown is how a failing store reports itself, details the client must not see.
+var errStoreDown = errors.New("write /var/lib/glofox/classes.log: no space left on device")
+
+// failingRepository fails to read or write the class named failOn.
+type failingRepository struct {
+	repository.Repository
+	failOn string
+}
+
+func (repo failingRepository) LoadClass(name string) (dto.ClassInfo, bool, error) {
+	if name == repo.failOn {
+		return dto.ClassInfo{}, false, errStoreDown
+	}
+	return repo.Repository.LoadClass(name)
+}
+
+func (repo failingRepository) UpdateClass(name string, fn repository.ClassUpdateFunc) (dto.ClassInfo, error) {
+	if name == repo.failOn {
+		return dto.ClassInfo{}, errStoreDown
+	}
+	return repo.Repository.UpdateClass(name, fn)
+}
+
+// writeFailingRepository reads every class but fails to write the class named failOn.
+type writeFailingRepository struct {
+	failingRepository
+}
+
+func (repo writeFailingRepository) LoadClass(name string) (dto.ClassInfo, bool, error) {
+	return repo.Repository.LoadClass(name)
+}
+
+func TestImportClasses_ReturnsStoreFailures(t *testing.T) {
+	rows := []dto.ClassRow{
+		{Class: dto.Class{Name: "Pilates", Capacity: 8, StartDate: "2030-06-01", EndDate: "2030-06-30"}},
+		{Class: dto.Class{Name: "Yoga", Capacity: 8, StartDate: "2030-06-01", EndDate: "2030-06-30"}},
+	}
+	cfg := config.Config{DateFormat: "2006-01-02"}
+
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		// A class that can not be read is not a bad row
+		svc := service.InitializeService(failingRepository{Repository: repo, failOn: "Yoga"}, cfg)
+		for _, mode := range []string{dto.ImportAllOrNothing, dto.ImportBestEffort} {
+			result, err := svc.ImportClasses(rows, mode)
+			assert.Equal(t, errStoreDown, err, mode)
+			assert.Empty(t, result.Failed, mode)
+		}
+		names, err := repo.ClassNames()
+		require.NoError(t, err)
+		assert.Empty(t, names)
+
+		// A class that can not be written takes back the import as a whole
+		svc = service.InitializeService(writeFailingRepository{failingRepository{Repository: repo, failOn: "Yoga"}}, cfg)
+		_, err = svc.ImportClasses(rows, dto.ImportAllOrNothing)
+		assert.Equal(t, errStoreDown, err)
+		names, err = repo.ClassNames()
+		require.NoError(t, err)
+		assert.Empty(t, names)
+
+		// In best-effort mode the classes imported before the failure stay
+		_, err = svc.ImportClasses(rows, dto.ImportBestEffort)
+		assert.Equal(t, errStoreDown, err)
+		names, err = repo.ClassNames()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Pilates"}, names)
+	})
+}
+
+func TestExport(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		svc := rescheduleService(t, repo)
+		_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "john_doe", BookingDate: "2030-06-12"})
+		require.NoError(t, err)
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-10"})
+		require.NoError(t, err)
+		require.NoError(t, svc.CancelBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-10"}))
+
+		classes, err := svc.ExportClasses()
+		require.NoError(t, err)
+		require.Len(t, classes, 2)
+		assert.Equal(t, dto.Class{Name: "Spin", Capacity: 1, StartDate: "2030-06-01", EndDate: "2030-06-30",
+			Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}}, classes[0])
+
+		// Cancelled bookings are exported too, in order of occurrence
+		bookings, err := svc.ExportBookings()
+		require.NoError(t, err)
+		require.Len(t, bookings, 2)
+		assert.Equal(t, "Spin", bookings[0].ClassName)
+		assert.Equal(t, dto.BookingCancelled, bookings[0].Status)
+		assert.Equal(t, "Yoga", bookings[1].ClassName)
+		assert.Equal(t, time.Date(2030, 6, 12, 7, 0, 0, 0, time.UTC), bookings[1].Occurrence.UTC())
+
+		// An export imports into an empty store as it was
+		other := service.InitializeService(repository.NewMapRepository(newMemoryStore()), config.Config{DateFormat: "2006-01-02"})
+		rows := make([]dto.ClassRow, 0, len(classes))
+		for _, class := range classes {
+			rows = append(rows, dto.ClassRow{Class: class})
+		}
+		_, err = other.ImportClasses(rows, "")
+		require.NoError(t, err)
+		imported, err := other.ExportClasses()
+		require.NoError(t, err)
+		assert.Equal(t, classes, imported)
+	})
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"strings"
+	"time"
+)
+
+// CreateMember registers a new, active member and assigns it a stable id.
+func (service *service) CreateMember(info dto.MemberInfo) (dto.Member, error) {
+	if strings.TrimSpace(info.Name) == "" {
+		return dto.Member{}, newError.ErrMemberNameRequired
+	}
+	status, err := memberStatus(info.Status, dto.MemberActive)
+	if err != nil {
+		return dto.Member{}, err
+	}
+
+	id, err := newID("mem_")
+	if err != nil {
+		return dto.Member{}, err
+	}
+
+	return service.repo.UpdateMember(id, func(member dto.Member, exists bool) (dto.Member, error) {
+		if exists {
+			return member, newError.ErrCreatingMember
+		}
+		return dto.Member{
+			ID:        id,
+			Name:      strings.TrimSpace(info.Name),
+			Email:     info.Email,
+			Status:    status,
+			CreatedAt: service.clock.Now().UTC().Truncate(time.Second),
+		}, nil
+	})
+}
+
+// GetMember returns the member registered under id.
+func (service *service) GetMember(id string) (dto.Member, error) {
+	member, exist, err := service.repo.LoadMember(id)
+	if err != nil {
+		return dto.Member{}, err
+	}
+	if !exist {
+		return dto.Member{}, newError.ErrMemberNotExist
+	}
+	return member, nil
+}
+
+// UpdateMember changes the details or the status of a member.
+// Fields left empty keep their current value; the id never changes.
+func (service *service) UpdateMember(id string, info dto.MemberInfo) (dto.Member, error) {
+	return service.repo.UpdateMember(id, func(member dto.Member, exists bool) (dto.Member, error) {
+		if !exists {
+			return member, newError.ErrMemberNotExist
+		}
+		if name := strings.TrimSpace(info.Name); name != "" {
+			member.Name = name
+		}
+		if info.Email != "" {
+			member.Email = info.Email
+		}
+		status, err := memberStatus(info.Status, member.Status)
+		if err != nil {
+			return member, err
+		}
+		member.Status = status
+		return member, nil
+	})
+}
+
+// DeactivateMember suspends a member so that it can no longer book classes.
+// The member is kept so that its existing bookings still resolve.
+func (service *service) DeactivateMember(id string) (dto.Member, error) {
+	return service.repo.UpdateMember(id, func(member dto.Member, exists bool) (dto.Member, error) {
+		if !exists {
+			return member, newError.ErrMemberNotExist
+		}
+		member.Status = dto.MemberSuspended
+		return member, nil
+	})
+}
+
+// activeMember returns the member registered under id, failing unless it may book classes.
+func (service *service) activeMember(id string) (dto.Member, error) {
+	member, err := service.GetMember(id)
+	if err != nil {
+		return dto.Member{}, err
+	}
+	if member.Status != dto.MemberActive {
+		return dto.Member{}, newError.ErrMemberNotActive
+	}
+	return member, nil
+}
+
+// memberStatus validates a requested status, falling back to current when none is given.
+func memberStatus(requested string, current string) (string, error) {
+	switch requested {
+	case "":
+		return current, nil
+	case dto.MemberActive, dto.MemberSuspended:
+		return requested, nil
+	default:
+		return "", newError.ErrInvalidMemberStatus
+	}
+}
+
+// newID generates a random, URL-safe id starting with prefix.
+func newID(prefix string) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberRegistry_Lifecycle(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		// Register a member and read it back by its assigned id
+		created, err := svc.CreateMember(dto.MemberInfo{Name: "John Doe", Email: "john@example.com"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, created.ID)
+		assert.Equal(t, dto.MemberActive, created.Status)
+
+		fetched, err := svc.GetMember(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created, fetched)
+
+		// Updating keeps the id and any field that was not provided
+		updated, err := svc.UpdateMember(created.ID, dto.MemberInfo{Email: "johnny@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, updated.ID)
+		assert.Equal(t, "John Doe", updated.Name)
+		assert.Equal(t, "johnny@example.com", updated.Email)
+
+		// Deactivating suspends the member without removing it
+		deactivated, err := svc.DeactivateMember(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.MemberSuspended, deactivated.Status)
+
+		fetched, err = svc.GetMember(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.MemberSuspended, fetched.Status)
+	})
+}
+
+func TestMemberRegistry_Validation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		// A member must have a name and a known status
+		_, err := svc.CreateMember(dto.MemberInfo{Name: "  "})
+		assert.Equal(t, newError.ErrMemberNameRequired, err)
+
+		_, err = svc.CreateMember(dto.MemberInfo{Name: "John Doe", Status: "banned"})
+		assert.Equal(t, newError.ErrInvalidMemberStatus, err)
+
+		// Unknown ids are reported as such
+		_, err = svc.GetMember("mem_unknown")
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+
+		_, err = svc.UpdateMember("mem_unknown", dto.MemberInfo{Name: "Jane"})
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+
+		_, err = svc.DeactivateMember("mem_unknown")
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+	})
+}
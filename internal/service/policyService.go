@@ -0,0 +1,70 @@
+package service
+
+import (
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"time"
+)
+
+// bookingPolicy returns the booking policy of a class, with the studio
+// defaults filled in for every value the class does not override.
+func (service *service) bookingPolicy(classInfo dto.ClassInfo) dto.BookingPolicy {
+	defaults := service.cfg.BookingPolicy
+	policy := dto.BookingPolicy{
+		OpensHoursBefore:  &defaults.OpensHoursBefore,
+		CutoffMinutes:     &defaults.CutoffMinutes,
+		CancelHoursBefore: &defaults.CancelHoursBefore,
+	}
+	if override := classInfo.Policy; override != nil {
+		if override.OpensHoursBefore != nil {
+			policy.OpensHoursBefore = override.OpensHoursBefore
+		}
+		if override.CutoffMinutes != nil {
+			policy.CutoffMinutes = override.CutoffMinutes
+		}
+		if override.CancelHoursBefore != nil {
+			policy.CancelHoursBefore = override.CancelHoursBefore
+		}
+	}
+	return policy
+}
+
+// checkBookingWindow verifies that the occurrence can be booked now: bookings
+// must have opened and the cutoff before the start must not have passed.
+func (service *service) checkBookingWindow(classInfo dto.ClassInfo, occurrence time.Time) error {
+	policy := service.bookingPolicy(classInfo)
+	now := service.clock.Now()
+
+	opens := time.Duration(*policy.OpensHoursBefore) * time.Hour
+	if opens > 0 && now.Before(occurrence.Add(-opens)) {
+		return newError.ErrBookingNotOpen
+	}
+	cutoff := time.Duration(*policy.CutoffMinutes) * time.Minute
+	if !now.Before(occurrence.Add(-cutoff)) {
+		return newError.ErrBookingClosed
+	}
+	return nil
+}
+
+// checkCancellationDeadline verifies that a booking of the occurrence can still be cancelled now.
+func (service *service) checkCancellationDeadline(classInfo dto.ClassInfo, occurrence time.Time) error {
+	policy := service.bookingPolicy(classInfo)
+	deadline := occurrence.Add(-time.Duration(*policy.CancelHoursBefore) * time.Hour)
+	if !service.clock.Now().Before(deadline) {
+		return newError.ErrCancellationClosed
+	}
+	return nil
+}
+
+// validatePolicy rejects negative booking policy values.
+func validatePolicy(policy *dto.BookingPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for _, value := range []*int{policy.OpensHoursBefore, policy.CutoffMinutes, policy.CancelHoursBefore} {
+		if value != nil && *value < 0 {
+			return newError.ErrInvalidBookingPolicy
+		}
+	}
+	return nil
+}
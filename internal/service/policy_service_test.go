@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(value int) *int {
+	return &value
+}
+
+// policyClass creates a class running daily at 18:00 UTC in June 2030 with the given policy overrides.
+func policyClass(t *testing.T, repo repository.Repository, policy *dto.BookingPolicy) {
+	svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+	require.NoError(t, svc.CreateClass(dto.Class{
+		Name:      "Spin",
+		Capacity:  5,
+		StartDate: "2030-06-01",
+		EndDate:   "2030-06-30",
+		Schedule:  &dto.Schedule{StartTime: "18:00", DurationMinutes: 45},
+		Policy:    policy,
+	}))
+}
+
+func TestCreateBooking_BookingWindow(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
+
+		// The studio opens bookings a week ahead; the class closes them 30 minutes before the start
+		cfg := config.Config{
+			DateFormat:    "2006-01-02",
+			BookingPolicy: config.BookingPolicy{OpensHoursBefore: 7 * 24, CutoffMinutes: 10},
+		}
+		policyClass(t, repo, &dto.BookingPolicy{CutoffMinutes: intPtr(30)})
+		booking := dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15"}
+		start := time.Date(2030, 6, 15, 18, 0, 0, 0, time.UTC)
+
+		// More than a week ahead, bookings are not open yet
+		svc := service.InitializeServiceWithClock(repo, cfg, fixedClock(start.Add(-7*24*time.Hour-time.Minute)))
+		_, err := svc.CreateBooking(booking)
+		assert.Equal(t, newError.ErrBookingNotOpen, err)
+
+		// Inside the class cutoff, which overrides the studio default, bookings are closed
+		svc = service.InitializeServiceWithClock(repo, cfg, fixedClock(start.Add(-20*time.Minute)))
+		_, err = svc.CreateBooking(booking)
+		assert.Equal(t, newError.ErrBookingClosed, err)
+
+		// An occurrence that already ran can not be booked either
+		svc = service.InitializeServiceWithClock(repo, cfg, fixedClock(start.AddDate(0, 0, 1)))
+		_, err = svc.CreateBooking(booking)
+		assert.Equal(t, newError.ErrBookingClosed, err)
+
+		// In between the booking goes through
+		svc = service.InitializeServiceWithClock(repo, cfg, fixedClock(start.Add(-time.Hour)))
+		_, err = svc.CreateBooking(booking)
+		assert.NoError(t, err)
+
+		// The class details report the effective policy
+		details, err := svc.GetClass("Spin")
+		require.NoError(t, err)
+		assert.Equal(t, 7*24, *details.Policy.OpensHoursBefore)
+		assert.Equal(t, 30, *details.Policy.CutoffMinutes)
+		assert.Equal(t, 0, *details.Policy.CancelHoursBefore)
+	})
+}
+
+func TestCancelBooking_CancellationDeadline(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe")
+
+		cfg := config.Config{
+			DateFormat:    "2006-01-02",
+			BookingPolicy: config.BookingPolicy{CancelHoursBefore: 2},
+		}
+		policyClass(t, repo, nil)
+		booking := dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-15"}
+		start := time.Date(2030, 6, 15, 18, 0, 0, 0, time.UTC)
+
+		svc := service.InitializeServiceWithClock(repo, cfg, fixedClock(start.Add(-3*time.Hour)))
+		_, err := svc.CreateBooking(booking)
+		require.NoError(t, err)
+
+		// Within two hours of the start the booking can no longer be cancelled
+		svc = service.InitializeServiceWithClock(repo, cfg, fixedClock(start.Add(-time.Hour)))
+		assert.Equal(t, newError.ErrCancellationClosed, svc.CancelBooking(booking))
+		assert.Equal(t, []string{"john_doe"}, loadClass(t, repo, "Spin").Bookings[start])
+
+		// Before the deadline it can
+		svc = service.InitializeServiceWithClock(repo, cfg, fixedClock(start.Add(-2*time.Hour-time.Second)))
+		assert.NoError(t, svc.CancelBooking(booking))
+	})
+}
+
+func TestCreateClass_InvalidBookingPolicy(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := service.InitializeService(repo, config.Config{DateFormat: "2006-01-02"})
+
+		err := svc.CreateClass(dto.Class{
+			Name:      "Spin",
+			Capacity:  5,
+			StartDate: "2030-06-01",
+			EndDate:   "2030-06-30",
+			Policy:    &dto.BookingPolicy{CutoffMinutes: intPtr(-5)},
+		})
+		assert.Equal(t, newError.ErrInvalidBookingPolicy, err)
+	})
+}
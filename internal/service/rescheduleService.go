@@ -0,0 +1,218 @@
+package service
+
+import (
+	"cmp"
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"log"
+	"slices"
+	"time"
+)
+
+// bookingMove describes where a booking is moved from and to. Occurrences are
+// UTC starts, as used to key bookings in the classes.
+type bookingMove struct {
+	sourceClass string
+	source      time.Time
+	fromMember  string
+	targetClass string
+	target      time.Time
+	toMember    string
+}
+
+// sameOccurrence reports whether the booking stays in its occurrence and only changes hands.
+func (move bookingMove) sameOccurrence() bool {
+	return move.sourceClass == move.targetClass && move.source.Equal(move.target)
+}
+
+// RescheduleBooking moves a confirmed booking to another occurrence of its
+// class, to another class or to another member, keeping its id. The target must
+// be open for booking and have room; otherwise the booking stays where it was.
+// A move within one class is a single atomic update of the class. A move to
+// another class takes the new place before giving up the original one, so the
+// member is never left without a place, and releases the new place again if
+// the original one can not be given up.
+// The credit of the booking moves along with it; a booking handed to another
+// member stays paid for by the member who gave it away, who is also the one
+// refunded when the booking is cancelled.
+func (service *service) RescheduleBooking(id string, info dto.RescheduleInfo) (dto.Booking, error) {
+	record, err := service.GetBooking(id)
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	if record.Status != dto.BookingConfirmed {
+		return dto.Booking{}, newError.ErrBookingNotMovable
+	}
+
+	payer := payerOf(record)
+	move := bookingMove{
+		sourceClass: record.ClassName,
+		source:      record.Occurrence.UTC(),
+		fromMember:  record.MemberID,
+		targetClass: cmp.Or(info.ClassName, record.ClassName),
+		toMember:    cmp.Or(info.MemberID, record.MemberID),
+	}
+	if move.toMember != move.fromMember {
+		if _, err = service.activeMember(move.toMember); err != nil {
+			return dto.Booking{}, err
+		}
+		if err = service.checkNoShowBlock(move.toMember); err != nil {
+			return dto.Booking{}, err
+		}
+	}
+
+	targetInfo, exist, err := service.repo.LoadClass(move.targetClass)
+	if err != nil {
+		return dto.Booking{}, err
+	}
+	if !exist {
+		return dto.Booking{}, newError.ErrClassNotExist
+	}
+	if move.target, err = service.targetOccurrence(targetInfo, move, info); err != nil {
+		return dto.Booking{}, err
+	}
+	if move.sameOccurrence() && move.toMember == move.fromMember {
+		return dto.Booking{}, newError.ErrNothingToReschedule
+	}
+
+	if !move.sameOccurrence() {
+		// Leaving the original occurrence is bound by its cancellation deadline
+		sourceInfo, exist, err := service.repo.LoadClass(move.sourceClass)
+		if err != nil {
+			return dto.Booking{}, err
+		}
+		if !exist {
+			return dto.Booking{}, newError.ErrBookingNotExist
+		}
+		if err = service.checkCancellationDeadline(sourceInfo, move.source); err != nil {
+			return dto.Booking{}, err
+		}
+		if err = service.moveCredit(payer, move.sourceClass, move.source, move.targetClass, move.target); err != nil {
+			return dto.Booking{}, err
+		}
+	}
+
+	promoted, err := service.moveBooking(move, info)
+	if err != nil {
+		if !move.sameOccurrence() {
+			if undoErr := service.moveCredit(payer, move.targetClass, move.target, move.sourceClass, move.source); undoErr != nil {
+				log.Println("Error: failed to move back credit of member", payer, undoErr)
+			}
+		}
+		return dto.Booking{}, err
+	}
+	service.syncBookingStatus(move.sourceClass, appendAffected(nil, move.source, promoted), dto.BookingConfirmed)
+
+	record.ClassName = move.targetClass
+	record.Occurrence = localTime(targetInfo, move.target)
+	record.MemberID = move.toMember
+	record.PaidBy = payer
+	_, err = service.repo.UpdateBooking(id, func(current dto.Booking, exists bool) (dto.Booking, error) {
+		if !exists {
+			return current, newError.ErrBookingNotExist
+		}
+		current.ClassName = record.ClassName
+		current.Occurrence = record.Occurrence
+		current.MemberID = record.MemberID
+		current.PaidBy = record.PaidBy
+		return current, nil
+	})
+	if err != nil {
+		// The classes hold the booking already and are the source of truth
+		log.Println("Error: failed to update booking", id, err)
+	}
+	return record, nil
+}
+
+// targetOccurrence resolves the occurrence a booking is moved to and checks
+// that it can be booked now. Without a date the booking keeps its occurrence,
+// which is only possible within its own class.
+func (service *service) targetOccurrence(classInfo dto.ClassInfo, move bookingMove, info dto.RescheduleInfo) (time.Time, error) {
+	if info.BookingDate == "" {
+		if move.targetClass != move.sourceClass {
+			return time.Time{}, newError.ErrInvalidBookingDate
+		}
+		return move.source, service.checkBookingWindow(classInfo, move.source)
+	}
+	date, err := service.parseBookingDate(info.BookingDate)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return service.bookableOccurrence(classInfo, date, info.BookingTime)
+}
+
+// moveBooking moves the place of the booking between the classes and returns
+// who was promoted from the waitlist of the original occurrence.
+func (service *service) moveBooking(move bookingMove, info dto.RescheduleInfo) ([]string, error) {
+	var promoted []string
+	if move.sourceClass == move.targetClass {
+		_, err := service.repo.UpdateClass(move.sourceClass, func(classInfo dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+			if !exist {
+				return classInfo, newError.ErrClassNotExist
+			}
+			if !slices.Contains(classInfo.Bookings[move.source], move.fromMember) {
+				return classInfo, newError.ErrBookingNotExist
+			}
+			if err := service.takePlace(classInfo, move, info); err != nil {
+				return classInfo, err
+			}
+			if !move.sameOccurrence() {
+				removeUser(classInfo.Bookings, move.source, move.fromMember)
+				promoted = promoteWaitlist(classInfo, move.source)
+			}
+			return classInfo, nil
+		})
+		return promoted, err
+	}
+
+	_, err := service.repo.UpdateClass(move.targetClass, func(classInfo dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if !exist {
+			return classInfo, newError.ErrClassNotExist
+		}
+		return classInfo, service.takePlace(classInfo, move, info)
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = service.repo.UpdateClass(move.sourceClass, func(classInfo dto.ClassInfo, exist bool) (dto.ClassInfo, error) {
+		if !exist || !removeUser(classInfo.Bookings, move.source, move.fromMember) {
+			return classInfo, newError.ErrBookingNotExist
+		}
+		promoted = promoteWaitlist(classInfo, move.source)
+		return classInfo, nil
+	})
+	if err != nil {
+		// The original booking is gone, so the place taken for it is released again
+		service.dropBooking(move.targetClass, move.target, move.toMember)
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// takePlace books the receiving member into the target occurrence.
+// classInfo must be the copy being updated.
+func (service *service) takePlace(classInfo dto.ClassInfo, move bookingMove, info dto.RescheduleInfo) error {
+	// The class may have changed since it was read, so check it again
+	current, err := service.targetOccurrence(classInfo, move, info)
+	if err != nil {
+		return err
+	}
+	if !current.Equal(move.target) {
+		return newError.ErrNoClassOccurrence
+	}
+
+	booked := classInfo.Bookings[move.target]
+	if slices.Contains(booked, move.toMember) || slices.Contains(classInfo.Waitlist[move.target], move.toMember) {
+		return newError.ErrDuplicateBooking
+	}
+	if move.sameOccurrence() {
+		// The spot changes hands without ever being free
+		booked[slices.Index(booked, move.fromMember)] = move.toMember
+		return nil
+	}
+	if len(booked) >= classInfo.AllowedCapacity {
+		return newError.ErrSlotsFullForTheDate
+	}
+	classInfo.Bookings[move.target] = append(booked, move.toMember)
+	return nil
+}
@@ -0,0 +1,227 @@
+package service_test
+
+import (
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rescheduleService creates two daily classes in June 2030, Spin at 18:00 for
+// one member and Yoga at 07:00 for two, seen at 9:00 on June 1st.
+func rescheduleService(t *testing.T, repo repository.Repository) service.BusinessService {
+	svc := service.InitializeServiceWithClock(repo, config.Config{DateFormat: "2006-01-02"},
+		fixedClock(time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)))
+	require.NoError(t, svc.CreateClass(dto.Class{Name: "Spin", Capacity: 1, StartDate: "2030-06-01", EndDate: "2030-06-30",
+		Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}}))
+	require.NoError(t, svc.CreateClass(dto.Class{Name: "Yoga", Capacity: 2, StartDate: "2030-06-01", EndDate: "2030-06-30",
+		Schedule: &dto.Schedule{StartTime: "07:00", DurationMinutes: 60}}))
+	return svc
+}
+
+func TestRescheduleBooking_WithinClass(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		svc := rescheduleService(t, repo)
+
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-11"})
+		require.NoError(t, err)
+		waiting, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-11", JoinWaitlist: true})
+		require.NoError(t, err)
+
+		// Tuesday's booking moves to Thursday and keeps its id
+		moved, err := svc.RescheduleBooking(booked.BookingID, dto.RescheduleInfo{BookingDate: "2030-06-13"})
+		require.NoError(t, err)
+		assert.Equal(t, booked.BookingID, moved.ID)
+		assert.Equal(t, time.Date(2030, 6, 13, 18, 0, 0, 0, time.UTC), moved.Occurrence)
+		assert.Equal(t, dto.BookingConfirmed, moved.Status)
+
+		tuesday := time.Date(2030, 6, 11, 18, 0, 0, 0, time.UTC)
+		thursday := time.Date(2030, 6, 13, 18, 0, 0, 0, time.UTC)
+		stored := loadClass(t, repo, "Spin")
+		assert.Equal(t, []string{"john_doe"}, stored.Bookings[thursday])
+		assert.Equal(t, []string{"jane_doe"}, stored.Bookings[tuesday])
+
+		// The freed spot went to the waitlist
+		booking, err := svc.GetBooking(waiting.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingConfirmed, booking.Status)
+		booking, err = svc.GetBooking(booked.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, moved, booking)
+
+		// Thursday is full now, so Jane can not move there and keeps Tuesday
+		_, err = svc.RescheduleBooking(waiting.BookingID, dto.RescheduleInfo{BookingDate: "2030-06-13"})
+		assert.Equal(t, newError.ErrSlotsFullForTheDate, err)
+		stored = loadClass(t, repo, "Spin")
+		assert.Equal(t, []string{"jane_doe"}, stored.Bookings[tuesday])
+		booking, err = svc.GetBooking(waiting.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, tuesday, booking.Occurrence)
+	})
+}
+
+func TestRescheduleBooking_ToAnotherClassMovesCredit(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := rescheduleService(t, repo)
+
+		// A member down to the single credit of a pack
+		require.NoError(t, repo.StoreMember(dto.Member{ID: "john_doe", Name: "John", Status: dto.MemberActive}))
+		_, err := repo.UpdateCredits("john_doe", func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+			account.Grants = append(account.Grants, dto.CreditGrant{
+				ID:        "grant_pack",
+				Remaining: 1,
+				ValidFrom: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+				ExpiresAt: time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC),
+			})
+			return account, nil
+		})
+		require.NoError(t, err)
+
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-11"})
+		require.NoError(t, err)
+
+		// Moving to another class needs a date
+		_, err = svc.RescheduleBooking(booked.BookingID, dto.RescheduleInfo{ClassName: "Yoga"})
+		assert.Equal(t, newError.ErrInvalidBookingDate, err)
+
+		moved, err := svc.RescheduleBooking(booked.BookingID, dto.RescheduleInfo{ClassName: "Yoga", BookingDate: "2030-06-12"})
+		require.NoError(t, err)
+		assert.Equal(t, "Yoga", moved.ClassName)
+		assert.Equal(t, time.Date(2030, 6, 12, 7, 0, 0, 0, time.UTC), moved.Occurrence)
+
+		assert.Empty(t, loadClass(t, repo, "Spin").Bookings)
+		assert.Equal(t, []string{"john_doe"}, loadClass(t, repo, "Yoga").Bookings[moved.Occurrence])
+
+		// The single credit now pays for the yoga class
+		ledger, err := svc.GetCreditLedger("john_doe")
+		require.NoError(t, err)
+		require.Len(t, ledger, 3)
+		assert.Equal(t, dto.CreditRefund, ledger[1].Type)
+		assert.Equal(t, "Spin", ledger[1].ClassName)
+		assert.Equal(t, dto.CreditConsume, ledger[2].Type)
+		assert.Equal(t, "Yoga", ledger[2].ClassName)
+		balance, err := svc.GetCreditBalance("john_doe")
+		require.NoError(t, err)
+		assert.Zero(t, balance.Credits)
+	})
+}
+
+func TestRescheduleBooking_TransferToMember(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe", "max_doe")
+		svc := rescheduleService(t, repo)
+
+		_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "max_doe", BookingDate: "2030-06-11"})
+		require.NoError(t, err)
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "john_doe", BookingDate: "2030-06-11"})
+		require.NoError(t, err)
+
+		// John gifts his spot to Jane, who takes it over in a full class
+		moved, err := svc.RescheduleBooking(booked.BookingID, dto.RescheduleInfo{MemberID: "jane_doe"})
+		require.NoError(t, err)
+		assert.Equal(t, "jane_doe", moved.MemberID)
+		assert.Equal(t, []string{"max_doe", "jane_doe"}, loadClass(t, repo, "Yoga").Bookings[moved.Occurrence])
+
+		// The booking now shows up in Jane's history instead of John's
+		page, err := svc.GetMemberBookings("jane_doe", dto.BookingQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{booked.BookingID}, bookingIDs(page))
+		page, err = svc.GetMemberBookings("john_doe", dto.BookingQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, page.Bookings)
+
+		// Jane can cancel it as her own
+		require.NoError(t, svc.CancelBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "jane_doe", BookingDate: "2030-06-11"}))
+		booking, err := svc.GetBooking(booked.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingCancelled, booking.Status)
+	})
+}
+
+func TestRescheduleBooking_TransferRefundsGiver(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		svc := rescheduleService(t, repo)
+
+		// John holds a pack of two credits, Jane holds none
+		require.NoError(t, repo.StoreMember(dto.Member{ID: "john_doe", Name: "John", Status: dto.MemberActive}))
+		require.NoError(t, repo.StoreMember(dto.Member{ID: "jane_doe", Name: "Jane", Status: dto.MemberActive}))
+		_, err := repo.UpdateCredits("john_doe", func(account dto.CreditAccount, exists bool) (dto.CreditAccount, error) {
+			account.Grants = append(account.Grants, dto.CreditGrant{
+				ID:        "grant_pack",
+				Remaining: 2,
+				ValidFrom: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+				ExpiresAt: time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC),
+			})
+			return account, nil
+		})
+		require.NoError(t, err)
+
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-11"})
+		require.NoError(t, err)
+
+		// John gifts the booking to Jane, who moves it to Thursday
+		moved, err := svc.RescheduleBooking(booked.BookingID, dto.RescheduleInfo{MemberID: "jane_doe"})
+		require.NoError(t, err)
+		assert.Equal(t, "john_doe", moved.PaidBy)
+		_, err = svc.RescheduleBooking(booked.BookingID, dto.RescheduleInfo{BookingDate: "2030-06-13"})
+		require.NoError(t, err)
+
+		// When Jane cancels, John gets his credit back
+		require.NoError(t, svc.CancelBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "jane_doe", BookingDate: "2030-06-13"}))
+		balance, err := svc.GetCreditBalance("john_doe")
+		require.NoError(t, err)
+		assert.Equal(t, 2, balance.Credits)
+		ledger, err := svc.GetCreditLedger("jane_doe")
+		require.NoError(t, err)
+		assert.Empty(t, ledger)
+	})
+}
+
+func TestRescheduleBooking_Rejected(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe")
+		svc := rescheduleService(t, repo)
+
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "john_doe", BookingDate: "2030-06-11"})
+		require.NoError(t, err)
+		_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "jane_doe", BookingDate: "2030-06-12"})
+		require.NoError(t, err)
+
+		for _, test := range []struct {
+			info dto.RescheduleInfo
+			err  error
+		}{
+			{dto.RescheduleInfo{}, newError.ErrNothingToReschedule},
+			{dto.RescheduleInfo{BookingDate: "2030-06-11"}, newError.ErrNothingToReschedule},
+			{dto.RescheduleInfo{BookingDate: "2030-07-11"}, newError.ErrBookingDatePassed},
+			{dto.RescheduleInfo{BookingDate: "2030-06-01"}, newError.ErrBookingClosed},
+			{dto.RescheduleInfo{ClassName: "Boxing", BookingDate: "2030-06-12"}, newError.ErrClassNotExist},
+			{dto.RescheduleInfo{MemberID: "mem_unknown"}, newError.ErrMemberNotExist},
+			{dto.RescheduleInfo{MemberID: "jane_doe", BookingDate: "2030-06-12"}, newError.ErrDuplicateBooking},
+		} {
+			_, err = svc.RescheduleBooking(booked.BookingID, test.info)
+			assert.Equal(t, test.err, err)
+		}
+
+		// The booking is untouched by the rejected moves
+		booking, err := svc.GetBooking(booked.BookingID)
+		require.NoError(t, err)
+		assert.Equal(t, "john_doe", booking.MemberID)
+		assert.Equal(t, time.Date(2030, 6, 11, 7, 0, 0, 0, time.UTC), booking.Occurrence)
+
+		// Cancelled bookings can not be moved
+		require.NoError(t, svc.CancelBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "john_doe", BookingDate: "2030-06-11"}))
+		_, err = svc.RescheduleBooking(booked.BookingID, dto.RescheduleInfo{BookingDate: "2030-06-13"})
+		assert.Equal(t, newError.ErrBookingNotMovable, err)
+
+		_, err = svc.RescheduleBooking("bkg_unknown", dto.RescheduleInfo{BookingDate: "2030-06-13"})
+		assert.Equal(t, newError.ErrBookingNotExist, err)
+	})
+}
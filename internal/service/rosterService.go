@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	newError "glofox/errors"
+	"glofox/models/dto"
+	"time"
+)
+
+// GetRoster returns the members booked into a class occurrence and those on
+// its waitlist, in order, with the status of their bookings.
+// Who holds a spot is read from a single load of the class. Updates of a class
+// never change a loaded copy, so the roster is consistent even while members
+// book or cancel; a member always shows up either booked or waitlisted.
+func (service *service) GetRoster(className string, query dto.RosterQuery) (dto.Roster, error) {
+	date, err := service.parseBookingDate(query.Date)
+	if err != nil {
+		return dto.Roster{}, newError.ErrInvalidRosterDate
+	}
+	classInfo, exist, err := service.repo.LoadClass(className)
+	if err != nil {
+		return dto.Roster{}, err
+	}
+	if !exist {
+		return dto.Roster{}, newError.ErrClassNotExist
+	}
+	occurrence, err := occurrenceOf(classInfo, date, query.Time)
+	if err != nil {
+		return dto.Roster{}, err
+	}
+
+	roster := dto.Roster{
+		ClassName:  className,
+		Occurrence: localTime(classInfo, occurrence),
+		Capacity:   classInfo.AllowedCapacity,
+		Booked:     make([]dto.RosterEntry, 0, len(classInfo.Bookings[occurrence])),
+		Waitlisted: make([]dto.RosterEntry, 0, len(classInfo.Waitlist[occurrence])),
+	}
+	for _, memberID := range classInfo.Bookings[occurrence] {
+		entry, err := service.rosterEntry(className, occurrence, memberID, dto.BookingConfirmed)
+		if err != nil {
+			return dto.Roster{}, err
+		}
+		roster.Booked = append(roster.Booked, entry)
+	}
+	for index, memberID := range classInfo.Waitlist[occurrence] {
+		entry, err := service.rosterEntry(className, occurrence, memberID, dto.BookingWaitlisted)
+		if err != nil {
+			return dto.Roster{}, err
+		}
+		entry.WaitlistPosition = index + 1
+		roster.Waitlisted = append(roster.Waitlisted, entry)
+	}
+	return roster, nil
+}
+
+// rosterEntry describes a member holding a place in a class occurrence. The
+// place itself decides whether the member is booked or waitlisted; the booking
+// record only adds its id and whether the member checked in or did not show up.
+func (service *service) rosterEntry(className string, occurrence time.Time, memberID, placed string) (dto.RosterEntry, error) {
+	entry := dto.RosterEntry{MemberID: memberID, Status: placed}
+	member, exist, err := service.repo.LoadMember(memberID)
+	if err != nil {
+		return dto.RosterEntry{}, err
+	}
+	if exist {
+		entry.Name = member.Name
+	}
+
+	booking, err := service.occurrenceBooking(className, occurrence, memberID)
+	if errors.Is(err, newError.ErrBookingNotExist) {
+		// Places taken before bookings were recorded have no record
+		return entry, nil
+	}
+	if err != nil {
+		return dto.RosterEntry{}, err
+	}
+	entry.BookingID = booking.ID
+	if placed == dto.BookingConfirmed && booking.Status != dto.BookingWaitlisted {
+		entry.Status = booking.Status
+	}
+	entry.CheckedIn = entry.Status == dto.BookingAttended
+	return entry, nil
+}
@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"fmt"
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/models/dto"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRoster(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		registerMember(t, repo, "john_doe", "jane_doe", "max_doe")
+		svc := rescheduleService(t, repo)
+
+		// Spin takes a single member, so Jane and Max wait in that order
+		booked, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "john_doe", BookingDate: "2030-06-10"})
+		require.NoError(t, err)
+		for _, memberID := range []string{"jane_doe", "max_doe"} {
+			_, err = svc.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: memberID, BookingDate: "2030-06-10", JoinWaitlist: true})
+			require.NoError(t, err)
+		}
+
+		roster, err := svc.GetRoster("Spin", dto.RosterQuery{Date: "2030-06-10"})
+		require.NoError(t, err)
+		assert.Equal(t, "Spin", roster.ClassName)
+		assert.Equal(t, time.Date(2030, 6, 10, 18, 0, 0, 0, time.UTC), roster.Occurrence)
+		assert.Equal(t, 1, roster.Capacity)
+		assert.Equal(t, []dto.RosterEntry{
+			{MemberID: "john_doe", Name: "john_doe", BookingID: booked.BookingID, Status: dto.BookingConfirmed},
+		}, roster.Booked)
+		require.Len(t, roster.Waitlisted, 2)
+		assert.Equal(t, "jane_doe", roster.Waitlisted[0].MemberID)
+		assert.Equal(t, dto.BookingWaitlisted, roster.Waitlisted[0].Status)
+		assert.Equal(t, 1, roster.Waitlisted[0].WaitlistPosition)
+		assert.Equal(t, 2, roster.Waitlisted[1].WaitlistPosition)
+
+		// Once John checks in, the roster shows it
+		_, err = serviceAt(repo, config.Config{}, 10, 17, 30).CheckIn(booked.BookingID)
+		require.NoError(t, err)
+		roster, err = svc.GetRoster("Spin", dto.RosterQuery{Date: "2030-06-10", Time: "18:00"})
+		require.NoError(t, err)
+		assert.Equal(t, dto.BookingAttended, roster.Booked[0].Status)
+		assert.True(t, roster.Booked[0].CheckedIn)
+
+		// An occurrence nobody booked has an empty roster
+		roster, err = svc.GetRoster("Spin", dto.RosterQuery{Date: "2030-06-11"})
+		require.NoError(t, err)
+		assert.Empty(t, roster.Booked)
+		assert.Empty(t, roster.Waitlisted)
+
+		_, err = svc.GetRoster("Spin", dto.RosterQuery{})
+		assert.Equal(t, newError.ErrInvalidRosterDate, err)
+		_, err = svc.GetRoster("Spin", dto.RosterQuery{Date: "2030-07-10"})
+		assert.Equal(t, newError.ErrNoClassOccurrence, err)
+		_, err = svc.GetRoster("Boxing", dto.RosterQuery{Date: "2030-06-10"})
+		assert.Equal(t, newError.ErrClassNotExist, err)
+	})
+}
+
+func TestGetRoster_ConsistentWhileBooking(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		members := make([]string, 12)
+		for i := range members {
+			members[i] = fmt.Sprintf("mem_%02d", i)
+		}
+		registerMember(t, repo, members...)
+		svc := rescheduleService(t, repo)
+
+		var wg sync.WaitGroup
+		for _, memberID := range members {
+			wg.Add(1)
+			go func(memberID string) {
+				defer wg.Done()
+				_, err := svc.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: memberID, BookingDate: "2030-06-10", JoinWaitlist: true})
+				assert.NoError(t, err)
+			}(memberID)
+		}
+
+		// Every roster read meanwhile respects the capacity and lists each member once
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		for reading := true; reading; {
+			select {
+			case <-done:
+				reading = false
+			default:
+			}
+			roster, err := svc.GetRoster("Yoga", dto.RosterQuery{Date: "2030-06-10"})
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(roster.Booked), 2)
+			seen := make(map[string]bool)
+			for _, entry := range append(roster.Booked, roster.Waitlisted...) {
+				assert.False(t, seen[entry.MemberID], "member %s listed twice", entry.MemberID)
+				seen[entry.MemberID] = true
+			}
+		}
+
+		roster, err := svc.GetRoster("Yoga", dto.RosterQuery{Date: "2030-06-10"})
+		require.NoError(t, err)
+		assert.Len(t, roster.Booked, 2)
+		assert.Len(t, roster.Waitlisted, 10)
+	})
+}
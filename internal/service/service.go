@@ -2,31 +2,81 @@ package service
 
 import (
 	"glofox/config"
-	mapstore "glofox/core"
+	"glofox/internal/ical"
+	"glofox/internal/repository"
 	"glofox/models/dto"
-	"sync"
+	"time"
 )
 
+// Clock tells the current time. Time-based rules such as booking windows read
+// it instead of time.Now so that they can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock reading the system time.
+type SystemClock struct{}
+
+// Now returns the current system time in UTC.
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
 // service is the concrete implementation of BusinessService interface.
-// It holds a thread-safe map store (syMap) and a mutex lock to manage concurrent access.
+// It holds the class repository, whose atomic updates guard concurrent access.
 type service struct {
-	syMap mapstore.MapStore
-	lock  *sync.Mutex
-	cfg   config.Config
+	repo   repository.Repository
+	cfg    config.Config
+	clock  Clock
+	studio string // Id of the studio served, "" for the default studio
 }
 
-// BusinessService defines the business logic interface for class and booking operations.
+// BusinessService defines the business logic interface for class, booking, member and credit operations.
 type BusinessService interface {
 	CreateClass(info dto.Class) error
-	CreateBooking(bookingInfo dto.BookingInfo) error
+	UpdateClass(name string, update dto.ClassUpdate) (dto.ClassChange, error)
+	DeleteClass(name string, cascade bool) (dto.ClassChange, error)
+	GetClasses() ([]dto.ClassDetails, error)
+	GetClass(name string) (dto.ClassDetails, error)
+	CreateBooking(bookingInfo dto.BookingInfo) (dto.BookingResult, error)
+	CancelBooking(bookingInfo dto.BookingInfo) error
+	CancelBookingByID(id string) (dto.Booking, error)
+	GetBooking(id string) (dto.Booking, error)
+	RescheduleBooking(id string, info dto.RescheduleInfo) (dto.Booking, error)
+	CheckIn(id string) (dto.Booking, error)
+	GetRoster(className string, query dto.RosterQuery) (dto.Roster, error)
+	CheckInRoster(className string, roster dto.RosterCheckIn) (dto.RosterCheckInResult, error)
+	MarkNoShows() (int, error)
+	GetAttendance(memberID string) (dto.Attendance, error)
+	ClassCalendar() (ical.Calendar, error)
+	MemberCalendar(memberID string) (ical.Calendar, error)
+	ImportClasses(rows []dto.ClassRow, mode string) (dto.ImportResult, error)
+	ExportClasses() ([]dto.Class, error)
+	ExportBookings() ([]dto.Booking, error)
+	GetMemberBookings(memberID string, query dto.BookingQuery) (dto.BookingPage, error)
+	GetWaitlistPosition(bookingInfo dto.BookingInfo) (int, error)
+	CreateMember(info dto.MemberInfo) (dto.Member, error)
+	GetMember(id string) (dto.Member, error)
+	UpdateMember(id string, info dto.MemberInfo) (dto.Member, error)
+	DeactivateMember(id string) (dto.Member, error)
+	CreatePlan(info dto.PlanInfo) (dto.Plan, error)
+	GetPlans() ([]dto.Plan, error)
+	PurchasePlan(memberID string, info dto.PurchaseInfo) (dto.CreditBalance, error)
+	GetCreditBalance(memberID string) (dto.CreditBalance, error)
+	GetCreditLedger(memberID string) ([]dto.CreditEntry, error)
 }
 
 // InitializeService creates and returns a new instance of BusinessService
-// injecting the shared map store and mutex for thread-safe operations.
-func InitializeService(syMap mapstore.MapStore, mu *sync.Mutex, cfg config.Config) BusinessService {
+// injecting the class repository and the application configuration.
+func InitializeService(repo repository.Repository, cfg config.Config) BusinessService {
+	return InitializeServiceWithClock(repo, cfg, SystemClock{})
+}
+
+// InitializeServiceWithClock creates a BusinessService that reads the current time from clock.
+func InitializeServiceWithClock(repo repository.Repository, cfg config.Config, clock Clock) BusinessService {
 	return &service{
-		syMap: syMap,
-		lock:  mu,
+		repo:  repo,
 		cfg:   cfg,
+		clock: clock,
 	}
 }
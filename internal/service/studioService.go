@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/schedule"
+)
+
+// studioID is the form of a studio id: it appears in paths and storage keys.
+var studioID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Studios gives access to the business services of the studios served by one
+// deployment. The service of a studio only reaches the repository of that
+// studio, so studios are isolated whatever the caller passes in, and runs with
+// the configuration as overridden by the studio.
+type Studios interface {
+	Studio(id string) (BusinessService, error) // Returns the service of a studio; "" is the default studio
+	IDs() []string                             // Returns the id of every studio, starting with the default studio ""
+}
+
+// studios is the concrete implementation of Studios.
+type studios struct {
+	ids      []string
+	services map[string]BusinessService
+}
+
+// InitializeStudios creates the business services of the default studio and of
+// every studio in the configuration, each over its own view of repo.
+func InitializeStudios(repo repository.Repository, cfg config.Config) (Studios, error) {
+	return InitializeStudiosWithClock(repo, cfg, SystemClock{})
+}
+
+// InitializeStudiosWithClock creates the services of every studio reading the current time from clock.
+// It rejects studios with a malformed or repeated id and studios in an unknown time zone.
+func InitializeStudiosWithClock(repo repository.Repository, cfg config.Config, clock Clock) (Studios, error) {
+	all := &studios{
+		ids:      []string{""},
+		services: map[string]BusinessService{"": InitializeServiceWithClock(repo.Studio(""), cfg, clock)},
+	}
+	for _, studio := range cfg.Studios {
+		if !studioID.MatchString(studio.ID) {
+			return nil, fmt.Errorf("studio %q: id must be lower case letters, digits and dashes", studio.ID)
+		}
+		if _, ok := all.services[studio.ID]; ok {
+			return nil, fmt.Errorf("studio %q is configured twice", studio.ID)
+		}
+		if _, err := schedule.LoadLocation(studio.Timezone); err != nil {
+			return nil, fmt.Errorf("studio %q: %w", studio.ID, err)
+		}
+		all.ids = append(all.ids, studio.ID)
+		all.services[studio.ID] = &service{repo: repo.Studio(studio.ID), cfg: cfg.ForStudio(studio), clock: clock, studio: studio.ID}
+	}
+	return all, nil
+}
+
+// Studio returns the business service of the studio with the given id.
+func (all *studios) Studio(id string) (BusinessService, error) {
+	services, ok := all.services[id]
+	if !ok {
+		return nil, newError.ErrStudioNotExist
+	}
+	return services, nil
+}
+
+// IDs returns the id of every studio in the order they are configured.
+func (all *studios) IDs() []string {
+	return append([]string(nil), all.ids...)
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"glofox/config"
+	newError "glofox/errors"
+	"glofox/internal/repository"
+	"glofox/internal/service"
+	"glofox/models/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStudios_IsolateData(t *testing.T) {
+	cfg := config.Config{DateFormat: "2006-01-02", Studios: []config.StudioConfig{
+		{ID: "downtown"},
+		{ID: "harbour", DateFormat: "02/01/2006"},
+	}}
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		studios, err := service.InitializeStudiosWithClock(repo, cfg, fixedClock(time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "downtown", "harbour"}, studios.IDs())
+		downtown, err := studios.Studio("downtown")
+		require.NoError(t, err)
+		harbour, err := studios.Studio("harbour")
+		require.NoError(t, err)
+
+		// Both studios run a class called Yoga, with dates in their own format
+		require.NoError(t, downtown.CreateClass(dto.Class{Name: "Yoga", Capacity: 10, StartDate: "2030-06-01", EndDate: "2030-06-30"}))
+		require.NoError(t, harbour.CreateClass(dto.Class{Name: "Yoga", Capacity: 5, StartDate: "01/06/2030", EndDate: "30/06/2030"}))
+		yoga, err := harbour.GetClass("Yoga")
+		require.NoError(t, err)
+		assert.Equal(t, 5, yoga.Capacity)
+		assert.Equal(t, "30/06/2030", yoga.EndDate)
+
+		registerMember(t, repo.Studio("downtown"), "mem_1")
+		booked, err := downtown.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "mem_1", BookingDate: "2030-06-10"})
+		require.NoError(t, err)
+
+		// The other studio knows neither the member nor the booking
+		_, err = harbour.CreateBooking(dto.BookingInfo{ClassName: "Yoga", MemberID: "mem_1", BookingDate: "10/06/2030"})
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+		_, err = harbour.GetMember("mem_1")
+		assert.Equal(t, newError.ErrMemberNotExist, err)
+		_, err = harbour.GetBooking(booked.BookingID)
+		assert.Equal(t, newError.ErrBookingNotExist, err)
+		bookings, err := harbour.ExportBookings()
+		require.NoError(t, err)
+		assert.Empty(t, bookings)
+		yoga, err = harbour.GetClass("Yoga")
+		require.NoError(t, err)
+		assert.Zero(t, yoga.Availability[9].Booked)
+
+		// Nor does the default studio
+		defaultStudio, err := studios.Studio("")
+		require.NoError(t, err)
+		all, err := defaultStudio.GetClasses()
+		require.NoError(t, err)
+		assert.Empty(t, all)
+
+		_, err = studios.Studio("uptown")
+		assert.Equal(t, newError.ErrStudioNotExist, err)
+	})
+}
+
+func TestStudios_OverrideSettings(t *testing.T) {
+	cfg := config.Config{
+		DateFormat:    "2006-01-02",
+		Timezone:      "UTC",
+		BookingPolicy: config.BookingPolicy{OpensHoursBefore: 336},
+		Studios: []config.StudioConfig{{
+			ID: "dublin", Timezone: "Europe/Dublin", BookingPolicy: &config.BookingPolicy{OpensHoursBefore: 24},
+		}},
+	}
+	forEachBackend(t, func(t *testing.T, repo repository.Repository) {
+		studios, err := service.InitializeStudiosWithClock(repo, cfg, fixedClock(time.Date(2030, 6, 1, 20, 0, 0, 0, time.UTC)))
+		require.NoError(t, err)
+		dublin, err := studios.Studio("dublin")
+		require.NoError(t, err)
+		require.NoError(t, dublin.CreateClass(dto.Class{Name: "Spin", Capacity: 5, StartDate: "2030-06-01", EndDate: "2030-06-30",
+			Schedule: &dto.Schedule{StartTime: "18:00", DurationMinutes: 45}}))
+
+		// The class runs in the studio time zone and books under the studio policy
+		spin, err := dublin.GetClass("Spin")
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Dublin", spin.Timezone)
+		assert.Equal(t, 24, *spin.Policy.OpensHoursBefore)
+
+		registerMember(t, repo.Studio("dublin"), "mem_1")
+		_, err = dublin.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "mem_1", BookingDate: "2030-06-05"})
+		assert.Equal(t, newError.ErrBookingNotOpen, err)
+		booked, err := dublin.CreateBooking(dto.BookingInfo{ClassName: "Spin", MemberID: "mem_1", BookingDate: "2030-06-02"})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2030, 6, 2, 17, 0, 0, 0, time.UTC), booked.Occurrence.UTC())
+	})
+}
+
+func TestInitializeStudios_RejectsInvalidStudios(t *testing.T) {
+	for name, studios := range map[string][]config.StudioConfig{
+		"empty id":         {{ID: ""}},
+		"id with slash":    {{ID: "down/town"}},
+		"upper case id":    {{ID: "Downtown"}},
+		"repeated id":      {{ID: "downtown"}, {ID: "downtown"}},
+		"unknown timezone": {{ID: "downtown", Timezone: "Mars/Olympus"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := repository.NewMapRepository(newMemoryStore())
+			_, err := service.InitializeStudios(repo, config.Config{DateFormat: "2006-01-02", Studios: studios})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -2,13 +2,147 @@ package dto
 
 import "time"
 
+// Booking statuses. A booking is confirmed or waitlisted when it is made,
+// and later ends up cancelled, attended or a no-show.
+const (
+	BookingConfirmed  = "confirmed"
+	BookingWaitlisted = "waitlisted"
+	BookingCancelled  = "cancelled"
+	BookingAttended   = "attended"
+	BookingNoShow     = "no-show"
+)
+
+// Channels a booking can be made through. Bookings that do not name one come from the API.
+const (
+	SourceAPI       = "api"
+	SourceWeb       = "web"
+	SourceMobile    = "mobile"
+	SourceFrontDesk = "front-desk"
+)
+
+// BookingInfo identifies a class occurrence and a member. BookingDate is either
+// a date, taken in the time zone of the class, or an RFC 3339 timestamp.
+// Members may leave out MemberID to act for themselves. UserName is the
+// deprecated name of memberId, still accepted from clients written before
+// members were registered; memberId wins when both are sent.
 type BookingInfo struct {
-	ClassName   string `json:"className" validate:"required"`
-	UserName    string `json:"userName" validate:"required"`
+	ClassName    string `json:"className" form:"className" binding:"required"`
+	MemberID     string `json:"memberId" form:"memberId"`
+	UserName     string `json:"userName,omitempty" form:"userName"`
+	BookingDate  string `json:"bookingDate" form:"bookingDate"`
+	BookingTime  string `json:"bookingTime,omitempty" form:"bookingTime"`
+	JoinWaitlist bool   `json:"joinWaitlist" form:"joinWaitlist"`
+	Source       string `json:"source,omitempty" form:"source"` // Channel the booking is made through, e.g. web or mobile
+}
+
+// RescheduleInfo moves a booking to another occurrence, another class or
+// another member. Fields left empty keep their current value, except that
+// moving to another class needs a BookingDate.
+type RescheduleInfo struct {
+	ClassName   string `json:"className"`
 	BookingDate string `json:"bookingDate"`
+	BookingTime string `json:"bookingTime,omitempty"`
+	MemberID    string `json:"memberId"` // Member the booking is handed to, e.g. to gift the spot
 }
 
+// Booking is a single booking of a member into a class occurrence.
+// Occurrence is the start of the class run in the time zone of the class.
+// PaidBy is the member whose credit paid for the booking, which stays the
+// member who gave it away when the booking is handed to another member.
 type Booking struct {
-	UserName    string    `json:"userName"`
-	BookingDate time.Time `json:"bookingDate"`
+	ID         string    `json:"id"`
+	MemberID   string    `json:"memberId"`
+	PaidBy     string    `json:"paidBy,omitempty"`
+	ClassName  string    `json:"className"`
+	Occurrence time.Time `json:"occurrence"`
+	Status     string    `json:"status"`
+	Source     string    `json:"source"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// BookingResult describes the outcome of a booking request: either a confirmed
+// spot in the class, or a place on the waitlist for the requested date.
+// Occurrence is the start of the booked class run in the time zone of the class.
+type BookingResult struct {
+	BookingID        string    `json:"bookingId,omitempty"`
+	Status           string    `json:"status"`
+	Occurrence       time.Time `json:"occurrence"`
+	WaitlistPosition int       `json:"waitlistPosition,omitempty"`
+}
+
+// BookingQuery filters and pages the bookings of a member. From and To are
+// dates in the studio time zone, with To included, or RFC 3339 timestamps.
+// Status may be repeated or hold a comma separated list.
+type BookingQuery struct {
+	From   string   `form:"from"`
+	To     string   `form:"to"`
+	Status []string `form:"status"`
+	Limit  int      `form:"limit"`
+	Offset int      `form:"offset"`
+}
+
+// BookingPage is one page of the bookings of a member, ordered by occurrence start.
+// Total counts every booking matching the query.
+type BookingPage struct {
+	Bookings []Booking `json:"bookings"`
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+// RosterQuery selects the class occurrence of a roster: a date, or an RFC 3339
+// timestamp of the occurrence start, and optionally the start time.
+type RosterQuery struct {
+	Date string `form:"date" binding:"required"`
+	Time string `form:"time"`
+}
+
+// RosterEntry is a member booked or waitlisted for a class occurrence.
+// Status is the status of the booking record, so it shows whether the member
+// checked in; WaitlistPosition is only set on the waitlist.
+type RosterEntry struct {
+	MemberID         string `json:"memberId"`
+	Name             string `json:"name"`
+	BookingID        string `json:"bookingId,omitempty"`
+	Status           string `json:"status"`
+	CheckedIn        bool   `json:"checkedIn"`
+	WaitlistPosition int    `json:"waitlistPosition,omitempty"`
+}
+
+// Roster lists who is booked into a class occurrence and who waits for a spot, in order.
+type Roster struct {
+	ClassName  string        `json:"className"`
+	Occurrence time.Time     `json:"occurrence"`
+	Capacity   int           `json:"capacity"`
+	Booked     []RosterEntry `json:"booked"`
+	Waitlisted []RosterEntry `json:"waitlisted"`
+}
+
+// RosterCheckIn is the payload of a bulk check-in of the members attending a class occurrence.
+type RosterCheckIn struct {
+	BookingDate string   `json:"bookingDate" binding:"required"`
+	BookingTime string   `json:"bookingTime,omitempty"`
+	MemberIDs   []string `json:"memberIds" binding:"required"`
+}
+
+// CheckInFailure explains why a member of a roster could not be checked in.
+type CheckInFailure struct {
+	MemberID string `json:"memberId"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// RosterCheckInResult reports the outcome of a bulk check-in per member.
+type RosterCheckInResult struct {
+	CheckedIn []Booking        `json:"checkedIn"`
+	Failed    []CheckInFailure `json:"failed"`
+}
+
+// Attendance sums up how often a member attended or missed booked classes.
+// BlockedUntil is set while repeated no-shows keep the member from booking.
+type Attendance struct {
+	MemberID     string     `json:"memberId"`
+	Attended     int        `json:"attended"`
+	NoShows      int        `json:"noShows"`
+	BlockedUntil *time.Time `json:"blockedUntil,omitempty"`
 }
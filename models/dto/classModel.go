@@ -3,15 +3,103 @@ package dto
 import "time"
 
 type Class struct {
-	Name      string `json:"className" validate:"required"`
-	Capacity  int    `json:"classCapacity" validate:"required"`
-	StartDate string `json:"startDate" validate:"required"`
-	EndDate   string `json:"endDate" validate:"required"`
+	Name      string         `json:"className" binding:"required"`
+	Capacity  int            `json:"classCapacity" binding:"required"`
+	StartDate string         `json:"startDate" binding:"required"`
+	EndDate   string         `json:"endDate" binding:"required"`
+	Timezone  string         `json:"timezone,omitempty"` // IANA time zone of the class; defaults to the studio time zone
+	Schedule  *Schedule      `json:"schedule,omitempty"`
+	Policy    *BookingPolicy `json:"bookingPolicy,omitempty"`
+}
+
+// Schedule describes when a class recurs within its date range, e.g. every
+// Mon/Wed/Fri at 07:00 for 60 minutes. RRule accepts a subset of RFC 5545
+// (FREQ=DAILY|WEEKLY, INTERVAL, BYDAY, COUNT and UNTIL); its BYDAY takes
+// precedence over Weekdays. A class without a schedule runs every day.
+type Schedule struct {
+	Weekdays        []string `json:"weekdays,omitempty"`
+	StartTime       string   `json:"startTime" binding:"required"`
+	DurationMinutes int      `json:"durationMinutes" binding:"required"`
+	RRule           string   `json:"rrule,omitempty"`
+}
+
+// BookingPolicy holds when a class occurrence can be booked and cancelled,
+// relative to its start. Fields left nil fall back to the studio defaults.
+type BookingPolicy struct {
+	OpensHoursBefore  *int `json:"opensHoursBefore,omitempty"`  // Bookings open this many hours before the start; 0 means as soon as the class exists
+	CutoffMinutes     *int `json:"cutoffMinutes,omitempty"`     // Bookings close this many minutes before the start
+	CancelHoursBefore *int `json:"cancelHoursBefore,omitempty"` // Cancellations close this many hours before the start
 }
 
 type ClassInfo struct {
 	AllowedCapacity int                    `json:"allowedCapacity"`
 	StartDate       time.Time              `json:"classStartDt"`
 	EndDate         time.Time              `json:"classEndDt"`
-	Bookings        map[time.Time][]string `json:"bookings"`
+	Timezone        string                 `json:"timezone,omitempty"` // IANA time zone the schedule runs in; empty is UTC
+	Schedule        *Schedule              `json:"schedule,omitempty"`
+	Policy          *BookingPolicy         `json:"bookingPolicy,omitempty"` // Overrides of the studio booking policy
+	Bookings        map[time.Time][]string `json:"bookings"`                // Member IDs booked per occurrence start
+	Waitlist        map[time.Time][]string `json:"waitlist"`                // Member IDs waiting per occurrence start, in order
+}
+
+// ClassDetails is the read model returned by the class endpoints.
+// Dates and times are rendered in the time zone of the class.
+type ClassDetails struct {
+	Name         string              `json:"className"`
+	Capacity     int                 `json:"classCapacity"`
+	StartDate    string              `json:"startDate"`
+	EndDate      string              `json:"endDate"`
+	Timezone     string              `json:"timezone"`
+	Schedule     *Schedule           `json:"schedule,omitempty"`
+	Policy       BookingPolicy       `json:"bookingPolicy"` // Effective policy, with studio defaults filled in
+	Availability []ClassAvailability `json:"availability"`
+}
+
+// ClassAvailability holds the booked, remaining and waitlisted spots of a single class occurrence.
+// StartTime and EndTime are only set for classes running on a schedule.
+// Start is the local start of the occurrence, or local midnight for classes without a schedule.
+type ClassAvailability struct {
+	Date       string    `json:"date"`
+	Start      time.Time `json:"start"`
+	StartTime  string    `json:"startTime,omitempty"`
+	EndTime    string    `json:"endTime,omitempty"`
+	Booked     int       `json:"booked"`
+	Remaining  int       `json:"remaining"`
+	Waitlisted int       `json:"waitlisted"`
+}
+
+// Policies for bookings that no longer fit a class after an update.
+const (
+	ConflictReject   = "reject"   // Refuse the update (the default)
+	ConflictWaitlist = "waitlist" // Move bookings over the new capacity to the head of the waitlist
+	ConflictCancel   = "cancel"   // Cancel bookings over the new capacity
+)
+
+// ClassUpdate is the payload of PUT and PATCH /class/:name. With PATCH only the
+// fields present are changed; PUT replaces the class and requires capacity and dates.
+// Bookings on occurrences that no longer exist are cancelled unless OnConflict is reject.
+type ClassUpdate struct {
+	Capacity   *int           `json:"classCapacity"`
+	StartDate  *string        `json:"startDate"`
+	EndDate    *string        `json:"endDate"`
+	Timezone   *string        `json:"timezone"`
+	Schedule   *Schedule      `json:"schedule"`
+	Policy     *BookingPolicy `json:"bookingPolicy"`
+	OnConflict string         `json:"onConflict"`
+	Replace    bool           `json:"-"`
+}
+
+// AffectedBooking identifies a member whose booking was moved, promoted or cancelled by a class change.
+// Occurrence is rendered in the time zone of the class.
+type AffectedBooking struct {
+	MemberID   string    `json:"memberId"`
+	Occurrence time.Time `json:"occurrence"`
+}
+
+// ClassChange reports the outcome of updating or deleting a class.
+type ClassChange struct {
+	Class      *ClassDetails     `json:"class,omitempty"`
+	Cancelled  []AffectedBooking `json:"cancelled"`
+	Waitlisted []AffectedBooking `json:"waitlisted"`
+	Promoted   []AffectedBooking `json:"promoted,omitempty"` // Waitlisted members who got a place thanks to the change
 }
@@ -0,0 +1,76 @@
+package dto
+
+import "time"
+
+// Plan types sold by the studio.
+const (
+	PlanPack      = "pack"      // A fixed number of class credits
+	PlanUnlimited = "unlimited" // Any number of classes while the plan is valid
+)
+
+// Credit ledger entry types.
+const (
+	CreditPurchase = "purchase"
+	CreditConsume  = "consume"
+	CreditRefund   = "refund"
+)
+
+// PlanInfo is the payload used to create a membership plan.
+type PlanInfo struct {
+	Name      string `json:"name" binding:"required"`
+	Type      string `json:"type" binding:"required"`
+	Credits   int    `json:"credits,omitempty"`
+	ValidDays int    `json:"validDays" binding:"required"`
+}
+
+// Plan is a membership plan members can buy, such as "10 classes, valid 90 days".
+type Plan struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Credits   int    `json:"credits,omitempty"`
+	ValidDays int    `json:"validDays"`
+}
+
+// PurchaseInfo is the payload used to sell a plan to a member.
+type PurchaseInfo struct {
+	PlanID string `json:"planId" binding:"required"`
+}
+
+// CreditGrant is a plan bought by a member. A pack grant holds the credits
+// still left; an unlimited grant covers every class starting in its validity window.
+type CreditGrant struct {
+	ID        string    `json:"id"`
+	PlanID    string    `json:"planId"`
+	PlanName  string    `json:"planName"`
+	Unlimited bool      `json:"unlimited"`
+	Remaining int       `json:"remaining"`
+	ValidFrom time.Time `json:"validFrom"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreditEntry is one line of a member's credit ledger.
+// Credits is the change to the grant's remaining credits, zero for unlimited grants.
+type CreditEntry struct {
+	Seq        int        `json:"seq"`
+	At         time.Time  `json:"at"`
+	Type       string     `json:"type"`
+	GrantID    string     `json:"grantId"`
+	Credits    int        `json:"credits"`
+	ClassName  string     `json:"className,omitempty"`
+	Occurrence *time.Time `json:"occurrence,omitempty"`
+}
+
+// CreditAccount holds every grant of a member together with the ledger explaining its balance.
+type CreditAccount struct {
+	Grants []CreditGrant `json:"grants"`
+	Ledger []CreditEntry `json:"ledger"`
+}
+
+// CreditBalance is the read model of a member's credits at a point in time.
+type CreditBalance struct {
+	MemberID       string        `json:"memberId"`
+	Credits        int           `json:"credits"`                  // Credits left on packs that have not expired
+	UnlimitedUntil *time.Time    `json:"unlimitedUntil,omitempty"` // End of the current unlimited plan, if any
+	Grants         []CreditGrant `json:"grants"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import newError "glofox/errors"
+
+// Import modes.
+const (
+	ImportAllOrNothing = "all-or-nothing" // Import nothing unless every row is valid (the default)
+	ImportBestEffort   = "best-effort"    // Import the valid rows and report the others
+)
+
+// ClassRow is a class read from an import file. Err is set when the row could
+// not be read into a class, e.g. for a capacity that is not a number.
+type ClassRow struct {
+	Class Class
+	Err   error
+}
+
+// ImportFailure explains why a row of an import was not imported. Row counts
+// the classes of the file from 1, not counting a CSV header.
+type ImportFailure struct {
+	Row       int                   `json:"row"`
+	ClassName string                `json:"className,omitempty"`
+	Code      newError.Code         `json:"code"`
+	Message   string                `json:"message"`
+	Errors    []newError.FieldError `json:"errors,omitempty"`
+}
+
+// ImportResult reports the outcome of a bulk import per row.
+type ImportResult struct {
+	Mode     string          `json:"mode"`
+	Total    int             `json:"total"`
+	Imported []string        `json:"imported"`
+	Failed   []ImportFailure `json:"failed"`
+}
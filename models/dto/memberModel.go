@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+// Member statuses. Only active members can book classes.
+const (
+	MemberActive    = "active"
+	MemberSuspended = "suspended"
+)
+
+// MemberInfo is the payload used to register or update a member.
+// A name is required to register; on update, empty fields keep their current value.
+type MemberInfo struct {
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Status string `json:"status,omitempty"`
+}
+
+// Member is a registered studio member. ID is assigned on registration and never changes.
+type Member struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,5 @@
+package utils
+
+// CSVContentType is the media type of comma separated values, as sent to
+// clients asking for text/csv.
+const CSVContentType = "text/csv"
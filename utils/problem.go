@@ -0,0 +1,35 @@
+package utils
+
+import (
+	newError "glofox/errors"
+	"net/http"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document. Type is "about:blank", so
+// Title is the HTTP status text; the stable error code and the invalid request
+// fields are carried in the Code and Errors extension members.
+type Problem struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     newError.Code         `json:"code"`
+	Errors   []newError.FieldError `json:"errors,omitempty"`
+}
+
+// CreateProblem builds the problem details of err, answered with status for the request path instance.
+func CreateProblem(status int, err error, instance string) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   clientMessage(err),
+		Instance: instance,
+		Code:     newError.CodeOf(err),
+		Errors:   newError.FieldsOf(err),
+	}
+}
@@ -1,12 +1,19 @@
 package utils
 
+import (
+	newError "glofox/errors"
+	"log"
+)
+
 type Response struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Data    string `json:"data,omitempty"`
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Code    newError.Code         `json:"code,omitempty"`   // Stable error code, only set on failures
+	Errors  []newError.FieldError `json:"errors,omitempty"` // Invalid request fields, only set on validation failures
+	Data    interface{}           `json:"data,omitempty"`
 }
 
-func CreateResp(Success bool, Message string, data ...string) Response {
+func CreateResp(Success bool, Message string, data ...interface{}) Response {
 	res := Response{
 		Success: Success,
 		Message: Message,
@@ -16,3 +23,23 @@ func CreateResp(Success bool, Message string, data ...string) Response {
 	}
 	return res
 }
+
+// CreateErrorResp builds the failure envelope for err, carrying its stable code
+// and any field-level validation details.
+func CreateErrorResp(err error) Response {
+	return Response{
+		Success: false,
+		Message: clientMessage(err),
+		Code:    newError.CodeOf(err),
+		Errors:  newError.FieldsOf(err),
+	}
+}
+
+// clientMessage returns the message of err shown to clients. The message of an
+// internal error only helps operators, so it is logged and a generic one is sent.
+func clientMessage(err error) string {
+	if newError.CodeOf(err) == newError.CodeInternal {
+		log.Println("Error: internal error:", err)
+	}
+	return newError.MessageOf(err)
+}